@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+// pkcs11PinEnv names the environment variable holding the PKCS#11 user PIN.
+const pkcs11PinEnv = "CERTGEN_PKCS11_PIN"
+
+// pkcs11Options locates a CA key pair on a PKCS#11 token.
+type pkcs11Options struct {
+	lib   string
+	slot  int
+	keyID string
+}
+
+// loadCA reads a PEM-encoded CA certificate and private key from disk. The
+// key may be RSA, ECDSA or Ed25519. When the certificate file is a bundle,
+// the CA whose certificate matches the key is used.
+func loadCA(fileWriter *fileio.FileWriter, certPath, keyPath string) (*x509.Certificate, crypto.Signer, error) {
+	certs, err := loadCACerts(fileWriter, certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := fileWriter.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := encoding.DecodePEMSigner(keyPEM)
+	encoding.Zero(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load CA key: %w", err)
+	}
+
+	cert, err := certificate.SelectIssuer(certs, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// loadCACerts reads every certificate in a CA certificate file, which may
+// hold a single CA or a bundle such as an intermediate and its root.
+func loadCACerts(fileWriter *fileio.FileWriter, certPath string) ([]*x509.Certificate, error) {
+	certPEM, err := fileWriter.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := encoding.DecodePEMCertificates(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+	return certs, nil
+}
+
+// checkCAExpiry warns when a loaded CA is expired or expires within warnDays.
+// With strict set the warning becomes an error.
+func checkCAExpiry(cert *x509.Certificate, warnDays int, strict bool) error {
+	expiring, err := certificate.CheckExpiry(cert, time.Duration(warnDays)*24*time.Hour)
+	var msg string
+	switch {
+	case err != nil:
+		msg = fmt.Sprintf("CA %v", err)
+	case expiring:
+		msg = fmt.Sprintf("CA certificate %q expires on %s (within %d days)", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339), warnDays)
+	default:
+		return nil
+	}
+
+	if strict {
+		return fmt.Errorf("%s", msg)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	return nil
+}
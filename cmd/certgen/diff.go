@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+// runDiff implements "certgen diff", which lists the fields that differ
+// between two PEM certificates, e.g. to check a rotated certificate against
+// the one it replaces.
+func runDiff(args []string) error {
+	var opts encoding.DiffOptions
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.BoolVar(&opts.IncludeSerial, "serial", false, "Also compare serial numbers")
+	fs.BoolVar(&opts.IncludeValidity, "validity", false, "Also compare the validity periods")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [options] old.pem new.pem\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("two certificate files are required")
+	}
+
+	oldPath, newPath := fs.Arg(0), fs.Arg(1)
+	fileReader := fileio.NewFileWriter("")
+	var certs [2]*x509.Certificate
+	for i, path := range []string{oldPath, newPath} {
+		data, err := fileReader.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if certs[i], err = encoding.DecodePEMCertificate(data); err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+	}
+
+	diffs := encoding.DiffCertificatesWithOptions(certs[0], certs[1], opts)
+	if len(diffs) == 0 {
+		fmt.Printf("✓ %s and %s match\n", oldPath, newPath)
+		return nil
+	}
+	fmt.Printf("%s -> %s:\n", oldPath, newPath)
+	for _, diff := range diffs {
+		fmt.Printf("  %s\n", diff)
+	}
+	return fmt.Errorf("certificates differ in %d field(s)", len(diffs))
+}
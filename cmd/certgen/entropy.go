@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+)
+
+// entropyPollInterval is how often --wait-for-entropy rechecks the kernel.
+const entropyPollInterval = 500 * time.Millisecond
+
+// ensureEntropy runs the pre-flight entropy check before any key is
+// generated. Below opts.minEntropy bits it warns, or with
+// opts.waitEntropy blocks until the kernel reports enough. A threshold above
+// the kernel's pool size is capped to it, as waiting for more would never
+// end.
+func ensureEntropy(opts *runOptions) error {
+	want := opts.minEntropy
+	if want == 0 && opts.waitEntropy {
+		want = certificate.DefaultMinEntropy
+	}
+	if want == 0 {
+		return nil
+	}
+	if pool, err := certificate.KernelPoolSize(); err == nil && want > pool {
+		fmt.Fprintf(os.Stderr, "Warning: --min-entropy %d exceeds the kernel's %d-bit pool and can never be reached; using %d\n", want, pool, pool)
+		want = pool
+	}
+
+	avail, ok, err := certificate.CheckEntropy(certificate.KernelEntropy, want)
+	if err != nil {
+		return err
+	}
+	if avail < 0 {
+		opts.logger.Debugf("Entropy estimate not available on this platform; skipping check\n")
+		return nil
+	}
+	if ok {
+		opts.logger.Debugf("Entropy available: %d bits\n", avail)
+		return nil
+	}
+	if !opts.waitEntropy {
+		fmt.Fprintf(os.Stderr, "Warning: the kernel reports only %d bits of entropy (want %d); keys generated now may be weak. Use --wait-for-entropy to wait for more\n", avail, want)
+		return nil
+	}
+
+	opts.logger.Infof("Waiting for entropy: %d of %d bits available\n", avail, want)
+	avail, err = certificate.WaitForEntropy(context.Background(), certificate.KernelEntropy, want, entropyPollInterval)
+	if err != nil {
+		return err
+	}
+	opts.logger.Debugf("Entropy available: %d bits\n", avail)
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/asn1"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+// stringSliceFlag is a flag.Value that collects every occurrence of a
+// repeatable flag.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// ipFlag collects repeatable IP address values.
+type ipFlag []net.IP
+
+func (f *ipFlag) String() string {
+	ips := make([]string, len(*f))
+	for i, ip := range *f {
+		ips[i] = ip.String()
+	}
+	return strings.Join(ips, ",")
+}
+
+func (f *ipFlag) Set(value string) error {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %q", value)
+	}
+	*f = append(*f, ip)
+	return nil
+}
+
+// extensionFlag collects repeatable --extension values, parsing each as it
+// is given so syntax errors are reported by the flag package.
+type extensionFlag []config.Extension
+
+func (e *extensionFlag) String() string {
+	return fmt.Sprintf("%d extensions", len(*e))
+}
+
+func (e *extensionFlag) Set(value string) error {
+	ext, err := config.ParseExtension(value)
+	if err != nil {
+		return err
+	}
+	*e = append(*e, ext)
+	return nil
+}
+
+// oidFlag collects repeatable dotted-decimal OID values.
+type oidFlag []asn1.ObjectIdentifier
+
+func (o *oidFlag) String() string {
+	oids := make([]string, len(*o))
+	for i, oid := range *o {
+		oids[i] = oid.String()
+	}
+	return strings.Join(oids, ",")
+}
+
+func (o *oidFlag) Set(value string) error {
+	oid, err := config.ParseOID(value)
+	if err != nil {
+		return err
+	}
+	*o = append(*o, oid)
+	return nil
+}
+
+// isFlagSet reports whether the named flag was given on the command line.
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
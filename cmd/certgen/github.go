@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/ghoutput"
+)
+
+// writeGitHubOutput appends the run's output paths and fingerprints to
+// $GITHUB_OUTPUT for later workflow steps. With several leaves, the leaf
+// outputs are numbered from 1, e.g. leaf_cert_2.
+func writeGitHubOutput(opts *runOptions, domain string, root *rootCA, fileWriter *fileio.FileWriter, leaves []*leafFiles) error {
+	if opts.githubOutput == "" {
+		return nil
+	}
+
+	outputs := []ghoutput.Output{
+		{Name: "domain", Value: domain},
+		{Name: "root_cert", Value: fileWriter.GetRootCertPath()},
+		{Name: "root_key", Value: root.keyPath},
+		{Name: "root_fingerprint", Value: encoding.FingerprintSHA256(root.cert)},
+	}
+	if len(leaves) > 1 {
+		outputs = append(outputs, ghoutput.Output{Name: "leaf_count", Value: strconv.Itoa(len(leaves))})
+	}
+	for i, leaf := range leaves {
+		data := leafHookData(leaf, root, fileWriter)
+		leafOutputs := []ghoutput.Output{
+			{Name: "leaf_cert", Value: data.LeafCertPath},
+			{Name: "leaf_key", Value: data.LeafKeyPath},
+			{Name: "pkcs12", Value: data.PKCS12Path},
+			{Name: "leaf_fingerprint", Value: leaf.certSHA256},
+		}
+		if len(leaves) > 1 {
+			leafOutputs = append([]ghoutput.Output{{Name: "leaf_domain", Value: data.Domain}}, leafOutputs...)
+		}
+		for _, out := range leafOutputs {
+			if out.Value == "" {
+				continue
+			}
+			if len(leaves) > 1 {
+				out.Name = fmt.Sprintf("%s_%d", out.Name, i+1)
+			}
+			outputs = append(outputs, out)
+		}
+	}
+
+	if err := ghoutput.Append(opts.githubOutput, outputs); err != nil {
+		return err
+	}
+	opts.logger.Step("Wrote GitHub Actions outputs", opts.githubOutput)
+	return nil
+}
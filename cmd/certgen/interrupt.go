@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+// interruptExitCode is the conventional exit status for a process stopped by
+// SIGINT.
+const interruptExitCode = 130
+
+// cleanupOnInterrupt removes everything recorded in created when the run is
+// interrupted with SIGINT or SIGTERM, so a cancelled run does not leave
+// half-written outputs or PKCS#12 temp files behind. The returned func stops
+// watching once the run has finished and its outputs should be kept.
+func cleanupOnInterrupt(created *fileio.CreatedFiles) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-sigs:
+			removed, err := created.RemoveAll()
+			fmt.Fprintf(os.Stderr, "\nInterrupted (%v); removed %d partial output(s) from this run\n", sig, len(removed))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			os.Exit(interruptExitCode)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/erfianugrah/certgen/pkg/castore"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/logging"
+)
+
+// runIssue implements "certgen issue", which issues a new leaf from the CA
+// kept in a --ca-dir store without touching or re-emitting the root.
+func runIssue(args []string) error {
+	var (
+		caDir      string
+		seqSerials bool
+		quiet      bool
+		opts       runOptions
+		cfg        = config.NewCertificateConfig()
+	)
+
+	fs := flag.NewFlagSet("issue", flag.ExitOnError)
+	fs.StringVar(&caDir, "ca-dir", "", "Directory holding the root CA created with --ca-dir (required)")
+	fs.StringVar(&cfg.Domain, "domain", "", "The domain name for the leaf certificate (required unless --ip is given)")
+	fs.Var((*ipFlag)(&cfg.IPAddresses), "ip", "IP address Subject Alternative Name (repeatable)")
+	fs.Var((*stringSliceFlag)(&cfg.DNSNames), "san", "Additional DNS Subject Alternative Name (repeatable)")
+	fs.BoolVar(&cfg.WithWWW, "with-www", false, "Also add www.<domain> to the SANs when --domain is an apex domain")
+	fs.StringVar(&cfg.Organization, "organization", cfg.Organization, "Organization Name")
+	fs.IntVar(&cfg.ValidityDays, "days", 0, "Validity period for the leaf certificate (0 uses the profile's validity, else 3650)")
+	fs.BoolVar(&cfg.ClampToCA, "clamp-to-ca", false, "Cap the leaf's expiry at the CA's so it never outlives its issuer")
+	fs.IntVar(&cfg.MaxValidityDays, "max-validity", 0, "Reject leaf validity periods longer than this many days; "+config.MaxValidityEnv+" sets an operator cap this can only lower (0 for none)")
+	fs.IntVar(&cfg.KeySize, "key-size", cfg.KeySize, "RSA key size in bits")
+	fs.IntVar(&cfg.LeafKeySize, "leaf-key-size", 0, "RSA key size in bits for the leaf (same as --key-size)")
+	fs.StringVar(&cfg.Profile, "profile", cfg.Profile, "Leaf certificate profile: "+strings.Join(config.ProfileNames(), ", "))
+	fs.StringVar(&cfg.PKCS12Password, "p12-password", cfg.PKCS12Password, "Password for PKCS#12 file")
+	fs.BoolVar(&opts.noPKCS12, "no-pkcs12", false, "Skip the PKCS#12 bundle")
+	fs.IntVar(&opts.minEntropy, "min-entropy", 0, "Warn before generating the key when the kernel's entropy estimate (Linux) is below this many bits (0 disables the check)")
+	fs.BoolVar(&opts.waitEntropy, "wait-for-entropy", false, "Block until the kernel's entropy estimate reaches --min-entropy before generating the key")
+	fs.StringVar(&opts.opensslBin, "openssl-bin", "", "openssl binary to use for PKCS#12 export (defaults to openssl from PATH)")
+	fs.BoolVar(&opts.writeDER, "der", false, "Also write the leaf certificate in DER format")
+	fs.BoolVar(&seqSerials, "sequential-serials", false, "Use increasing serials from serial.txt in --ca-dir instead of random ones")
+	fs.IntVar(&opts.warnDays, "ca-expiry-warn-days", 30, "Warn when the CA expires within this many days")
+	fs.BoolVar(&opts.strict, "strict", false, "Fail instead of warning when the CA is expired or expiring")
+	fs.BoolVar(&quiet, "quiet", false, "Only print the summary")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s issue --ca-dir DIR --domain svc.example.com [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if _, err := config.LoadUserDefaults(cfg); err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if caDir == "" || (cfg.Domain == "" && len(cfg.IPAddresses) == 0) {
+		fs.Usage()
+		return fmt.Errorf("--ca-dir and --domain or --ip are required")
+	}
+	if err := applyMaxValidity(cfg); err != nil {
+		return err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+	if opts.minEntropy < 0 {
+		return fmt.Errorf("--min-entropy must not be negative")
+	}
+	if err := checkOpenSSLBin(opts.opensslBin); err != nil {
+		return fmt.Errorf("--openssl-bin: %w", err)
+	}
+	if err := cfg.Normalize(); err != nil {
+		return err
+	}
+
+	store := castore.NewStore(caDir)
+	exists, err := store.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("no root CA in %s; create one first with --ca-dir %s --root-only", caDir, caDir)
+	}
+	cert, key, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if err := checkCAExpiry(cert, opts.warnDays, opts.strict); err != nil {
+		return err
+	}
+
+	level := logging.LevelNormal
+	if quiet {
+		level = logging.LevelQuiet
+	}
+	opts.logger = logging.New(os.Stdout, level)
+	opts.names = template.Must(fileio.ParseNameTemplate(fileio.DefaultNameTemplate))
+	if seqSerials {
+		opts.serials = store
+	}
+	opts.index = store
+
+	if err := ensureEntropy(&opts); err != nil {
+		return err
+	}
+
+	opts.logger.Infof("Issuing leaf certificate for %s\n\n", cfg.Identity())
+	opts.logger.Step("Loaded Root CA from "+store.Dir(), "")
+
+	leaves, err := issueLeaves(cfg, &rootCA{cert: cert, key: key, keyPath: store.KeyPath()}, &opts)
+	if err != nil {
+		return err
+	}
+	leaf := leaves[0]
+
+	opts.logger.Summaryf("\n✓ Leaf certificate issued from %s\n", store.Dir())
+	opts.logger.Summaryf("\nGenerated files:\n")
+	if leaf.key != "" {
+		opts.logger.Summaryf("  - Leaf key:           %s\n", leaf.key)
+	}
+	opts.logger.Summaryf("  - Leaf cert:          %s\n", leaf.cert)
+	if leaf.p12 != "" {
+		opts.logger.Summaryf("  - PKCS#12 bundle:     %s\n", leaf.p12)
+	} else if leaf.p12Skipped != "" {
+		opts.logger.Summaryf("  - PKCS#12 bundle:     skipped (%s)\n", leaf.p12Skipped)
+	}
+	opts.logger.Summaryf("  - Leaf cert (base64): %s\n", leaf.base64)
+	if leaf.der != "" {
+		opts.logger.Summaryf("  - Leaf cert (DER):    %s\n", leaf.der)
+	}
+	return nil
+}
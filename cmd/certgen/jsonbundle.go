@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/erfianugrah/certgen/pkg/certgen"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/pkcs12"
+)
+
+// runJSONBundle generates a root CA and leaf in memory and writes them as one
+// JSON document to opts.jsonBundle, or stdout for "-", instead of loose files.
+func runJSONBundle(cfg *config.CertificateConfig, opts *runOptions) error {
+	opts.logger.Infof("Generating JSON bundle for %s\n", cfg.Identity())
+
+	bundle, err := certgen.Generate(cfg)
+	if err != nil {
+		return err
+	}
+	opts.logger.Step("Generated root CA and leaf certificate", "")
+
+	var p12 []byte
+	if !opts.noPKCS12 {
+		gen := pkcs12.NewGenerator()
+		gen.SetTempDir(opts.tempDir)
+		gen.SetIncludeCA(!opts.p12NoCA)
+		gen.SetMAC(cfg.PKCS12MACAlgorithm, cfg.PKCS12MACIterations)
+		gen.SetOpenSSLPath(opts.opensslBin)
+		if _, err := gen.OpenSSLPath(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: openssl not found in PATH; leaving p12_base64 out of the JSON bundle (pass --no-pkcs12 to silence this)")
+		} else {
+			if p12, err = gen.GeneratePKCS12(bundle.LeafCert, bundle.LeafKey, bundle.RootCert, cfg.PKCS12Password); err != nil {
+				return fmt.Errorf("failed to create PKCS#12: %w", err)
+			}
+			opts.logger.Step("Generated PKCS#12 bundle", "")
+		}
+	}
+
+	data, err := bundle.JSON(p12)
+	if err != nil {
+		return err
+	}
+	defer encoding.Zero(data)
+
+	if opts.jsonBundle == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write JSON bundle: %w", err)
+		}
+		return nil
+	}
+
+	// The bundle is a private output, so it gets mode 0600 whatever its name
+	fileWriter := newFileWriter(cfg.FileIdentity(), opts)
+	fileWriter.SetJSONBundlePath(opts.jsonBundle)
+	if err := fileWriter.WriteFile(fileWriter.GetJSONBundlePath(), data); err != nil {
+		return err
+	}
+	opts.logger.Summaryf("\n✓ JSON bundle written to %s\n", opts.jsonBundle)
+	return nil
+}
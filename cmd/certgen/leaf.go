@@ -0,0 +1,494 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/logging"
+	"github.com/erfianugrah/certgen/pkg/pkcs12"
+)
+
+// leafFiles records where the outputs for one leaf certificate were written.
+type leafFiles struct {
+	domain  string
+	key     string
+	cert    string
+	der     string
+	p12     string
+	base64  string
+	secret  string
+	haproxy string
+
+	// p12Trust is the key-less PKCS#12 bundle written with --p12-trust.
+	p12Trust string
+
+	// ecdsaKey and ecdsaCert are the second, ECDSA leaf written with
+	// --dual-leaf.
+	ecdsaKey  string
+	ecdsaCert string
+	sshPub    string
+	publicKey string
+
+	fingerprint string
+
+	// certSHA256 is the leaf certificate's SHA-256 fingerprint.
+	certSHA256 string
+
+	// p12Skipped says why no PKCS#12 bundle was written, if one could have
+	// been.
+	p12Skipped string
+}
+
+// issueLeaves issues the leaf certificates of a run: one for cfg.Domain,
+// opts.count indexed leaves derived from it, or one per opts.leafDomains.
+// Leaves for explicit domains are named by full domain so that, say,
+// api.example.com and api.example.org don't overwrite each other.
+func issueLeaves(cfg *config.CertificateConfig, root *rootCA, opts *runOptions) ([]*leafFiles, error) {
+	pkcs12Gen := pkcs12.NewGenerator()
+	pkcs12Gen.SetTempDir(opts.tempDir)
+	pkcs12Gen.SetIncludeCA(!opts.p12NoCA)
+	pkcs12Gen.SetMAC(cfg.PKCS12MACAlgorithm, cfg.PKCS12MACIterations)
+	pkcs12Gen.SetOpenSSLPath(opts.opensslBin)
+
+	if opts.noPKCS12 {
+		opts.p12Skip = "--no-pkcs12"
+	} else if _, err := pkcs12Gen.OpenSSLPath(); err != nil && opts.leafKey == "" {
+		opts.p12Skip = "openssl not found"
+		fmt.Fprintln(os.Stderr, "Warning: openssl not found in PATH; skipping the PKCS#12 bundle (pass --no-pkcs12 to silence this)")
+	}
+
+	if opts.logger.Level() >= logging.LevelVerbose && opts.p12Skip == "" && opts.leafKey == "" {
+		if v, err := pkcs12Gen.OpenSSLVersion(); err == nil {
+			opts.logger.Debugf("  Using %s for PKCS#12 export\n", v)
+		} else {
+			opts.logger.Debugf("  Could not detect openssl version: %v\n", err)
+		}
+	}
+
+	if certificate.NewGenerator(cfg).LeafOutlivesCA(root.cert) {
+		expires := root.cert.NotAfter.Format(time.RFC3339)
+		if cfg.ClampToCA {
+			opts.logger.Infof("  Capping leaf validity at the CA's expiry (%s)\n", expires)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: leaf validity extends past the CA's expiry on %s (pass --clamp-to-ca to cap it)\n", expires)
+		}
+	}
+
+	var leafPub crypto.PublicKey
+	if opts.leafKey != "" {
+		pub, err := loadLeafPublicKey(opts.leafKey)
+		if err != nil {
+			return nil, err
+		}
+		leafPub = pub
+		opts.logger.Debugf("  Using supplied %s leaf key from %s\n", encoding.KeyTypeName(pub), opts.leafKey)
+	}
+
+	if opts.reuseKey != "" {
+		key, err := loadLeafPrivateKey(opts.reuseKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.reusedKey = key
+		opts.logger.Debugf("  Reusing %d-bit leaf key from %s\n", key.N.BitLen(), opts.reuseKey)
+	}
+
+	if len(opts.leafDomains) > 0 {
+		leafOpts := *opts
+		if !isFlagSet("name-template") {
+			leafOpts.names = template.Must(fileio.ParseNameTemplate(fileio.FullNameTemplate))
+		}
+		leaves := make([]*leafFiles, 0, len(opts.leafDomains))
+		for _, domain := range opts.leafDomains {
+			files, err := issueLeaf(cfg.ForDomain(domain), root.cert, root.key, leafPub, pkcs12Gen, &leafOpts)
+			if err != nil {
+				return nil, fmt.Errorf("leaf %s: %w", domain, err)
+			}
+			leaves = append(leaves, files)
+		}
+		return leaves, nil
+	}
+
+	if opts.count <= 1 {
+		files, err := issueLeaf(cfg, root.cert, root.key, leafPub, pkcs12Gen, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*leafFiles{files}, nil
+	}
+
+	leaves := make([]*leafFiles, 0, opts.count)
+	for i := 1; i <= opts.count; i++ {
+		leafCfg := *cfg
+		leafCfg.Domain = config.IndexedDomain(cfg.Domain, i, opts.count)
+		files, err := issueLeaf(&leafCfg, root.cert, root.key, leafPub, pkcs12Gen, opts)
+		if err != nil {
+			return nil, fmt.Errorf("leaf %s: %w", leafCfg.Domain, err)
+		}
+		leaves = append(leaves, files)
+	}
+	return leaves, nil
+}
+
+// loadLeafPublicKey reads the key a leaf is issued for from a PEM public key
+// or, for convenience, a private key file.
+func loadLeafPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leaf key: %w", err)
+	}
+	pub, err := encoding.DecodePEMPublicKey(data)
+	encoding.Zero(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leaf key %s: %w", path, err)
+	}
+	return pub, nil
+}
+
+// loadLeafPrivateKey reads the RSA leaf key whose certificate is being
+// rotated.
+func loadLeafPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leaf key: %w", err)
+	}
+	key, err := encoding.DecodePEMPrivateKey(data)
+	encoding.Zero(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load leaf key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// issueLeaf generates a leaf certificate for cfg.Domain signed by the root and
+// writes its key, certificate, PKCS#12 bundle and base64 files. When leafPub
+// is set the certificate is issued for that key instead, and the key and
+// PKCS#12 files are skipped since there is no private key to put in them.
+func issueLeaf(cfg *config.CertificateConfig, rootCert *x509.Certificate, rootKey crypto.Signer, leafPub crypto.PublicKey, pkcs12Gen *pkcs12.Generator, opts *runOptions) (*leafFiles, error) {
+	if opts.serials != nil {
+		serial, err := opts.serials.NextSerial()
+		if err != nil {
+			return nil, err
+		}
+		leafCfg := *cfg
+		leafCfg.Serial = serial
+		cfg = &leafCfg
+		opts.logger.Debugf("  Using serial %X from %s\n", serial, opts.serials.SerialPath())
+	}
+
+	certGen := certificate.NewGenerator(cfg)
+	fileWriter := newFileWriter(cfg.FileIdentity(), opts)
+
+	var (
+		leafCert *x509.Certificate
+		leafKey  *rsa.PrivateKey
+		err      error
+	)
+	switch {
+	case leafPub != nil:
+		leafCert, err = certGen.GenerateLeafCertificateWithKey(rootCert, rootKey, leafPub)
+	case opts.reusedKey != nil:
+		leafKey = opts.reusedKey
+		leafCert, err = certGen.GenerateLeafCertificateForKey(rootCert, rootKey, leafKey)
+	default:
+		leafCert, leafKey, err = certGen.GenerateLeafCertificate(rootCert, rootKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate leaf certificate: %w", err)
+	}
+	opts.logger.Step("Generated leaf certificate", "")
+	if err := recordIssued(leafCert, opts); err != nil {
+		return nil, err
+	}
+
+	files := &leafFiles{
+		domain: cfg.Identity(),
+		cert:   fileWriter.GetLeafCertPath(),
+		base64: fileWriter.GetLeafBase64Path(),
+	}
+
+	if leafKey != nil {
+		files.key = fileWriter.GetLeafKeyPath()
+		leafKeyPEM, err := encoding.EncodePrivateKey(leafKey, opts.keyFormat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode leaf key: %w", err)
+		}
+		err = fileWriter.WriteFile(files.key, leafKeyPEM)
+		encoding.Zero(leafKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved leaf key", files.key)
+	}
+
+	leafCertPEM, err := encoding.EncodeCertificateToPEM(leafCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode leaf certificate: %w", err)
+	}
+	if err := fileWriter.WriteFile(files.cert, leafCertPEM); err != nil {
+		return nil, err
+	}
+	opts.logger.Step("Saved leaf certificate", files.cert)
+
+	if opts.dualLeaf {
+		if err := issueECDSALeaf(cfg, rootCert, rootKey, fileWriter, files, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.sshPubKey {
+		files.sshPub = fileWriter.GetLeafSSHPublicKeyPath()
+		sshPub, err := encoding.EncodePublicKeyToSSH(leafCert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := fileWriter.WriteFile(files.sshPub, sshPub); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved leaf public key (OpenSSH)", files.sshPub)
+	}
+
+	if opts.publicKey {
+		files.publicKey = fileWriter.GetLeafPublicKeyPEMPath()
+		pubPEM, err := encoding.EncodePublicKeyToPEM(leafCert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := fileWriter.WriteFile(files.publicKey, pubPEM); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved leaf public key (PEM)", files.publicKey)
+	}
+
+	if opts.k8sSecret != "" {
+		files.secret = fileWriter.GetK8sSecretPath()
+		if err := writeK8sSecret(fileWriter, files.secret, opts, leafCertPEM, leafKey, rootCert); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved Kubernetes TLS secret", files.secret)
+	}
+
+	if opts.haproxyPEM {
+		files.haproxy = fileWriter.GetHAProxyPEMPath()
+		if err := writeHAProxyPEM(fileWriter, files.haproxy, opts, leafCertPEM, leafKey, rootCert); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved HAProxy PEM", files.haproxy)
+	}
+
+	if opts.chainPath != "" {
+		if err := fileWriter.AppendFile(opts.chainPath, leafCertPEM); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Appended leaf certificate to chain", opts.chainPath)
+	}
+
+	if opts.writeDER {
+		files.der = fileWriter.GetLeafDERPath()
+		if err := fileWriter.WriteFile(files.der, leafCert.Raw); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved leaf certificate (DER)", files.der)
+	}
+
+	if err := fileWriter.WriteBase64Stream(files.base64, leafCert.Raw); err != nil {
+		return nil, err
+	}
+	opts.logger.Step("Saved leaf certificate (base64)", files.base64)
+
+	files.certSHA256 = encoding.FingerprintSHA256(leafCert)
+	if opts.fingerprint {
+		files.fingerprint = fileWriter.GetLeafFingerprintPath()
+		line := files.certSHA256 + "\n"
+		if err := fileWriter.WriteFile(files.fingerprint, []byte(line)); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved leaf SHA-256 fingerprint", files.fingerprint)
+	}
+
+	// The bundle comes last as it is the only step needing openssl; everything
+	// above is already on disk if it fails.
+	if leafKey != nil && opts.p12Skip != "" {
+		files.p12Skipped = opts.p12Skip
+		opts.logger.Infof("Skipped PKCS#12 bundle (%s)\n", opts.p12Skip)
+	} else if leafKey != nil {
+		files.p12 = fileWriter.GetPKCS12Path()
+		pfxData, err := pkcs12Gen.GeneratePKCS12(leafCert, leafKey, rootCert, cfg.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate PKCS#12: %w", err)
+		}
+		if err := fileWriter.WriteFile(files.p12, pfxData); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Generated PKCS#12 file", files.p12)
+	}
+
+	if opts.p12Trust {
+		files.p12Trust = fileWriter.GetPKCS12TrustPath()
+		trustData, err := pkcs12Gen.GeneratePKCS12TrustStore([]*x509.Certificate{leafCert, rootCert}, cfg.PKCS12Password)
+		if err != nil {
+			return nil, err
+		}
+		if err := fileWriter.WriteFile(files.p12Trust, trustData); err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Generated PKCS#12 trust store", files.p12Trust)
+	}
+
+	return files, nil
+}
+
+// issueECDSALeaf issues an ECDSA leaf alongside the RSA one, with the same
+// subject and SANs, and writes its key and certificate.
+func issueECDSALeaf(cfg *config.CertificateConfig, rootCert *x509.Certificate, rootKey crypto.Signer, fileWriter *fileio.FileWriter, files *leafFiles, opts *runOptions) error {
+	if opts.serials != nil {
+		serial, err := opts.serials.NextSerial()
+		if err != nil {
+			return err
+		}
+		leafCfg := *cfg
+		leafCfg.Serial = serial
+		cfg = &leafCfg
+	}
+
+	cert, key, err := certificate.NewGenerator(cfg).GenerateECDSALeafCertificate(rootCert, rootKey)
+	if err != nil {
+		return fmt.Errorf("failed to generate ECDSA leaf certificate: %w", err)
+	}
+	opts.logger.Step("Generated ECDSA leaf certificate", "")
+	if err := recordIssued(cert, opts); err != nil {
+		return err
+	}
+
+	files.ecdsaKey = fileWriter.GetLeafECDSAKeyPath()
+	keyPEM, err := encoding.EncodeSignerToPEM(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode ECDSA leaf key: %w", err)
+	}
+	err = fileWriter.WriteFile(files.ecdsaKey, keyPEM)
+	encoding.Zero(keyPEM)
+	if err != nil {
+		return err
+	}
+	opts.logger.Step("Saved ECDSA leaf key", files.ecdsaKey)
+
+	files.ecdsaCert = fileWriter.GetLeafECDSACertPath()
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode ECDSA leaf certificate: %w", err)
+	}
+	if err := fileWriter.WriteFile(files.ecdsaCert, certPEM); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved ECDSA leaf certificate", files.ecdsaCert)
+	return nil
+}
+
+// recordIssued appends cert to the CA store's index.txt, if there is one.
+func recordIssued(cert *x509.Certificate, opts *runOptions) error {
+	if opts.index == nil {
+		return nil
+	}
+	if err := opts.index.AppendIndex(cert); err != nil {
+		return err
+	}
+	opts.logger.Debugf("  Recorded serial %X in %s\n", cert.SerialNumber, opts.index.IndexPath())
+	return nil
+}
+
+// writeK8sSecret writes a kubernetes.io/tls Secret named opts.k8sSecret with
+// the leaf certificate and key, and the root as ca.crt.
+func writeK8sSecret(fileWriter *fileio.FileWriter, path string, opts *runOptions, leafCertPEM []byte, leafKey *rsa.PrivateKey, rootCert *x509.Certificate) error {
+	keyPEM, err := encoding.EncodePrivateKey(leafKey, opts.keyFormat)
+	if err != nil {
+		return fmt.Errorf("failed to encode leaf key: %w", err)
+	}
+	defer encoding.Zero(keyPEM)
+	rootPEM, err := encoding.EncodeCertificateToPEM(rootCert)
+	if err != nil {
+		return fmt.Errorf("failed to encode root CA certificate: %w", err)
+	}
+
+	secret, err := encoding.EncodeKubernetesTLSSecret(opts.k8sSecret, leafCertPEM, keyPEM, rootPEM)
+	if err != nil {
+		return err
+	}
+	defer encoding.Zero(secret)
+	return fileWriter.WriteFile(path, secret)
+}
+
+// writeHAProxyPEM writes the leaf key, leaf certificate and root certificate
+// to a single PEM file, in the order HAProxy expects.
+func writeHAProxyPEM(fileWriter *fileio.FileWriter, path string, opts *runOptions, leafCertPEM []byte, leafKey *rsa.PrivateKey, rootCert *x509.Certificate) error {
+	keyPEM, err := encoding.EncodePrivateKey(leafKey, opts.keyFormat)
+	if err != nil {
+		return fmt.Errorf("failed to encode leaf key: %w", err)
+	}
+	defer encoding.Zero(keyPEM)
+	rootPEM, err := encoding.EncodeCertificateToPEM(rootCert)
+	if err != nil {
+		return fmt.Errorf("failed to encode root CA certificate: %w", err)
+	}
+
+	combined := encoding.EncodeHAProxyPEM(keyPEM, leafCertPEM, rootPEM)
+	defer encoding.Zero(combined)
+	return fileWriter.WriteFile(path, combined)
+}
+
+// checkOpenSSLBin fails early when an --openssl-bin override cannot be run,
+// rather than quietly skipping the PKCS#12 bundle as a missing default
+// openssl does.
+func checkOpenSSLBin(bin string) error {
+	if bin == "" {
+		return nil
+	}
+	return requireOpenSSL(bin)
+}
+
+// requireOpenSSL fails when openssl, or bin if set, cannot be found. Outputs
+// that were explicitly requested and need openssl, such as --p12-trust, are
+// checked up front so a missing openssl does not fail the run after the
+// other files are written.
+func requireOpenSSL(bin string) error {
+	gen := pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(bin)
+	_, err := gen.OpenSSLPath()
+	return err
+}
+
+// applyMaxValidity tightens cfg.MaxValidityDays, set from --max-validity, to
+// the operator's CERTGEN_MAX_VALIDITY cap when that is stricter, so the
+// flag can only lower the environment's limit.
+func applyMaxValidity(cfg *config.CertificateConfig) error {
+	envMax, err := config.MaxValidityDaysFromEnv()
+	if err != nil {
+		return err
+	}
+	if envMax > 0 && (cfg.MaxValidityDays <= 0 || envMax < cfg.MaxValidityDays) {
+		cfg.MaxValidityDays = envMax
+	}
+	return nil
+}
+
+// checkMaxValidity enforces the CERTGEN_MAX_VALIDITY cap for subcommands
+// such as sign and renew that take a lifetime rather than a
+// CertificateConfig.
+func checkMaxValidity(validity time.Duration) error {
+	envMax, err := config.MaxValidityDaysFromEnv()
+	if err != nil {
+		return err
+	}
+	if envMax > 0 && validity > time.Duration(envMax)*24*time.Hour {
+		return fmt.Errorf("validity of %d days exceeds the maximum of %d days set in %s", int(validity.Hours()/24), envMax, config.MaxValidityEnv)
+	}
+	return nil
+}
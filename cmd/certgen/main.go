@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/erfianugrah/certgen/pkg/castore"
 	"github.com/erfianugrah/certgen/pkg/certificate"
 	"github.com/erfianugrah/certgen/pkg/config"
 	"github.com/erfianugrah/certgen/pkg/encoding"
 	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/ghoutput"
+	"github.com/erfianugrah/certgen/pkg/hook"
+	"github.com/erfianugrah/certgen/pkg/logging"
 	"github.com/erfianugrah/certgen/pkg/pkcs12"
 )
 
@@ -17,31 +28,250 @@ var (
 	version = "1.0.0"
 )
 
+// subcommands maps the first CLI argument to an alternative entrypoint. When
+// no subcommand matches, certgen runs the default generation flow.
+var subcommands = map[string]func(args []string) error{
+	"sign":   runSign,
+	"ocsp":   runOCSP,
+	"serve":  runServe,
+	"report": runReport,
+	"verify": runVerify,
+	"diff":   runDiff,
+	"issue":  runIssue,
+	"renew":  runRenew,
+}
+
+// runOptions holds CLI settings that control output rather than the
+// certificate contents themselves.
+type runOptions struct {
+	writeDER    bool
+	tempDir     string
+	opensslBin  string
+	jsonBundle  string
+	minEntropy  int
+	waitEntropy bool
+	csrOnly     bool
+	csrDER      bool
+	caDir       string
+	keyFormat   string
+	warnDays    int
+	strict      bool
+	logger      *logging.Logger
+	names       *template.Template
+	checksums   bool
+	base64Wrap  bool
+	crlf        bool
+	p12NoCA     bool
+	caCert      string
+	pkcs11      pkcs11Options
+	count       int
+	chainPath   string
+	rootOnly    bool
+	leafKey     string
+	reuseKey    string
+	noPKCS12    bool
+	certOut     string
+	keyOut      string
+	k8sSecret   string
+	haproxyPEM  bool
+	dualLeaf    bool
+	truststore  bool
+	p12Trust    bool
+	mobile      bool
+	mobileCfg   bool
+	sshPubKey   bool
+	publicKey   bool
+	fingerprint bool
+	postHook    *hook.Hook
+
+	// created records the files written this run for cleanup on interrupt.
+	created *fileio.CreatedFiles
+
+	// serials, when set, hands out sequential leaf serials from the CA
+	// store's counter file.
+	serials *castore.Store
+	// index, when set, is the CA store whose index.txt records every leaf
+	// issued.
+	index *castore.Store
+
+	leafDomains []string
+
+	// systemBundle is the trust bundle the root is appended to, if any.
+	systemBundle string
+
+	// githubOutput is the $GITHUB_OUTPUT file that output paths and
+	// fingerprints are appended to, if any.
+	githubOutput string
+
+	// reusedKey is the private key loaded by issueLeaves from
+	// --reuse-leaf-key, if any.
+	reusedKey *rsa.PrivateKey
+
+	// p12Skip is set by issueLeaves to the reason PKCS#12 bundles are
+	// skipped in this run, if any.
+	p12Skip string
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			if err := cmd(os.Args[2:]); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			return
+		}
+	}
+
 	var (
-		showVersion bool
-		cfg         = config.NewCertificateConfig()
+		showVersion   bool
+		passwordStdin bool
+		verbose       bool
+		quiet         bool
+		logFormat     string
+		quietSuccess  bool
+		sanList       string
+		notBefore     string
+		nameTemplate  string
+		profilesFile  string
+		orgPerCert    string
+		subjectDN     string
+		seqSerials    bool
+		postHook      string
+		postHookShell bool
+		githubOutput  bool
+		hookTimeout   time.Duration
+		showConfig    bool
+		noNormalize   bool
+		cpuProfile    string
+		memProfile    string
+		strictValid   bool
+		opts          runOptions
+		cfg           = config.NewCertificateConfig()
 	)
 
-	flag.StringVar(&cfg.Domain, "domain", "", "The domain name for the leaf certificate (required)")
+	flag.StringVar(&cfg.Domain, "domain", "", "The domain name for the leaf certificate (required unless --ip is given)")
+	flag.Var((*ipFlag)(&cfg.IPAddresses), "ip", "IP address Subject Alternative Name (repeatable); without --domain the first becomes the Common Name")
+	flag.StringVar(&cfg.CommonName, "common-name", "", "Subject Common Name (defaults to --domain, or the first --ip)")
+	flag.BoolVar(&cfg.NoCommonName, "no-common-name", false, "Leave the Common Name out of the leaf subject and mark its Subject Alternative Names critical, as RFC 5280 requires")
+	flag.Var((*stringSliceFlag)(&cfg.DNSNames), "san", "Additional DNS Subject Alternative Name (repeatable)")
+	flag.BoolVar(&cfg.WithWWW, "with-www", false, "Also add www.<domain> to the SANs when --domain is an apex domain such as example.com")
+	flag.StringVar(&sanList, "sans", "", "Comma-separated list of additional DNS Subject Alternative Names")
+	flag.BoolVar(&noNormalize, "no-normalize", false, "Keep domain names verbatim instead of lowercasing them and converting IDNs to punycode")
 	flag.StringVar(&cfg.Country, "country", cfg.Country, "Country Name")
+	flag.BoolVar(&cfg.AllowAnyCountry, "allow-any-country", false, "Accept --country values that are not two-letter ISO 3166 codes")
 	flag.StringVar(&cfg.State, "state", cfg.State, "State or Province Name")
 	flag.StringVar(&cfg.Locality, "locality", cfg.Locality, "Locality Name")
 	flag.StringVar(&cfg.Organization, "organization", cfg.Organization, "Organization Name")
 	flag.StringVar(&cfg.OrganizationalUnit, "organizational_unit", cfg.OrganizationalUnit, "Organizational Unit Name")
-	flag.IntVar(&cfg.ValidityDays, "days", cfg.ValidityDays, "Validity period for the leaf certificate")
+	flag.StringVar(&subjectDN, "subject", "", "Full subject as an RFC 4514 DN (e.g. \"CN=svc,O=Acme,C=US\"); replaces --common-name, --country, --state, --locality, --organization and --organizational_unit")
+	flag.StringVar(&cfg.RootCommonName, "root-cn", "", "Common Name for the root CA (defaults to --domain)")
+	flag.BoolVar(&cfg.RootNoSAN, "no-san", false, "Omit the Subject Alternative Name from the root CA (always set with --root-only)")
+	flag.StringVar(&cfg.CACommonNameSuffix, "ca-cn-suffix", "", "Append this label to the root CA's Common Name, e.g. \"Root CA\" (ignored with --root-cn)")
+	flag.StringVar(&cfg.RootOrganization, "root-organization", "", "Organization Name for the root CA (defaults to --organization)")
+	flag.Var((*stringSliceFlag)(&opts.leafDomains), "leaf-domain", "Issue a separate leaf for this domain from the same root (repeatable); files are prefixed with the full domain")
+	flag.IntVar(&opts.count, "count", 1, "Number of leaf certificates to issue from the root, named <name>-001.<domain> and so on")
+	flag.IntVar(&cfg.ValidityDays, "days", 0, "Validity period for the leaf certificate (0 uses the profile's validity, else 3650)")
+	flag.BoolVar(&cfg.ClampToCA, "clamp-to-ca", false, "Cap the leaf's expiry at the CA's so it never outlives its issuer")
+	flag.IntVar(&cfg.MaxValidityDays, "max-validity", 0, "Reject leaf validity periods longer than this many days; "+config.MaxValidityEnv+" sets an operator cap this can only lower (0 for none)")
+	flag.DurationVar(&cfg.Validity, "validity", 0, "Validity period for the leaf certificate as a duration, e.g. 1h or 30m (excludes --days)")
+	flag.StringVar(&notBefore, "not-before", "", "Fixed validity start time in RFC 3339 format, e.g. 2024-01-01T00:00:00Z (defaults to now)")
+	flag.BoolVar(&passwordStdin, "p12-password-stdin", false, "Read the PKCS#12 password from the first line of stdin")
+	flag.IntVar(&cfg.RootKeySize, "root-key-size", 0, "RSA key size in bits for the root CA (defaults to 4096)")
+	flag.IntVar(&cfg.LeafKeySize, "leaf-key-size", 0, "RSA key size in bits for leaf certificates (defaults to 4096)")
+	flag.StringVar(&cfg.SignatureAlgorithm, "signature-algorithm", "", "Signature algorithm for the certificates and CSR: "+strings.Join(config.SignatureAlgorithms, ", ")+" (defaults to Go's choice for the key)")
+	flag.IntVar(&cfg.SerialBits, "serial-bits", cfg.SerialBits, "Size of the random certificate serial number in bits")
 	flag.StringVar(&cfg.PKCS12Password, "p12-password", cfg.PKCS12Password, "Password for PKCS#12 file")
+	flag.StringVar(&cfg.PKCS12MACAlgorithm, "p12-macalg", "", "PKCS#12 MAC digest: "+strings.Join(pkcs12.MACAlgorithms, ", ")+" (defaults to openssl's choice)")
+	flag.IntVar(&cfg.PKCS12MACIterations, "p12-iter", 0, "PKCS#12 MAC and key encryption iteration count (defaults to openssl's choice)")
+	flag.BoolVar(&opts.noPKCS12, "no-pkcs12", false, "Skip the PKCS#12 bundle, removing the need for openssl")
+	flag.BoolVar(&opts.truststore, "truststore", false, "Also write a PKCS#12 truststore holding only the root CA certificate, for Java clients")
+	flag.BoolVar(&opts.p12Trust, "p12-trust", false, "Also write <prefix>_trust.p12, a PKCS#12 bundle of the leaf and root CA certificates with no private key, for importing into a truststore")
+	flag.BoolVar(&opts.mobile, "mobile", false, "Also write the root CA certificate as DER with a .crt extension, for installing on Android and iOS")
+	flag.BoolVar(&opts.mobileCfg, "mobileconfig", false, "Also write an Apple .mobileconfig profile that installs the root CA on iOS and macOS (implies --mobile)")
+	flag.BoolVar(&opts.p12NoCA, "p12-no-ca", false, "Leave the root CA certificate out of the PKCS#12 bundle")
+	flag.StringVar(&cfg.Profile, "profile", cfg.Profile, "Leaf certificate profile: "+strings.Join(config.ProfileNames(), ", "))
+	flag.BoolVar(&cfg.ServerOnly, "server-only", false, "Leave clientAuth out of the leaf's extended key usages")
+	flag.StringVar(&profilesFile, "profiles-file", "", "JSON file defining additional leaf profiles")
+	flag.StringVar(&orgPerCert, "organization-per-cert", "", "JSON file mapping leaf domains to subject overrides ({\"a.example.com\": {\"organization\": \"Tenant A\"}})")
+	flag.Var((*stringSliceFlag)(&cfg.PermittedDNSDomains), "permit-dns", "Restrict the root CA to issuing for this DNS domain (repeatable)")
+	flag.Var((*stringSliceFlag)(&cfg.ExcludedDNSDomains), "exclude-dns", "Forbid the root CA from issuing for this DNS domain (repeatable)")
+	flag.Var((*extensionFlag)(&cfg.Extensions), "extension", "Custom leaf extension as OID:base64value[:critical], value DER-encoded (repeatable)")
+	flag.BoolVar(&cfg.Precert, "precert", false, "Add the critical CT poison extension, issuing the leaf as a Certificate Transparency pre-certificate (for CT testing; clients reject it)")
+	flag.Var((*oidFlag)(&cfg.PolicyOIDs), "policy-oid", "Certificate policy OID asserted by the root and leaf, e.g. a CPS OID (repeatable)")
+	flag.BoolVar(&cfg.NonCriticalBasicConstraints, "non-critical-basic-constraints", false, "Mark the BasicConstraints extension non-critical (advanced)")
+	flag.BoolVar(&cfg.NonCriticalKeyUsage, "non-critical-key-usage", false, "Mark the KeyUsage extension non-critical (advanced)")
+	flag.StringVar(&opts.caDir, "ca-dir", "", "Directory holding a persistent root CA; created on first use and reused afterwards")
+	flag.BoolVar(&seqSerials, "sequential-serials", false, "Issue leaves with increasing serial numbers from serial.txt in --ca-dir instead of random ones")
+	flag.StringVar(&opts.caCert, "ca-cert", "", "Existing root CA certificate to issue from (used with --pkcs11-lib)")
+	flag.StringVar(&opts.pkcs11.lib, "pkcs11-lib", "", "PKCS#11 module holding the root CA key; the PIN is read from $"+pkcs11PinEnv)
+	flag.IntVar(&opts.pkcs11.slot, "pkcs11-slot", 0, "PKCS#11 slot number")
+	flag.StringVar(&opts.pkcs11.keyID, "pkcs11-key-id", "", "Hex-encoded CKA_ID of the root CA key pair on the token")
+	flag.IntVar(&opts.warnDays, "ca-expiry-warn-days", 30, "Warn when a loaded CA expires within this many days")
+	flag.BoolVar(&opts.strict, "strict", false, "Turn warnings about a loaded CA into errors")
+	flag.BoolVar(&strictValid, "strict-validity", false, "Fail instead of warning when a server leaf is valid for more than 398 days, the most browsers accept")
+	flag.BoolVar(&opts.rootOnly, "root-only", false, "Only generate the root CA; issue leaves later with --ca-dir or certgen sign")
+	flag.BoolVar(&opts.csrOnly, "csr-only", false, "Only generate a leaf key and certificate signing request")
+	flag.BoolVar(&opts.csrDER, "csr-der", false, "With --csr-only, also write the CSR in DER format")
+	flag.StringVar(&opts.reuseKey, "reuse-leaf-key", "", "Issue a fresh leaf certificate for this existing RSA leaf private key PEM instead of generating a new key")
+	flag.StringVar(&opts.leafKey, "leaf-key", "", "Issue the leaf for this existing public (or private) key PEM instead of generating one; no leaf key or PKCS#12 is written")
+	flag.StringVar(&opts.keyFormat, "key-format", encoding.KeyFormatPKCS8, "Private key output format: pkcs8 or pkcs1")
+	flag.StringVar(&nameTemplate, "name-template", fileio.DefaultNameTemplate, "Output file name template with {{.Domain}}, {{.Subdomain}}, {{.Kind}} and {{.Ext}}")
+	flag.StringVar(&opts.certOut, "cert-out", "", "Write the leaf certificate to this path instead of the templated name, e.g. tls.crt")
+	flag.StringVar(&opts.keyOut, "key-out", "", "Write the leaf key to this path instead of the templated name, e.g. tls.key")
+	flag.BoolVar(&opts.fingerprint, "fingerprint-file", false, "Also write the leaf certificate's SHA-256 fingerprint to <prefix>_leaf.sha256.txt")
+	flag.BoolVar(&opts.sshPubKey, "ssh-pubkey", false, "Also write the leaf public key in OpenSSH authorized_keys format to <prefix>_leaf.pub")
+	flag.StringVar(&opts.jsonBundle, "json-bundle", "", "Write the root and leaf certificates, keys and PKCS#12 (base64) as one JSON document to this file, or - for stdout, instead of separate files")
+	flag.BoolVar(&opts.publicKey, "public-key", false, "Also write the leaf public key as a PEM SubjectPublicKeyInfo to <prefix>_leaf.pub.pem")
+	flag.StringVar(&postHook, "post-hook", "", "Command to run after each successful generation, templated with the output paths, e.g. 'cp {{.LeafCertPath}} /etc/tls/'")
+	flag.BoolVar(&githubOutput, "github-output", false, "Append output paths and fingerprints to $GITHUB_OUTPUT for later GitHub Actions steps (on by default when it is set)")
+	flag.BoolVar(&postHookShell, "post-hook-shell", false, "Run --post-hook through sh -c, allowing pipes and redirects")
+	flag.DurationVar(&hookTimeout, "post-hook-timeout", hook.DefaultTimeout, "Time limit for --post-hook")
+	flag.BoolVar(&opts.dualLeaf, "dual-leaf", false, "Also issue an ECDSA P-256 leaf with the same names, written to <prefix>_leaf_ecdsa.pem/.key, for dual-certificate serving")
+	flag.BoolVar(&opts.haproxyPEM, "haproxy-pem", false, "Also write the leaf key, leaf certificate and root CA certificate to <prefix>_haproxy.pem for HAProxy")
+	flag.StringVar(&opts.k8sSecret, "k8s-secret", "", "Also write a kubernetes.io/tls Secret manifest with this name to <prefix>_secret.yaml")
+	flag.BoolVar(&opts.base64Wrap, "base64-wrap", false, "Wrap the base64 output files at 64 columns like a PEM body instead of one long line")
+	flag.BoolVar(&opts.crlf, "crlf", false, "Write PEM files with CRLF line endings for Windows tools")
+	flag.BoolVar(&opts.checksums, "checksums", false, "Write a sha256sum-compatible .sha256 file next to every generated file")
+	flag.StringVar(&opts.chainPath, "append-chain", "", "Also append the leaf certificate PEM to this chain file, creating it if needed")
+	flag.StringVar(&opts.systemBundle, "system-bundle", "", "Also append the root CA certificate to this trust bundle unless it is already there, e.g. /etc/ssl/certs/ca-certificates.crt in a CI container")
+	flag.BoolVar(&opts.writeDER, "der", false, "Also write raw DER-encoded certificates")
+	flag.StringVar(&opts.tempDir, "tmp-dir", "", "Base directory for temporary PKCS#12 files (defaults to $TMPDIR)")
+	flag.IntVar(&opts.minEntropy, "min-entropy", 0, "Warn before generating keys when the kernel's entropy estimate (Linux) is below this many bits (0 disables the check)")
+	flag.BoolVar(&opts.waitEntropy, "wait-for-entropy", false, fmt.Sprintf("Block until the kernel's entropy estimate reaches --min-entropy (default %d bits) before generating keys", certificate.DefaultMinEntropy))
+	flag.StringVar(&opts.opensslBin, "openssl-bin", "", "openssl binary to use for PKCS#12 export, for installs outside PATH (defaults to openssl from PATH)")
+	flag.BoolVar(&verbose, "verbose", false, "Print additional diagnostic output")
+	flag.BoolVar(&quiet, "quiet", false, "Only print the final summary")
+	flag.StringVar(&logFormat, "log-format", "text", "Output format: text, or json for one JSON object per line")
+	flag.BoolVar(&quietSuccess, "quiet-success", false, "Print nothing on success; errors still go to stderr")
+	flag.BoolVar(&showConfig, "show-config", false, "Print the resolved configuration as JSON and exit without generating")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "Write a CPU profile of the run to this file, for go tool pprof")
+	flag.StringVar(&memProfile, "memprofile", "", "Write a heap profile taken at the end of the run to this file, for go tool pprof")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Certificate Generator v%s\n\n", version)
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s sign --csr req.csr --ca-cert rootCA.pem --ca-key rootCA.key\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s issue --ca-dir DIR --domain svc.example.com\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s renew --cert leaf.pem --ca-cert rootCA.pem --ca-key rootCA.key [--key leaf.key]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s ocsp --cert leaf.pem --ca-cert rootCA.pem --ca-key rootCA.key [--status good|revoked]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s serve [--addr :8080]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s report [--dir ./certs] [--within 30d] [--json]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s verify --file chain.pem | --leaf leaf.pem --ca rootCA.pem [--dns example.com]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s diff [--serial] [--validity] old.pem new.pem\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExample:\n")
 		fmt.Fprintf(os.Stderr, "  %s --domain example.com --organization \"My Company\" --days 365\n", os.Args[0])
 	}
 
+	// Per-user defaults go in after the flags are registered, which resets
+	// fields to the flag defaults, and before parsing, so flags still win.
+	if _, err := config.LoadUserDefaults(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	flag.Parse()
 
 	if showVersion {
@@ -49,113 +279,643 @@ func main() {
 		os.Exit(0)
 	}
 
-	if cfg.Domain == "" {
-		fmt.Fprintln(os.Stderr, "Error: --domain flag is required")
-		flag.Usage()
+	cfg.DNSNames = append(cfg.DNSNames, config.ParseDNSNames(sanList)...)
+
+	if passwordStdin {
+		if isFlagSet("p12-password") {
+			fmt.Fprintln(os.Stderr, "Error: --p12-password and --p12-password-stdin are mutually exclusive")
+			os.Exit(1)
+		}
+		password, err := readPasswordFromStdin()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.PKCS12Password = password
+	}
+
+	if cfg.Domain == "" && len(opts.leafDomains) > 0 {
+		cfg.Domain = opts.leafDomains[0]
+	}
+	if profilesFile != "" {
+		if err := config.LoadProfiles(profilesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --profiles-file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if orgPerCert != "" {
+		overrides, err := config.LoadSubjectOverrides(orgPerCert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --organization-per-cert: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.SubjectOverrides = overrides
+	}
+	_, err := config.LookupProfile(cfg.Profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --profile: %v\n", err)
+		os.Exit(1)
+	}
+
+	if subjectDN != "" {
+		subject, err := config.ParseSubjectDN(subjectDN)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --subject: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.ApplySubject(subject)
+	}
+
+	if err := applyMaxValidity(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	if cfg.ValidityDays <= 0 || cfg.ValidityDays > 36500 {
-		fmt.Fprintln(os.Stderr, "Error: --days must be between 1 and 36500 (100 years)")
+	if err := config.Validate(cfg); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", line)
+		}
+		if cfg.Domain == "" && len(cfg.IPAddresses) == 0 {
+			flag.Usage()
+		}
 		os.Exit(1)
 	}
 
-	if err := run(cfg); err != nil {
-		log.Fatalf("Error: %v", err)
+	if opts.rootOnly && opts.csrOnly {
+		fmt.Fprintln(os.Stderr, "Error: --root-only and --csr-only are mutually exclusive")
+		os.Exit(1)
+	}
+	if opts.rootOnly {
+		// A standalone CA only signs; it has no names of its own to serve
+		cfg.RootNoSAN = true
 	}
-}
 
-func run(cfg *config.CertificateConfig) error {
-	certGen := certificate.NewGenerator(cfg)
-	fileWriter := fileio.NewFileWriter(cfg.Domain)
-	pkcs12Gen := pkcs12.NewGenerator()
+	if opts.csrDER && !opts.csrOnly {
+		fmt.Fprintln(os.Stderr, "Error: --csr-der requires --csr-only")
+		os.Exit(1)
+	}
+
+	if opts.reuseKey != "" && (opts.leafKey != "" || opts.rootOnly || opts.csrOnly || opts.count > 1 || len(opts.leafDomains) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --reuse-leaf-key cannot be combined with --leaf-key, --root-only, --csr-only, --count or --leaf-domain")
+		os.Exit(1)
+	}
 
-	fmt.Printf("Generating certificates for domain: %s\n", cfg.Domain)
-	fmt.Printf("Organization: %s\n", cfg.Organization)
-	fmt.Printf("Validity: %d days\n\n", cfg.ValidityDays)
+	if opts.leafKey != "" && (opts.rootOnly || opts.csrOnly) {
+		fmt.Fprintln(os.Stderr, "Error: --leaf-key cannot be combined with --root-only or --csr-only")
+		os.Exit(1)
+	}
 
-	rootCert, rootKey, err := certGen.GenerateRootCA()
-	if err != nil {
-		return fmt.Errorf("failed to generate root CA: %w", err)
+	if (opts.sshPubKey || opts.publicKey || opts.fingerprint) && (opts.rootOnly || opts.csrOnly) {
+		fmt.Fprintln(os.Stderr, "Error: --ssh-pubkey, --public-key and --fingerprint-file need a leaf certificate; they cannot be combined with --root-only or --csr-only")
+		os.Exit(1)
 	}
-	fmt.Println("✓ Generated Root CA certificate")
 
-	rootKeyPEM, err := encoding.EncodePrivateKeyToPEM(rootKey)
-	if err != nil {
-		return fmt.Errorf("failed to encode root key: %w", err)
+	if opts.truststore && opts.csrOnly {
+		fmt.Fprintln(os.Stderr, "Error: --truststore cannot be combined with --csr-only")
+		os.Exit(1)
 	}
-	if err := fileWriter.WriteFile(fileWriter.GetRootKeyPath(), rootKeyPEM); err != nil {
-		return err
+
+	if opts.p12Trust && (opts.rootOnly || opts.csrOnly || opts.jsonBundle != "") {
+		fmt.Fprintln(os.Stderr, "Error: --p12-trust needs a leaf certificate; it cannot be combined with --root-only, --csr-only or --json-bundle")
+		os.Exit(1)
 	}
-	fmt.Printf("✓ Saved Root CA key: %s\n", fileWriter.GetRootKeyPath())
 
-	rootCertPEM, err := encoding.EncodeCertificateToPEM(rootCert)
+	if opts.systemBundle != "" && (opts.csrOnly || opts.jsonBundle != "") {
+		fmt.Fprintln(os.Stderr, "Error: --system-bundle cannot be combined with --csr-only or --json-bundle")
+		os.Exit(1)
+	}
+
+	opts.mobile = opts.mobile || opts.mobileCfg
+	if opts.mobile && (opts.csrOnly || opts.jsonBundle != "") {
+		fmt.Fprintln(os.Stderr, "Error: --mobile and --mobileconfig cannot be combined with --csr-only or --json-bundle")
+		os.Exit(1)
+	}
+
+	if opts.dualLeaf && (opts.rootOnly || opts.csrOnly) {
+		fmt.Fprintln(os.Stderr, "Error: --dual-leaf cannot be combined with --root-only or --csr-only")
+		os.Exit(1)
+	}
+
+	if opts.haproxyPEM && (opts.leafKey != "" || opts.rootOnly || opts.csrOnly) {
+		fmt.Fprintln(os.Stderr, "Error: --haproxy-pem needs the leaf private key; it cannot be combined with --leaf-key, --root-only or --csr-only")
+		os.Exit(1)
+	}
+
+	if opts.k8sSecret != "" {
+		if opts.leafKey != "" || opts.rootOnly || opts.csrOnly || opts.count > 1 {
+			fmt.Fprintln(os.Stderr, "Error: --k8s-secret needs a single generated leaf; it cannot be combined with --leaf-key, --root-only, --csr-only or --count")
+			os.Exit(1)
+		}
+		if err := encoding.ValidateSecretName(opts.k8sSecret); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --k8s-secret: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(opts.leafDomains) > 0 && (opts.count > 1 || opts.rootOnly || opts.csrOnly || opts.certOut != "" || opts.keyOut != "" || opts.k8sSecret != "") {
+		fmt.Fprintln(os.Stderr, "Error: --leaf-domain cannot be combined with --count, --root-only, --csr-only, --cert-out, --key-out or --k8s-secret")
+		os.Exit(1)
+	}
+
+	if opts.jsonBundle != "" && (opts.rootOnly || opts.csrOnly || opts.caDir != "" || opts.pkcs11.lib != "" || opts.leafKey != "" || opts.reuseKey != "" || opts.count > 1 || len(opts.leafDomains) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --json-bundle generates a fresh root and one leaf in memory; it cannot be combined with --root-only, --csr-only, --ca-dir, --pkcs11-lib, --leaf-key, --reuse-leaf-key, --count or --leaf-domain")
+		os.Exit(1)
+	}
+
+	if opts.jsonBundle != "" && (postHook != "" || githubOutput || opts.truststore || opts.haproxyPEM || opts.k8sSecret != "" || opts.publicKey || opts.writeDER || opts.crlf || isFlagSet("key-format") || opts.certOut != "" || opts.keyOut != "" || opts.dualLeaf) {
+		fmt.Fprintln(os.Stderr, "Error: --json-bundle writes only the JSON document; it cannot be combined with --post-hook, --github-output, --truststore, --haproxy-pem, --k8s-secret, --public-key, --der, --crlf, --key-format, --cert-out, --key-out or --dual-leaf")
+		os.Exit(1)
+	}
+	if opts.jsonBundle == "-" && opts.checksums {
+		fmt.Fprintln(os.Stderr, "Error: --checksums needs a file; it cannot be used with --json-bundle -")
+		os.Exit(1)
+	}
+
+	if opts.count > 1 && (opts.certOut != "" || opts.keyOut != "") {
+		fmt.Fprintln(os.Stderr, "Error: --cert-out and --key-out cannot be used with --count")
+		os.Exit(1)
+	}
+
+	if !noNormalize {
+		if err := cfg.Normalize(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for i, domain := range opts.leafDomains {
+			if opts.leafDomains[i], err = config.NormalizeDNSName(domain); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --leaf-domain: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if _, ok := config.WWWName(cfg.Domain); cfg.WithWWW && cfg.Domain != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Warning: --with-www has no effect: %s is not an apex domain\n", cfg.Domain)
+	}
+
+	if !cfg.AllowAnyCountry {
+		// Already checked by Validate; this only uppercases
+		cfg.Country, _ = config.NormalizeCountry(cfg.Country)
+	}
+
+	if opts.count < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --count must be at least 1")
+		os.Exit(1)
+	}
+	if opts.count > 1 && cfg.Domain == "" {
+		fmt.Fprintln(os.Stderr, "Error: --count needs --domain to number the leaves; it cannot be used for IP-only certificates")
+		os.Exit(1)
+	}
+
+	if opts.minEntropy < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --min-entropy must not be negative")
+		os.Exit(1)
+	}
+
+	if err := checkOpenSSLBin(opts.opensslBin); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --openssl-bin: %v\n", err)
+		os.Exit(1)
+	}
+	if opts.p12Trust {
+		if err := requireOpenSSL(opts.opensslBin); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --p12-trust needs openssl: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	opts.githubOutput = ghoutput.Path()
+	if githubOutput && opts.githubOutput == "" {
+		fmt.Fprintf(os.Stderr, "Error: --github-output needs $%s to name the output file\n", ghoutput.EnvVar)
+		os.Exit(1)
+	}
+
+	if postHook != "" {
+		if opts.csrOnly {
+			fmt.Fprintln(os.Stderr, "Error: --post-hook cannot be combined with --csr-only")
+			os.Exit(1)
+		}
+		h, err := hook.Parse(postHook, postHookShell)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --post-hook: %v\n", err)
+			os.Exit(1)
+		}
+		h.SetTimeout(hookTimeout)
+		opts.postHook = h
+	}
+
+	if isFlagSet("validity") {
+		if isFlagSet("days") {
+			fmt.Fprintln(os.Stderr, "Error: --validity and --days are mutually exclusive")
+			os.Exit(1)
+		}
+		if cfg.Validity <= 0 {
+			fmt.Fprintln(os.Stderr, "Error: --validity must be positive")
+			os.Exit(1)
+		}
+	}
+
+	// The default lifetime is deliberately long for local development, so
+	// only warn when the profile or validity was chosen
+	validityChosen := isFlagSet("days") || isFlagSet("validity") || isFlagSet("profile")
+	if (validityChosen || strictValid) && !opts.rootOnly && !opts.csrOnly && cfg.ExceedsBrowserValidity() {
+		msg := fmt.Sprintf("server leaf validity exceeds %d days; browsers will reject it", config.BrowserMaxValidityDays)
+		if strictValid {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s (use --days %d or --profile webserver)\n", msg, config.BrowserMaxValidityDays)
+	}
+
+	if notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: --not-before must be an RFC 3339 timestamp, e.g. 2024-01-01T00:00:00Z")
+			os.Exit(1)
+		}
+		cfg.NotBefore = &t
+	}
+
+	names, err := fileio.ParseNameTemplate(nameTemplate)
 	if err != nil {
-		return fmt.Errorf("failed to encode root certificate: %w", err)
+		fmt.Fprintf(os.Stderr, "Error: --name-template: %v\n", err)
+		os.Exit(1)
 	}
-	if err := fileWriter.WriteFile(fileWriter.GetRootCertPath(), rootCertPEM); err != nil {
-		return err
+	opts.names = names
+
+	if opts.keyFormat != encoding.KeyFormatPKCS8 && opts.keyFormat != encoding.KeyFormatPKCS1 {
+		fmt.Fprintln(os.Stderr, "Error: --key-format must be pkcs8 or pkcs1")
+		os.Exit(1)
+	}
+
+	if cfg.SerialBits < config.MinSerialBits || cfg.SerialBits > config.MaxSerialBits {
+		fmt.Fprintf(os.Stderr, "Error: --serial-bits must be between %d and %d\n", config.MinSerialBits, config.MaxSerialBits)
+		os.Exit(1)
+	}
+
+	if seqSerials {
+		if opts.caDir == "" {
+			fmt.Fprintln(os.Stderr, "Error: --sequential-serials requires --ca-dir")
+			os.Exit(1)
+		}
+		if isFlagSet("serial-bits") {
+			fmt.Fprintln(os.Stderr, "Error: --sequential-serials and --serial-bits are mutually exclusive")
+			os.Exit(1)
+		}
+		opts.serials = castore.NewStore(opts.caDir)
+	}
+	if opts.caDir != "" {
+		store := castore.NewStore(opts.caDir)
+		store.SetChecksums(opts.checksums)
+		opts.index = store
+	}
+
+	if cfg.PKCS12MACAlgorithm != "" && !slices.Contains(pkcs12.MACAlgorithms, cfg.PKCS12MACAlgorithm) {
+		fmt.Fprintf(os.Stderr, "Error: --p12-macalg must be one of %s\n", strings.Join(pkcs12.MACAlgorithms, ", "))
+		os.Exit(1)
+	}
+	if cfg.PKCS12MACIterations < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --p12-iter must not be negative")
+		os.Exit(1)
 	}
-	fmt.Printf("✓ Saved Root CA certificate: %s\n", fileWriter.GetRootCertPath())
 
-	leafCert, leafKey, err := certGen.GenerateLeafCertificate(rootCert, rootKey)
+	if opts.pkcs11.lib != "" {
+		if opts.caCert == "" || opts.pkcs11.keyID == "" {
+			fmt.Fprintln(os.Stderr, "Error: --pkcs11-lib requires --ca-cert and --pkcs11-key-id")
+			os.Exit(1)
+		}
+		if opts.caDir != "" {
+			fmt.Fprintln(os.Stderr, "Error: --pkcs11-lib and --ca-dir are mutually exclusive")
+			os.Exit(1)
+		}
+	} else if opts.caCert != "" {
+		fmt.Fprintln(os.Stderr, "Error: --ca-cert requires --pkcs11-lib")
+		os.Exit(1)
+	}
+
+	if showConfig {
+		out, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		fmt.Println(string(out))
+		os.Exit(0)
+	}
+
+	level := logging.LevelNormal
+	switch {
+	case quietSuccess:
+		level = logging.LevelSilent
+	case quiet:
+		level = logging.LevelQuiet
+	case verbose:
+		level = logging.LevelVerbose
+	}
+	// Keep stdout clean for a JSON bundle written there
+	logOut := os.Stdout
+	if opts.jsonBundle == "-" {
+		logOut = os.Stderr
+	}
+	switch logFormat {
+	case "text":
+		opts.logger = logging.New(logOut, level)
+	case "json":
+		opts.logger = logging.NewJSON(logOut, level)
+	default:
+		fmt.Fprintln(os.Stderr, "Error: --log-format must be text or json")
+		os.Exit(1)
+	}
+
+	stopProfiling, err := startProfiling(cpuProfile, memProfile)
 	if err != nil {
-		return fmt.Errorf("failed to generate leaf certificate: %w", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-	fmt.Println("✓ Generated leaf certificate")
 
-	leafKeyPEM, err := encoding.EncodePrivateKeyToPEM(leafKey)
+	opts.created = fileio.NewCreatedFiles()
+	stop := cleanupOnInterrupt(opts.created)
+	err = run(cfg, &opts)
+	stop()
+	// log.Fatalf skips deferred calls, so finish the profiles first
+	if profErr := stopProfiling(); profErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", profErr)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to encode leaf key: %w", err)
+		log.Fatalf("Error: %v", err)
 	}
-	if err := fileWriter.WriteFile(fileWriter.GetLeafKeyPath(), leafKeyPEM); err != nil {
+}
+
+func run(cfg *config.CertificateConfig, opts *runOptions) error {
+	if err := ensureEntropy(opts); err != nil {
 		return err
 	}
-	fmt.Printf("✓ Saved leaf key: %s\n", fileWriter.GetLeafKeyPath())
+	if opts.csrOnly {
+		return runCSR(cfg, opts)
+	}
+	if opts.jsonBundle != "" {
+		return runJSONBundle(cfg, opts)
+	}
+
+	// Keep this run's PKCS#12 scratch files in one directory that an
+	// interrupt can remove, since the generator's own cleanup is deferred.
+	if opts.created != nil {
+		tempDir, err := pkcs12.CreateTempDir(opts.tempDir)
+		if err != nil {
+			return err
+		}
+		opts.created.Add(tempDir)
+		defer os.RemoveAll(tempDir)
+		opts.tempDir = tempDir
+	}
+
+	fileWriter := newFileWriter(cfg.FileIdentity(), opts)
+
+	if opts.rootOnly {
+		opts.logger.Infof("Generating root CA for %s\n", cfg.Identity())
+		opts.logger.Infof("Organization: %s\n\n", cfg.Organization)
+	} else {
+		opts.logger.Infof("Generating certificates for %s\n", cfg.Identity())
+		opts.logger.Infof("Organization: %s\n", cfg.Organization)
+		if cfg.Validity > 0 {
+			opts.logger.Infof("Validity: %s\n\n", cfg.Validity)
+		} else {
+			opts.logger.Infof("Validity: %d days\n\n", cfg.LeafValidityDays())
+		}
+	}
 
-	leafCertPEM, err := encoding.EncodeCertificateToPEM(leafCert)
+	root, err := setupRoot(cfg, fileWriter, opts)
 	if err != nil {
-		return fmt.Errorf("failed to encode leaf certificate: %w", err)
+		return err
 	}
-	if err := fileWriter.WriteFile(fileWriter.GetLeafCertPath(), leafCertPEM); err != nil {
+	defer root.close()
+
+	if err := writeRoot(root, fileWriter, opts); err != nil {
 		return err
 	}
-	fmt.Printf("✓ Saved leaf certificate: %s\n", fileWriter.GetLeafCertPath())
 
-	pfxData, err := pkcs12Gen.GeneratePKCS12(leafCert, leafKey, rootCert, cfg.PKCS12Password)
+	if opts.systemBundle != "" {
+		if err := appendSystemBundle(root, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.truststore {
+		if err := writeTruststore(root, fileWriter, cfg, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.mobile {
+		if err := writeMobile(root, fileWriter, opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.rootOnly {
+		if err := runPostHook(opts, hook.Data{
+			Domain:       cfg.Identity(),
+			RootCertPath: fileWriter.GetRootCertPath(),
+			RootKeyPath:  root.keyPath,
+		}); err != nil {
+			return err
+		}
+		if err := writeGitHubOutput(opts, cfg.Identity(), root, fileWriter, nil); err != nil {
+			return err
+		}
+
+		opts.logger.Summaryf("\n✓ Root CA generation completed successfully!\n")
+		opts.logger.Summaryf("\nGenerated files:\n")
+		opts.logger.Summaryf("  - Root CA key:        %s\n", root.keyPath)
+		opts.logger.Summaryf("  - Root CA cert:       %s\n", fileWriter.GetRootCertPath())
+		if opts.truststore {
+			opts.logger.Summaryf("  - Truststore:         %s\n", fileWriter.GetTruststorePath())
+		}
+		if opts.mobile {
+			opts.logger.Summaryf("  - Root CA (mobile):   %s\n", fileWriter.GetRootCRTPath())
+		}
+		if opts.mobileCfg {
+			opts.logger.Summaryf("  - Mobile profile:     %s\n", fileWriter.GetRootMobileConfigPath())
+		}
+		opts.logger.Summaryf("  - Root CA (base64):   %s\n", fileWriter.GetRootBase64Path())
+		if opts.writeDER {
+			opts.logger.Summaryf("  - Root CA (DER):      %s\n", fileWriter.GetRootDERPath())
+		}
+		return nil
+	}
+
+	leaves, err := issueLeaves(cfg, root, opts)
 	if err != nil {
-		return fmt.Errorf("failed to generate PKCS#12: %w", err)
+		return err
+	}
+
+	for _, leaf := range leaves {
+		if err := runPostHook(opts, leafHookData(leaf, root, fileWriter)); err != nil {
+			return err
+		}
 	}
-	if err := fileWriter.WriteFile(fileWriter.GetPKCS12Path(), pfxData); err != nil {
+	if err := writeGitHubOutput(opts, cfg.Identity(), root, fileWriter, leaves); err != nil {
 		return err
 	}
-	fmt.Printf("✓ Generated PKCS#12 file: %s\n", fileWriter.GetPKCS12Path())
 
-	leafBase64, err := encoding.ConvertCertificateToBase64DER(leafCert)
+	opts.logger.Summaryf("\n✓ Certificate generation completed successfully!\n")
+	opts.logger.Summaryf("\nGenerated files:\n")
+	opts.logger.Summaryf("  - Root CA key:        %s\n", root.keyPath)
+	opts.logger.Summaryf("  - Root CA cert:       %s\n", fileWriter.GetRootCertPath())
+	if opts.truststore {
+		opts.logger.Summaryf("  - Truststore:         %s\n", fileWriter.GetTruststorePath())
+	}
+	if opts.mobile {
+		opts.logger.Summaryf("  - Root CA (mobile):   %s\n", fileWriter.GetRootCRTPath())
+	}
+	if opts.mobileCfg {
+		opts.logger.Summaryf("  - Mobile profile:     %s\n", fileWriter.GetRootMobileConfigPath())
+	}
+	if len(leaves) == 1 {
+		leaf := leaves[0]
+		if leaf.key != "" {
+			opts.logger.Summaryf("  - Leaf key:           %s\n", leaf.key)
+		}
+		opts.logger.Summaryf("  - Leaf cert:          %s\n", leaf.cert)
+		if leaf.ecdsaCert != "" {
+			opts.logger.Summaryf("  - ECDSA leaf key:     %s\n", leaf.ecdsaKey)
+			opts.logger.Summaryf("  - ECDSA leaf cert:    %s\n", leaf.ecdsaCert)
+		}
+		if leaf.sshPub != "" {
+			opts.logger.Summaryf("  - Leaf key (OpenSSH): %s\n", leaf.sshPub)
+		}
+		if leaf.publicKey != "" {
+			opts.logger.Summaryf("  - Leaf public key:    %s\n", leaf.publicKey)
+		}
+		if leaf.secret != "" {
+			opts.logger.Summaryf("  - Kubernetes secret:  %s\n", leaf.secret)
+		}
+		if leaf.haproxy != "" {
+			opts.logger.Summaryf("  - HAProxy PEM:        %s\n", leaf.haproxy)
+		}
+		if leaf.p12 != "" {
+			opts.logger.Summaryf("  - PKCS#12 bundle:     %s\n", leaf.p12)
+		} else if leaf.p12Skipped != "" {
+			opts.logger.Summaryf("  - PKCS#12 bundle:     skipped (%s)\n", leaf.p12Skipped)
+		}
+		if leaf.p12Trust != "" {
+			opts.logger.Summaryf("  - PKCS#12 trust:      %s\n", leaf.p12Trust)
+		}
+		opts.logger.Summaryf("  - Root CA (base64):   %s\n", fileWriter.GetRootBase64Path())
+		opts.logger.Summaryf("  - Leaf cert (base64): %s\n", leaf.base64)
+		if leaf.fingerprint != "" {
+			opts.logger.Summaryf("  - Leaf fingerprint:   %s\n", leaf.fingerprint)
+		}
+		if opts.writeDER {
+			opts.logger.Summaryf("  - Root CA (DER):      %s\n", fileWriter.GetRootDERPath())
+			opts.logger.Summaryf("  - Leaf cert (DER):    %s\n", leaf.der)
+		}
+	} else {
+		opts.logger.Summaryf("  - Root CA (base64):   %s\n", fileWriter.GetRootBase64Path())
+		if opts.writeDER {
+			opts.logger.Summaryf("  - Root CA (DER):      %s\n", fileWriter.GetRootDERPath())
+		}
+		extras := "key, PKCS#12 and base64 files"
+		switch {
+		case opts.leafKey != "":
+			extras = "base64 files"
+		case leaves[0].p12 == "":
+			extras = "key and base64 files"
+		}
+		opts.logger.Summaryf("  - Leaf certs:         %s ... %s (%d, each with %s)\n",
+			leaves[0].cert, leaves[len(leaves)-1].cert, len(leaves), extras)
+	}
+
+	return nil
+}
+
+// leafHookData describes a generated leaf and its root for --post-hook and
+// --github-output.
+func leafHookData(leaf *leafFiles, root *rootCA, fileWriter *fileio.FileWriter) hook.Data {
+	return hook.Data{
+		Domain:       leaf.domain,
+		RootCertPath: fileWriter.GetRootCertPath(),
+		RootKeyPath:  root.keyPath,
+		LeafCertPath: leaf.cert,
+		LeafKeyPath:  leaf.key,
+		PKCS12Path:   leaf.p12,
+	}
+}
+
+// runPostHook runs the --post-hook command, if any, logging its output.
+func runPostHook(opts *runOptions, data hook.Data) error {
+	if opts.postHook == nil {
+		return nil
+	}
+	output, err := opts.postHook.Run(context.Background(), data)
 	if err != nil {
-		return fmt.Errorf("failed to convert leaf certificate to base64: %w", err)
+		// Show what the hook printed regardless of the output level, as it
+		// likely explains the failure.
+		os.Stderr.Write(output)
+		return fmt.Errorf("post-hook for %s: %w", data.Domain, err)
 	}
-	if err := fileWriter.WriteBase64File(fileWriter.GetLeafBase64Path(), leafBase64); err != nil {
+	if len(output) > 0 {
+		opts.logger.Infof("%s", output)
+	}
+	opts.logger.Step("Ran post-hook", data.Domain)
+	return nil
+}
+
+// newFileWriter returns a FileWriter for domain honoring the output options.
+func newFileWriter(domain string, opts *runOptions) *fileio.FileWriter {
+	fileWriter := fileio.NewFileWriter(domain)
+	fileWriter.SetNameTemplate(opts.names)
+	fileWriter.SetChecksums(opts.checksums)
+	fileWriter.SetBase64Wrap(opts.base64Wrap)
+	fileWriter.SetCRLF(opts.crlf)
+	fileWriter.SetLeafCertPath(opts.certOut)
+	fileWriter.SetLeafKeyPath(opts.keyOut)
+	fileWriter.SetCreatedFiles(opts.created)
+	return fileWriter
+}
+
+func runCSR(cfg *config.CertificateConfig, opts *runOptions) error {
+	certGen := certificate.NewGenerator(cfg)
+	fileWriter := newFileWriter(cfg.FileIdentity(), opts)
+
+	opts.logger.Infof("Generating certificate signing request for %s\n", cfg.Identity())
+	opts.logger.Infof("Organization: %s\n\n", cfg.Organization)
+
+	leafKey, err := certGen.GeneratePrivateKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate leaf key: %w", err)
+	}
+
+	leafKeyPEM, err := encoding.EncodePrivateKey(leafKey, opts.keyFormat)
+	if err != nil {
+		return fmt.Errorf("failed to encode leaf key: %w", err)
+	}
+	err = fileWriter.WriteFile(fileWriter.GetLeafKeyPath(), leafKeyPEM)
+	encoding.Zero(leafKeyPEM)
+	if err != nil {
 		return err
 	}
+	opts.logger.Step("Saved leaf key", fileWriter.GetLeafKeyPath())
 
-	rootBase64, err := encoding.ConvertCertificateToBase64DER(rootCert)
+	csr, err := certGen.GenerateCertificateRequest(leafKey)
 	if err != nil {
-		return fmt.Errorf("failed to convert root certificate to base64: %w", err)
+		return fmt.Errorf("failed to generate certificate request: %w", err)
 	}
-	if err := fileWriter.WriteBase64File(fileWriter.GetRootBase64Path(), rootBase64); err != nil {
+
+	csrPEM, err := encoding.EncodeCSRToPEM(csr)
+	if err != nil {
+		return fmt.Errorf("failed to encode certificate request: %w", err)
+	}
+	if err := fileWriter.WriteFile(fileWriter.GetLeafCSRPath(), csrPEM); err != nil {
 		return err
 	}
+	opts.logger.Step("Saved certificate request", fileWriter.GetLeafCSRPath())
 
-	fmt.Println("\n✓ Certificate generation completed successfully!")
-	fmt.Printf("\nGenerated files:\n")
-	fmt.Printf("  - Root CA key:        %s\n", fileWriter.GetRootKeyPath())
-	fmt.Printf("  - Root CA cert:       %s\n", fileWriter.GetRootCertPath())
-	fmt.Printf("  - Leaf key:           %s\n", fileWriter.GetLeafKeyPath())
-	fmt.Printf("  - Leaf cert:          %s\n", fileWriter.GetLeafCertPath())
-	fmt.Printf("  - PKCS#12 bundle:     %s\n", fileWriter.GetPKCS12Path())
-	fmt.Printf("  - Root CA (base64):   %s\n", fileWriter.GetRootBase64Path())
-	fmt.Printf("  - Leaf cert (base64): %s\n", fileWriter.GetLeafBase64Path())
+	if opts.csrDER {
+		if err := fileWriter.WriteFile(fileWriter.GetLeafCSRDERPath(), csr.Raw); err != nil {
+			return err
+		}
+		opts.logger.Step("Saved certificate request (DER)", fileWriter.GetLeafCSRDERPath())
+	}
 
+	opts.logger.Summaryf("\n✓ CSR generation completed successfully!\n")
 	return nil
 }
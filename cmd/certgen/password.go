@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readPasswordFromStdin reads a single line from stdin, for feeding the
+// PKCS#12 password from a secrets manager without exposing it in argv.
+func readPasswordFromStdin() (string, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect stdin: %w", err)
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return "", fmt.Errorf("--p12-password-stdin requires the password to be piped in, but stdin is a terminal")
+	}
+	return readPasswordLine(os.Stdin)
+}
+
+func readPasswordLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read password from stdin: %w", err)
+	}
+	if err == io.EOF && line == "" {
+		return "", fmt.Errorf("no password received on stdin")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,45 @@
+//go:build pkcs11
+
+package main
+
+import (
+	"crypto"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// loadPKCS11Signer opens the configured token and returns a signer for the
+// key pair with the given ID. The PIN is read from CERTGEN_PKCS11_PIN so it
+// never appears in the process list. The returned function closes the
+// session.
+func loadPKCS11Signer(opts pkcs11Options) (crypto.Signer, func() error, error) {
+	id, err := hex.DecodeString(opts.keyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid PKCS#11 key ID %q: %w", opts.keyID, err)
+	}
+
+	slot := opts.slot
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       opts.lib,
+		SlotNumber: &slot,
+		Pin:        os.Getenv(pkcs11PinEnv),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open PKCS#11 token: %w", err)
+	}
+
+	signer, err := ctx.FindKeyPair(id, nil)
+	if err != nil {
+		ctx.Close()
+		return nil, nil, fmt.Errorf("failed to find PKCS#11 key %s: %w", opts.keyID, err)
+	}
+	if signer == nil {
+		ctx.Close()
+		return nil, nil, fmt.Errorf("no PKCS#11 key pair with ID %s in slot %d", opts.keyID, opts.slot)
+	}
+
+	return signer, ctx.Close, nil
+}
@@ -0,0 +1,12 @@
+//go:build !pkcs11
+
+package main
+
+import (
+	"crypto"
+	"fmt"
+)
+
+func loadPKCS11Signer(opts pkcs11Options) (crypto.Signer, func() error, error) {
+	return nil, nil, fmt.Errorf("PKCS#11 support is not compiled in; rebuild with -tags pkcs11")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling starts a CPU profile written to cpuPath and arranges for a
+// heap profile to be written to memPath; either may be empty to skip it. The
+// returned func stops the CPU profile, writes the heap profile and closes
+// both files. It must be called before exiting, even when the run failed.
+func startProfiling(cpuPath, memPath string) (stop func() error, err error) {
+	var cpuFile *os.File
+	if cpuPath != "" {
+		cpuFile, err = os.Create(cpuPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			cpuFile.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+	}
+
+	return func() error {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				return fmt.Errorf("failed to write CPU profile: %w", err)
+			}
+		}
+		if memPath == "" {
+			return nil
+		}
+
+		memFile, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("failed to create memory profile: %w", err)
+		}
+		// Collect garbage first so the profile shows live allocations
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(memFile); err != nil {
+			memFile.Close()
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+		if err := memFile.Close(); err != nil {
+			return fmt.Errorf("failed to write memory profile: %w", err)
+		}
+		return nil
+	}, nil
+}
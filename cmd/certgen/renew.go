@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+// runRenew implements "certgen renew", which reissues an existing leaf with
+// the same subject, SANs and key usages but a fresh serial and validity.
+func runRenew(args []string) error {
+	var (
+		certPath   string
+		caCertPath string
+		caKeyPath  string
+		keyPath    string
+		outPath    string
+		keyOutPath string
+		keySize    int
+		days       int
+		warnDays   int
+		strict     bool
+	)
+
+	fs := flag.NewFlagSet("renew", flag.ExitOnError)
+	fs.StringVar(&certPath, "cert", "", "Path to the PEM-encoded certificate to renew (required)")
+	fs.StringVar(&caCertPath, "ca-cert", "", "Path to the PEM-encoded CA certificate (required)")
+	fs.StringVar(&caKeyPath, "ca-key", "", "Path to the PEM-encoded CA private key (required)")
+	fs.StringVar(&keyPath, "key", "", "Reuse this PEM-encoded private key instead of generating a new one; it must match --cert")
+	fs.StringVar(&outPath, "out", "", "Output path for the renewed certificate (defaults to <name>_leaf.pem)")
+	fs.StringVar(&keyOutPath, "key-out", "", "Output path for the new private key (defaults to <name>_leaf.key)")
+	fs.IntVar(&keySize, "key-size", 0, "RSA key size in bits for the new key (defaults to the old key's type and size)")
+	fs.IntVar(&days, "days", 0, "Validity period for the renewed certificate (defaults to the old certificate's lifetime)")
+	fs.IntVar(&warnDays, "ca-expiry-warn-days", 30, "Warn when the CA expires within this many days")
+	fs.BoolVar(&strict, "strict", false, "Fail instead of warning when the CA is expired or expiring")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s renew --cert old_leaf.pem --ca-cert rootCA.pem --ca-key rootCA.key [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if certPath == "" || caCertPath == "" || caKeyPath == "" {
+		fs.Usage()
+		return fmt.Errorf("--cert, --ca-cert and --ca-key are required")
+	}
+	if days < 0 || days > config.MaxValidityDays {
+		return fmt.Errorf("--days must be between 1 and %d (100 years), or 0 to keep the old lifetime", config.MaxValidityDays)
+	}
+	if keySize != 0 && keySize < config.MinKeySize {
+		return fmt.Errorf("--key-size must be at least %d bits", config.MinKeySize)
+	}
+	if keyPath != "" && (keyOutPath != "" || keySize != 0) {
+		return fmt.Errorf("--key-out and --key-size cannot be used with --key; the existing key is reused")
+	}
+
+	fileWriter := fileio.NewFileWriter("")
+
+	oldPEM, err := fileWriter.ReadFile(certPath)
+	if err != nil {
+		return err
+	}
+	old, err := encoding.DecodePEMCertificate(oldPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate to renew: %w", err)
+	}
+
+	validity := old.NotAfter.Sub(old.NotBefore)
+	if days > 0 {
+		validity = time.Duration(days) * 24 * time.Hour
+	}
+	if err := checkMaxValidity(validity); err != nil {
+		return err
+	}
+
+	caCert, caKey, err := loadCA(fileWriter, caCertPath, caKeyPath)
+	if err != nil {
+		return err
+	}
+	if err := checkCAExpiry(caCert, warnDays, strict); err != nil {
+		return err
+	}
+
+	var (
+		pub    crypto.PublicKey
+		keyPEM []byte
+	)
+	if keyPath != "" {
+		data, err := fileWriter.ReadFile(keyPath)
+		if err != nil {
+			return err
+		}
+		key, err := encoding.DecodePEMSigner(data)
+		encoding.Zero(data)
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", keyPath, err)
+		}
+		if k, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(old.PublicKey) {
+			return fmt.Errorf("key %s does not match certificate %s", keyPath, certPath)
+		}
+		pub = key.Public()
+	} else {
+		var key crypto.Signer
+		if keySize != 0 {
+			if _, ok := old.PublicKey.(*rsa.PublicKey); !ok {
+				return fmt.Errorf("--key-size only applies to RSA keys; %s has an %s key", certPath, encoding.KeyTypeName(old.PublicKey))
+			}
+			cfg := config.NewCertificateConfig()
+			cfg.KeySize = keySize
+			key, err = certificate.NewGenerator(cfg).GeneratePrivateKey()
+		} else {
+			key, err = certificate.GenerateKeyLike(old.PublicKey)
+		}
+		if err != nil {
+			return err
+		}
+		if keyPEM, err = encoding.EncodeSignerToPEM(key); err != nil {
+			return fmt.Errorf("failed to encode private key: %w", err)
+		}
+		defer encoding.Zero(keyPEM)
+		pub = key.Public()
+	}
+
+	cert, err := certificate.RenewCertificate(old, pub, caCert, caKey, validity)
+	if err != nil {
+		return err
+	}
+
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode renewed certificate: %w", err)
+	}
+
+	name := cert.Subject.CommonName
+	if name == "" && len(cert.DNSNames) > 0 {
+		name = cert.DNSNames[0]
+	}
+	if name == "" {
+		name = "renewed"
+	}
+	names := fileio.NewFileWriter(safeFileName(name, "renewed"))
+	if outPath == "" {
+		outPath = names.GetLeafCertPath()
+	}
+	if keyPEM != nil {
+		if keyOutPath == "" {
+			keyOutPath = names.GetLeafKeyPath()
+		}
+		// Registering the path as the leaf key gives it key permissions
+		// whatever it is named
+		fileWriter.SetLeafKeyPath(keyOutPath)
+		if err := fileWriter.WriteFile(fileWriter.GetLeafKeyPath(), keyPEM); err != nil {
+			return err
+		}
+	}
+	if err := fileWriter.WriteFile(outPath, certPEM); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Renewed certificate for %s until %s: %s\n", name, cert.NotAfter.Format("2006-01-02"), outPath)
+	if keyPEM != nil {
+		fmt.Printf("✓ New private key: %s\n", keyOutPath)
+	}
+	return nil
+}
+
+// safeFileName makes a certificate name usable as a file name prefix by
+// replacing anything but letters, digits, dots, hyphens and underscores,
+// such as spaces, slashes or a wildcard, with underscores. Leading and
+// trailing dots and underscores are dropped; fallback is used when nothing
+// is left.
+func safeFileName(name, fallback string) string {
+	safe := []rune(name)
+	for i, r := range safe {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			safe[i] = '_'
+		}
+	}
+	name = strings.Trim(string(safe), "._")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
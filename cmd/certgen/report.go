@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/report"
+)
+
+// runReport implements "certgen report", which lists the certificates in a
+// directory with their expiry, flagging those expiring soon.
+func runReport(args []string) error {
+	var (
+		dir     string
+		within  string
+		jsonOut bool
+	)
+
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	fs.StringVar(&dir, "dir", ".", "Directory to scan (recursively) for *.pem certificates")
+	fs.StringVar(&within, "within", "30d", "Flag certificates expiring within this window, e.g. 30d or 720h")
+	fs.BoolVar(&jsonOut, "json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s report [--dir ./certs] [--within 30d] [--json]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	window, err := parseWindow(within)
+	if err != nil {
+		return fmt.Errorf("--within: %w", err)
+	}
+
+	entries, err := report.Scan(dir, window, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		if entries == nil {
+			entries = []report.Entry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No certificates found in %s\n", dir)
+		return nil
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tDAYS\tNOT AFTER\tSUBJECT\tPATH")
+	for _, e := range entries {
+		mark := e.Status
+		if e.Flagged() {
+			mark = "! " + mark
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", mark, e.DaysRemaining, e.NotAfter.Format(time.RFC3339), e.Subject, e.Path)
+	}
+	return tw.Flush()
+}
+
+// parseWindow parses a duration that may also be given in days, e.g. "30d".
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q, want e.g. 30d or 720h", s)
+	}
+	return d, nil
+}
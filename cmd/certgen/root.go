@@ -0,0 +1,193 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/erfianugrah/certgen/pkg/castore"
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/pkcs12"
+)
+
+// rootCA is the CA that signs the leaves of a run, together with a
+// description of where its key lives for the summary.
+type rootCA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	keyPath string
+	closer  func() error
+}
+
+func (r *rootCA) close() {
+	if r.closer != nil {
+		r.closer()
+	}
+}
+
+// setupRoot obtains the root CA for a run: from a PKCS#11 token, from the CA
+// store in --ca-dir, or freshly generated with its key written next to the
+// other outputs.
+func setupRoot(cfg *config.CertificateConfig, fileWriter *fileio.FileWriter, opts *runOptions) (*rootCA, error) {
+	certGen := certificate.NewGenerator(cfg)
+
+	switch {
+	case opts.pkcs11.lib != "":
+		certs, err := loadCACerts(fileWriter, opts.caCert)
+		if err != nil {
+			return nil, err
+		}
+		signer, closeToken, err := loadPKCS11Signer(opts.pkcs11)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := certificate.SelectIssuer(certs, signer)
+		if err != nil {
+			closeToken()
+			return nil, err
+		}
+		root := &rootCA{
+			cert:    cert,
+			key:     signer,
+			keyPath: fmt.Sprintf("PKCS#11 key %s (slot %d)", opts.pkcs11.keyID, opts.pkcs11.slot),
+			closer:  closeToken,
+		}
+		if err := checkCAExpiry(cert, opts.warnDays, opts.strict); err != nil {
+			root.close()
+			return nil, err
+		}
+		opts.logger.Step("Loaded Root CA key from PKCS#11 token", "")
+		return root, nil
+
+	case opts.caDir != "":
+		store := castore.NewStore(opts.caDir)
+		store.SetChecksums(opts.checksums)
+		cert, key, created, err := store.LoadOrCreate(certGen)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			opts.logger.Step("Generated Root CA and saved it to "+store.Dir(), "")
+		} else {
+			if err := checkCAExpiry(cert, opts.warnDays, opts.strict); err != nil {
+				return nil, err
+			}
+			opts.logger.Step("Loaded Root CA from "+store.Dir(), "")
+		}
+		return &rootCA{cert: cert, key: key, keyPath: store.KeyPath()}, nil
+
+	default:
+		cert, key, err := certGen.GenerateRootCA()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate root CA: %w", err)
+		}
+		opts.logger.Step("Generated Root CA certificate", "")
+
+		keyPath := fileWriter.GetRootKeyPath()
+		keyPEM, err := encoding.EncodePrivateKey(key, opts.keyFormat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode root key: %w", err)
+		}
+		err = fileWriter.WriteFile(keyPath, keyPEM)
+		encoding.Zero(keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		opts.logger.Step("Saved Root CA key", keyPath)
+		return &rootCA{cert: cert, key: key, keyPath: keyPath}, nil
+	}
+}
+
+// writeRoot writes the root certificate in PEM, base64 and, if requested,
+// DER form.
+func writeRoot(root *rootCA, fileWriter *fileio.FileWriter, opts *runOptions) error {
+	certPEM, err := encoding.EncodeCertificateToPEM(root.cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode root certificate: %w", err)
+	}
+	if err := fileWriter.WriteFile(fileWriter.GetRootCertPath(), certPEM); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved Root CA certificate", fileWriter.GetRootCertPath())
+
+	if opts.writeDER {
+		if err := fileWriter.WriteFile(fileWriter.GetRootDERPath(), root.cert.Raw); err != nil {
+			return err
+		}
+		opts.logger.Step("Saved Root CA certificate (DER)", fileWriter.GetRootDERPath())
+	}
+
+	if err := fileWriter.WriteBase64Stream(fileWriter.GetRootBase64Path(), root.cert.Raw); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved Root CA certificate (base64)", fileWriter.GetRootBase64Path())
+
+	return nil
+}
+
+// writeMobile writes the root certificate as a DER .crt for Android and iOS
+// and, with --mobileconfig, an Apple configuration profile embedding it.
+func writeMobile(root *rootCA, fileWriter *fileio.FileWriter, opts *runOptions) error {
+	if err := fileWriter.WriteFile(fileWriter.GetRootCRTPath(), root.cert.Raw); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved Root CA certificate (mobile)", fileWriter.GetRootCRTPath())
+
+	if !opts.mobileCfg {
+		return nil
+	}
+	profile, err := encoding.EncodeMobileConfig(root.cert, filepath.Base(fileWriter.GetRootCRTPath()))
+	if err != nil {
+		return err
+	}
+	if err := fileWriter.WriteFile(fileWriter.GetRootMobileConfigPath(), profile); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved mobile configuration profile", fileWriter.GetRootMobileConfigPath())
+	return nil
+}
+
+// appendSystemBundle appends the root certificate to the trust bundle at
+// --system-bundle unless it is already there. The bundle belongs to the
+// system, so it gets no checksum sidecar or CRLF line endings.
+func appendSystemBundle(root *rootCA, opts *runOptions) error {
+	fileWriter := fileio.NewFileWriter(root.cert.Subject.CommonName)
+	fileWriter.SetCreatedFiles(opts.created)
+	appended, err := fileWriter.AppendCertificate(opts.systemBundle, root.cert)
+	if err != nil {
+		return err
+	}
+	if appended {
+		opts.logger.Step("Appended Root CA certificate to system bundle", opts.systemBundle)
+	} else {
+		opts.logger.Step("Root CA certificate already in system bundle", opts.systemBundle)
+	}
+	return nil
+}
+
+// writeTruststore writes a PKCS#12 truststore holding only the root
+// certificate, protected by the PKCS#12 password.
+func writeTruststore(root *rootCA, fileWriter *fileio.FileWriter, cfg *config.CertificateConfig, opts *runOptions) error {
+	gen := pkcs12.NewGenerator()
+	gen.SetTempDir(opts.tempDir)
+	gen.SetMAC(cfg.PKCS12MACAlgorithm, cfg.PKCS12MACIterations)
+	gen.SetOpenSSLPath(opts.opensslBin)
+
+	data, err := gen.GenerateTruststore(root.cert, cfg.PKCS12Password)
+	if err != nil {
+		return err
+	}
+	if err := fileWriter.WriteFile(fileWriter.GetTruststorePath(), data); err != nil {
+		return err
+	}
+	opts.logger.Step("Saved truststore", fileWriter.GetTruststorePath())
+	if !gen.JavaTrusted(cfg.PKCS12Password) {
+		fmt.Fprintln(os.Stderr, "Warning: keytool was not used (not found, a password under 6 characters, or --p12-macalg/--p12-iter) and openssl is older than 3.2; Java may ignore the CA in the truststore (import it with keytool -importcert instead)")
+	}
+	return nil
+}
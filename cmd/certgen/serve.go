@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/server"
+)
+
+// runServe implements "certgen serve", which exposes certificate generation
+// as an HTTP endpoint returning a zip of the artifacts.
+func runServe(args []string) error {
+	var (
+		addr          string
+		maxBody       int64
+		timeout       time.Duration
+		maxConcurrent int
+	)
+
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&addr, "addr", ":8080", "Address to listen on")
+	fs.Int64Var(&maxBody, "max-body", server.DefaultMaxBodyBytes, "Maximum request body size in bytes")
+	fs.DurationVar(&timeout, "timeout", server.DefaultTimeout, "Maximum time to spend generating a single response")
+	fs.IntVar(&maxConcurrent, "max-concurrent", server.DefaultMaxConcurrent, "Maximum generations running at once; further requests get 429")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s serve [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if maxConcurrent < 1 {
+		return fmt.Errorf("--max-concurrent must be at least 1")
+	}
+
+	maxValidity, err := config.MaxValidityDaysFromEnv()
+	if err != nil {
+		return err
+	}
+
+	handler := server.NewHandler()
+	handler.SetMaxBodyBytes(maxBody)
+	handler.SetTimeout(timeout)
+	handler.SetMaxConcurrent(maxConcurrent)
+	handler.SetMaxValidityDays(maxValidity)
+
+	mux := http.NewServeMux()
+	mux.Handle("/generate", handler)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      timeout + 10*time.Second,
+	}
+
+	fmt.Printf("Certificate Generator v%s listening on %s (POST /generate)\n", version, addr)
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+// runSign implements "certgen sign", which issues a certificate for an
+// externally generated CSR using an existing CA.
+func runSign(args []string) error {
+	var (
+		csrPath    string
+		caCertPath string
+		caKeyPath  string
+		outPath    string
+		days       int
+		warnDays   int
+		strict     bool
+	)
+
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	fs.StringVar(&csrPath, "csr", "", "Path to the PEM-encoded certificate request (required)")
+	fs.StringVar(&caCertPath, "ca-cert", "", "Path to the PEM-encoded CA certificate (required)")
+	fs.StringVar(&caKeyPath, "ca-key", "", "Path to the PEM-encoded CA private key (required)")
+	fs.StringVar(&outPath, "out", "", "Output path for the signed certificate (defaults to <name>_leaf.pem)")
+	fs.IntVar(&days, "days", 365, "Validity period for the signed certificate")
+	fs.IntVar(&warnDays, "ca-expiry-warn-days", 30, "Warn when the CA expires within this many days")
+	fs.BoolVar(&strict, "strict", false, "Fail instead of warning when the CA is expired or expiring")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s sign --csr req.csr --ca-cert rootCA.pem --ca-key rootCA.key [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if csrPath == "" || caCertPath == "" || caKeyPath == "" {
+		fs.Usage()
+		return fmt.Errorf("--csr, --ca-cert and --ca-key are required")
+	}
+	if days <= 0 || days > config.MaxValidityDays {
+		return fmt.Errorf("--days must be between 1 and %d (100 years)", config.MaxValidityDays)
+	}
+	if err := checkMaxValidity(time.Duration(days) * 24 * time.Hour); err != nil {
+		return err
+	}
+
+	fileWriter := fileio.NewFileWriter("")
+
+	csrPEM, err := fileWriter.ReadFile(csrPath)
+	if err != nil {
+		return err
+	}
+	csr, err := encoding.DecodePEMCertificateRequest(csrPEM)
+	if err != nil {
+		return err
+	}
+
+	caCert, caKey, err := loadCA(fileWriter, caCertPath, caKeyPath)
+	if err != nil {
+		return err
+	}
+	if err := checkCAExpiry(caCert, warnDays, strict); err != nil {
+		return err
+	}
+
+	cert, err := certificate.SignCSR(csr, caCert, caKey, time.Duration(days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode signed certificate: %w", err)
+	}
+
+	if outPath == "" {
+		name := csr.Subject.CommonName
+		if name == "" && len(csr.DNSNames) > 0 {
+			name = csr.DNSNames[0]
+		}
+		outPath = fileio.NewFileWriter(safeFileName(name, "signed")).GetLeafCertPath()
+	}
+	if err := fileWriter.WriteFile(outPath, certPEM); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Signed certificate for %s: %s\n", cert.Subject.CommonName, outPath)
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package castore persists a root CA certificate and key in a directory so
+// that subsequent runs can reuse it to sign new leaf certificates.
+package castore
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+const (
+	certFileName = "rootCA.pem"
+	keyFileName  = "rootCA.key"
+)
+
+type Store struct {
+	dir        string
+	fileWriter *fileio.FileWriter
+}
+
+func NewStore(dir string) *Store {
+	return &Store{
+		dir:        dir,
+		fileWriter: fileio.NewFileWriter(""),
+	}
+}
+
+// SetChecksums enables sha256sum-compatible .sha256 sidecars for the CA
+// certificate, key and index.txt the store writes. serial.txt is rewritten
+// in place under a lock on every issuance, so it never gets one.
+func (s *Store) SetChecksums(enabled bool) {
+	s.fileWriter.SetChecksums(enabled)
+}
+
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) CertPath() string {
+	return filepath.Join(s.dir, certFileName)
+}
+
+func (s *Store) KeyPath() string {
+	return filepath.Join(s.dir, keyFileName)
+}
+
+// Exists reports whether the store holds a CA. A certificate without its key
+// (or the reverse) is reported as an error rather than as absent, so that a
+// damaged store is never silently replaced with a new CA.
+func (s *Store) Exists() (bool, error) {
+	hasCert := s.fileWriter.FileExists(s.CertPath())
+	hasKey := s.fileWriter.FileExists(s.KeyPath())
+
+	switch {
+	case hasCert && hasKey:
+		return true, nil
+	case hasCert:
+		return false, fmt.Errorf("CA store %s has %s but is missing %s", s.dir, certFileName, keyFileName)
+	case hasKey:
+		return false, fmt.Errorf("CA store %s has %s but is missing %s", s.dir, keyFileName, certFileName)
+	default:
+		return false, nil
+	}
+}
+
+func (s *Store) Load() (*x509.Certificate, *rsa.PrivateKey, error) {
+	certPEM, err := s.fileWriter.ReadFile(s.CertPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := encoding.DecodePEMCertificate(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load CA certificate: %w", err)
+	}
+
+	keyPEM, err := s.fileWriter.ReadFile(s.KeyPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := encoding.DecodePEMPrivateKey(keyPEM)
+	encoding.Zero(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load CA key: %w", err)
+	}
+
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		return nil, nil, fmt.Errorf("CA key in %s does not match CA certificate", s.dir)
+	}
+
+	return cert, key, nil
+}
+
+func (s *Store) Save(cert *x509.Certificate, key *rsa.PrivateKey) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create CA store %s: %w", s.dir, err)
+	}
+
+	keyPEM, err := encoding.EncodePrivateKeyToPEM(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode CA key: %w", err)
+	}
+	err = s.fileWriter.WriteFile(s.KeyPath(), keyPEM)
+	encoding.Zero(keyPEM)
+	if err != nil {
+		return err
+	}
+
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		return fmt.Errorf("failed to encode CA certificate: %w", err)
+	}
+	return s.fileWriter.WriteFile(s.CertPath(), certPEM)
+}
+
+// LoadOrCreate returns the stored CA, generating and saving one with gen if
+// the store is empty. created reports whether a new CA was generated.
+func (s *Store) LoadOrCreate(gen *certificate.Generator) (cert *x509.Certificate, key *rsa.PrivateKey, created bool, err error) {
+	exists, err := s.Exists()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if exists {
+		cert, key, err = s.Load()
+		return cert, key, false, err
+	}
+
+	cert, key, err = gen.GenerateRootCA()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to generate root CA: %w", err)
+	}
+	if err := s.Save(cert, key); err != nil {
+		return nil, nil, false, err
+	}
+	return cert, key, true, nil
+}
@@ -0,0 +1,95 @@
+package castore
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const indexFileName = "index.txt"
+
+// indexTimeFormat is the YYMMDDHHMMSSZ form openssl ca uses for dates in
+// its index file.
+const indexTimeFormat = "060102150405Z"
+
+func (s *Store) IndexPath() string {
+	return filepath.Join(s.dir, indexFileName)
+}
+
+// AppendIndex records cert in the store's index.txt as a valid entry, so the
+// CA directory keeps the same database of issued certificates that openssl ca
+// does and can be inspected or revoked with openssl's tools.
+func (s *Store) AppendIndex(cert *x509.Certificate) error {
+	if err := s.fileWriter.AppendFile(s.IndexPath(), []byte(FormatIndexEntry(cert))); err != nil {
+		return fmt.Errorf("failed to update index file: %w", err)
+	}
+	return nil
+}
+
+// FormatIndexEntry formats cert as a line of an openssl ca index file: the
+// status (V for valid), the expiry date, an empty revocation date, the serial
+// in hex, the file name (always "unknown") and the subject DN in openssl's
+// /-separated form, separated by tabs.
+func FormatIndexEntry(cert *x509.Certificate) string {
+	serial := fmt.Sprintf("%X", cert.SerialNumber)
+	if len(serial)%2 == 1 {
+		serial = "0" + serial
+	}
+	return strings.Join([]string{
+		"V",
+		cert.NotAfter.UTC().Format(indexTimeFormat),
+		"",
+		serial,
+		"unknown",
+		onelineDN(cert),
+	}, "\t") + "\n"
+}
+
+// dnShortNames are the attribute names openssl uses when printing a DN.
+var dnShortNames = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.5":              "serialNumber",
+	"2.5.4.6":              "C",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "ST",
+	"2.5.4.9":              "street",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"2.5.4.17":             "postalCode",
+	"1.2.840.113549.1.9.1": "emailAddress",
+}
+
+// onelineDN renders the certificate's subject in encoding order as openssl's
+// /C=../O=../CN=.. form. Tabs and newlines in values are replaced with
+// spaces, as they would break the index file's columns.
+func onelineDN(cert *x509.Certificate) string {
+	var rdns pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(cert.RawSubject, &rdns); err != nil || len(rest) > 0 {
+		rdns = cert.Subject.ToRDNSequence()
+	}
+
+	var b strings.Builder
+	for _, rdn := range rdns {
+		for i, atv := range rdn {
+			if i == 0 {
+				b.WriteByte('/')
+			} else {
+				b.WriteByte('+')
+			}
+			b.WriteString(attributeName(atv.Type))
+			b.WriteByte('=')
+			b.WriteString(strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(fmt.Sprint(atv.Value)))
+		}
+	}
+	return b.String()
+}
+
+func attributeName(oid asn1.ObjectIdentifier) string {
+	if name, ok := dnShortNames[oid.String()]; ok {
+		return name
+	}
+	return oid.String()
+}
@@ -0,0 +1,34 @@
+//go:build !unix
+
+package castore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockTimeout bounds how long lockFile waits for another process.
+const lockTimeout = 10 * time.Second
+
+// lockFile emulates an exclusive lock where flock is unavailable by creating
+// "<name>.lock" next to f, waiting while another process holds it.
+func lockFile(f *os.File) (func(), error) {
+	path := f.Name() + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		lock, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			lock.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s; remove it if no other certgen is running", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,68 @@
+package castore
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const serialFileName = "serial.txt"
+
+// SerialPath returns the path of the counter file NextSerial reads and
+// advances, holding the next serial number in hex.
+func (s *Store) SerialPath() string {
+	return filepath.Join(s.dir, serialFileName)
+}
+
+// NextSerial returns the next serial number from the store's counter file and
+// advances the counter, so leaves issued from the same CA get unique,
+// increasing serials across runs. The file holds the next serial in hex, like
+// openssl ca's serial file, and starts at 1. The file is locked while it is
+// updated, so concurrent runs never hand out the same serial.
+func (s *Store) NextSerial() (*big.Int, error) {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create CA store %s: %w", s.dir, err)
+	}
+
+	f, err := os.OpenFile(s.SerialPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open serial file: %w", err)
+	}
+	defer f.Close()
+
+	unlock, err := lockFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock serial file %s: %w", s.SerialPath(), err)
+	}
+	defer unlock()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read serial file: %w", err)
+	}
+
+	serial := big.NewInt(1)
+	if text := strings.TrimSpace(string(data)); text != "" {
+		if _, ok := serial.SetString(text, 16); !ok || serial.Sign() <= 0 {
+			return nil, fmt.Errorf("serial file %s does not hold a positive hex number: %q", s.SerialPath(), text)
+		}
+	}
+
+	next := new(big.Int).Add(serial, big.NewInt(1))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to update serial file: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return nil, fmt.Errorf("failed to update serial file: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%X\n", next); err != nil {
+		return nil, fmt.Errorf("failed to update serial file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to update serial file: %w", err)
+	}
+	return serial, nil
+}
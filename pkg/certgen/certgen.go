@@ -0,0 +1,122 @@
+// Package certgen composes the certificate and encoding packages into the
+// standard root CA + leaf flow, entirely in memory, for embedding certgen in
+// other programs.
+package certgen
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+// Bundle is a freshly generated root CA and the leaf it signed.
+type Bundle struct {
+	RootCert *x509.Certificate
+	RootKey  *rsa.PrivateKey
+	LeafCert *x509.Certificate
+	LeafKey  *rsa.PrivateKey
+}
+
+// Generate creates a root CA and a leaf certificate signed by it, as
+// described by cfg.
+func Generate(cfg *config.CertificateConfig) (*Bundle, error) {
+	return GenerateContext(context.Background(), cfg)
+}
+
+// GenerateContext is Generate, stopping before each key generation once ctx
+// is done. A key generation already running is not interrupted.
+func GenerateContext(ctx context.Context, cfg *config.CertificateConfig) (*Bundle, error) {
+	certGen := certificate.NewGenerator(cfg)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rootCert, rootKey, err := certGen.GenerateRootCA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate root CA: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	leafCert, leafKey, err := certGen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate leaf certificate: %w", err)
+	}
+
+	return &Bundle{RootCert: rootCert, RootKey: rootKey, LeafCert: leafCert, LeafKey: leafKey}, nil
+}
+
+// PEM encodes the bundle's certificates and PKCS#8 private keys. Callers
+// should encoding.Zero the key buffers once they are done with them.
+func (b *Bundle) PEM() (rootPEM, rootKeyPEM, leafPEM, leafKeyPEM []byte, err error) {
+	if rootPEM, err = encoding.EncodeCertificateToPEM(b.RootCert); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to encode root certificate: %w", err)
+	}
+	if leafPEM, err = encoding.EncodeCertificateToPEM(b.LeafCert); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to encode leaf certificate: %w", err)
+	}
+	if rootKeyPEM, err = encoding.EncodePrivateKeyToPEM(b.RootKey); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to encode root key: %w", err)
+	}
+	if leafKeyPEM, err = encoding.EncodePrivateKeyToPEM(b.LeafKey); err != nil {
+		encoding.Zero(rootKeyPEM)
+		return nil, nil, nil, nil, fmt.Errorf("failed to encode leaf key: %w", err)
+	}
+	return rootPEM, rootKeyPEM, leafPEM, leafKeyPEM, nil
+}
+
+// GenerateInMemory runs Generate and returns the results PEM-encoded,
+// without touching the filesystem.
+func GenerateInMemory(cfg *config.CertificateConfig) (rootPEM, rootKeyPEM, leafPEM, leafKeyPEM []byte, err error) {
+	bundle, err := Generate(cfg)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return bundle.PEM()
+}
+
+// JSONBundle is the document written by Bundle.JSON: certificates and PKCS#8
+// keys as PEM strings, and the PKCS#12 bundle, if any, as standard base64.
+type JSONBundle struct {
+	RootCert  string `json:"root_cert"`
+	RootKey   string `json:"root_key"`
+	LeafCert  string `json:"leaf_cert"`
+	LeafKey   string `json:"leaf_key"`
+	P12Base64 string `json:"p12_base64,omitempty"`
+}
+
+// JSON encodes the bundle as an indented JSONBundle document, for consumers
+// such as web UIs that want every artifact in one place. pkcs12Data is the
+// PKCS#12 bundle to embed; pass nil to leave p12_base64 out. The result holds
+// private keys, so callers should encoding.Zero it once written.
+func (b *Bundle) JSON(pkcs12Data []byte) ([]byte, error) {
+	rootPEM, rootKeyPEM, leafPEM, leafKeyPEM, err := b.PEM()
+	if err != nil {
+		return nil, err
+	}
+	defer encoding.Zero(rootKeyPEM)
+	defer encoding.Zero(leafKeyPEM)
+
+	doc := JSONBundle{
+		RootCert: string(rootPEM),
+		RootKey:  string(rootKeyPEM),
+		LeafCert: string(leafPEM),
+		LeafKey:  string(leafKeyPEM),
+	}
+	if len(pkcs12Data) > 0 {
+		doc.P12Base64 = base64.StdEncoding.EncodeToString(pkcs12Data)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON bundle: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -3,12 +3,16 @@
 package certificate
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
-	"crypto/x509/pkix"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/erfianugrah/certgen/pkg/config"
 )
@@ -23,43 +27,151 @@ func NewGenerator(cfg *config.CertificateConfig) *Generator {
 	}
 }
 
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"serverAuth":      x509.ExtKeyUsageServerAuth,
+	"clientAuth":      x509.ExtKeyUsageClientAuth,
+	"codeSigning":     x509.ExtKeyUsageCodeSigning,
+	"emailProtection": x509.ExtKeyUsageEmailProtection,
+	"timeStamping":    x509.ExtKeyUsageTimeStamping,
+	"OCSPSigning":     x509.ExtKeyUsageOCSPSigning,
+}
+
+func parseExtKeyUsage(names []string) ([]x509.ExtKeyUsage, error) {
+	usages := make([]x509.ExtKeyUsage, 0, len(names))
+	for _, name := range names {
+		usage, ok := extKeyUsageNames[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown extended key usage %q", name)
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+var keyUsageNames = map[string]x509.KeyUsage{
+	"digitalSignature": x509.KeyUsageDigitalSignature,
+	"nonRepudiation":   x509.KeyUsageContentCommitment,
+	"keyEncipherment":  x509.KeyUsageKeyEncipherment,
+	"dataEncipherment": x509.KeyUsageDataEncipherment,
+	"keyAgreement":     x509.KeyUsageKeyAgreement,
+	"keyCertSign":      x509.KeyUsageCertSign,
+	"cRLSign":          x509.KeyUsageCRLSign,
+	"encipherOnly":     x509.KeyUsageEncipherOnly,
+	"decipherOnly":     x509.KeyUsageDecipherOnly,
+}
+
+func parseKeyUsage(names []string) (x509.KeyUsage, error) {
+	var usage x509.KeyUsage
+	for _, name := range names {
+		u, ok := keyUsageNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown key usage %q", name)
+		}
+		usage |= u
+	}
+	return usage, nil
+}
+
+func (g *Generator) serialBits() int {
+	if g.config.SerialBits == 0 {
+		return config.DefaultSerialBits
+	}
+	return g.config.SerialBits
+}
+
+func newSerialNumber(bits int) (*big.Int, error) {
+	if bits < config.MinSerialBits || bits > config.MaxSerialBits {
+		return nil, fmt.Errorf("serial number size must be between %d and %d bits", config.MinSerialBits, config.MaxSerialBits)
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
+
+// GeneratePrivateKey generates an RSA key of the configured leaf key size.
 func (g *Generator) GeneratePrivateKey() (*rsa.PrivateKey, error) {
 	if g.config == nil {
 		return nil, fmt.Errorf("configuration is nil")
 	}
-	if g.config.KeySize < 1024 {
+	return generateRSAKey(g.config.LeafKeyBits())
+}
+
+// GenerateRootPrivateKey generates an RSA key of the configured root CA key
+// size.
+func (g *Generator) GenerateRootPrivateKey() (*rsa.PrivateKey, error) {
+	if g.config == nil {
+		return nil, fmt.Errorf("configuration is nil")
+	}
+	return generateRSAKey(g.config.RootKeyBits())
+}
+
+func generateRSAKey(bits int) (*rsa.PrivateKey, error) {
+	if bits < 1024 {
 		return nil, fmt.Errorf("key size must be at least 1024 bits")
 	}
-	key, err := rsa.GenerateKey(rand.Reader, g.config.KeySize)
+	key, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %w", err)
 	}
 	return key, nil
 }
 
+// Result is an issued certificate together with the options it was built
+// from, so callers can record exactly what was issued.
+type Result struct {
+	Certificate *x509.Certificate
+	DER         []byte
+	PrivateKey  *rsa.PrivateKey
+	Options     *config.CertificateOptions
+}
+
 func (g *Generator) GenerateRootCA() (*x509.Certificate, *rsa.PrivateKey, error) {
-	key, err := g.GeneratePrivateKey()
+	res, err := g.GenerateRootCAResult()
 	if err != nil {
 		return nil, nil, err
 	}
+	return res.Certificate, res.PrivateKey, nil
+}
+
+func (g *Generator) GenerateRootCADER() ([]byte, *rsa.PrivateKey, error) {
+	certDER, key, _, err := g.createRootCA()
+	return certDER, key, err
+}
+
+// GenerateRootCAResult generates a root CA and returns it with the effective
+// options used to build it.
+func (g *Generator) GenerateRootCAResult() (*Result, error) {
+	certDER, key, opts, err := g.createRootCA()
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root CA certificate: %w", err)
+	}
+
+	return &Result{Certificate: cert, DER: certDER, PrivateKey: key, Options: opts}, nil
+}
+
+func (g *Generator) createRootCA() ([]byte, *rsa.PrivateKey, *config.CertificateOptions, error) {
+	key, err := g.GenerateRootPrivateKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
 
 	opts := g.config.GetRootCAOptions()
 
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	serialNumber, err := newSerialNumber(g.serialBits())
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, nil, nil, err
 	}
 
 	template := &x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Country:            []string{opts.Subject.Country},
-			Province:           []string{opts.Subject.State},
-			Locality:           []string{opts.Subject.Locality},
-			Organization:       []string{opts.Subject.Organization},
-			OrganizationalUnit: []string{opts.Subject.OrganizationalUnit},
-			CommonName:         opts.Subject.CommonName,
-		},
+		SerialNumber:          serialNumber,
+		Subject:               opts.Subject.PKIXName(),
 		NotBefore:             opts.ValidFrom,
 		NotAfter:              opts.ValidFrom.Add(opts.ValidFor),
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
@@ -67,78 +179,216 @@ func (g *Generator) GenerateRootCA() (*x509.Certificate, *rsa.PrivateKey, error)
 		BasicConstraintsValid: true,
 		IsCA:                  true,
 		DNSNames:              opts.DNSNames,
+		IPAddresses:           opts.IPAddresses,
+		PermittedDNSDomains:   opts.PermittedDNSDomains,
+		ExcludedDNSDomains:    opts.ExcludedDNSDomains,
+		PolicyIdentifiers:     opts.PolicyOIDs,
+	}
+	if len(opts.PermittedDNSDomains) > 0 || len(opts.ExcludedDNSDomains) > 0 {
+		template.PermittedDNSDomainsCritical = true
+	}
+	if template.SignatureAlgorithm, err = g.signatureAlgorithm(&key.PublicKey); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := applyCriticality(template, opts); err != nil {
+		return nil, nil, nil, err
 	}
 
 	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create root CA certificate: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to create root CA certificate: %w", err)
+	}
+
+	return certDER, key, opts, nil
+}
+
+// GenerateLeafCertificate issues a leaf certificate signed by caKey, which
+// may be any crypto.Signer such as an in-memory key or an HSM-backed signer.
+func (g *Generator) GenerateLeafCertificate(caCert *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, *rsa.PrivateKey, error) {
+	res, err := g.GenerateLeafCertificateResult(caCert, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Certificate, res.PrivateKey, nil
+}
+
+func (g *Generator) GenerateLeafCertificateDER(caCert *x509.Certificate, caKey crypto.Signer) ([]byte, *rsa.PrivateKey, error) {
+	certDER, key, _, err := g.createLeafCertificate(caCert, caKey)
+	return certDER, key, err
+}
+
+// GenerateLeafCertificateResult issues a leaf certificate like
+// GenerateLeafCertificate and returns it with the effective options used.
+func (g *Generator) GenerateLeafCertificateResult(caCert *x509.Certificate, caKey crypto.Signer) (*Result, error) {
+	certDER, key, opts, err := g.createLeafCertificate(caCert, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse leaf certificate: %w", err)
+	}
+
+	return &Result{Certificate: cert, DER: certDER, PrivateKey: key, Options: opts}, nil
+}
+
+// GenerateLeafCertificateWithKey issues a leaf certificate for an externally
+// generated key pair, such as one held in an HSM. Only the public key is
+// needed, so the private key never passes through certgen.
+func (g *Generator) GenerateLeafCertificateWithKey(caCert *x509.Certificate, caKey crypto.Signer, leafPubKey crypto.PublicKey) (*x509.Certificate, error) {
+	certDER, _, err := g.signLeaf(caCert, caKey, leafPubKey)
+	if err != nil {
+		return nil, err
 	}
 
 	cert, err := x509.ParseCertificate(certDER)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse root CA certificate: %w", err)
+		return nil, fmt.Errorf("failed to parse leaf certificate: %w", err)
 	}
 
+	return cert, nil
+}
+
+// GenerateLeafCertificateForKey issues a fresh leaf certificate for an
+// existing leaf key, so a certificate can be rotated without changing the
+// pinned key.
+func (g *Generator) GenerateLeafCertificateForKey(caCert *x509.Certificate, caKey crypto.Signer, leafKey *rsa.PrivateKey) (*x509.Certificate, error) {
+	return g.GenerateLeafCertificateWithKey(caCert, caKey, &leafKey.PublicKey)
+}
+
+// GenerateECDSALeafCertificate issues a leaf certificate like
+// GenerateLeafCertificate but for a new ECDSA P-256 key, for servers that
+// present both an RSA and an ECDSA certificate for the same name.
+func (g *Generator) GenerateECDSALeafCertificate(caCert *x509.Certificate, caKey crypto.Signer) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate ECDSA key: %w", err)
+	}
+
+	cert, err := g.GenerateLeafCertificateWithKey(caCert, caKey, &key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
 	return cert, key, nil
 }
 
-func (g *Generator) GenerateLeafCertificate(caCert *x509.Certificate, caKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey, error) {
+func (g *Generator) createLeafCertificate(caCert *x509.Certificate, caKey crypto.Signer) ([]byte, *rsa.PrivateKey, *config.CertificateOptions, error) {
 	key, err := g.GeneratePrivateKey()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+
+	certDER, opts, err := g.signLeaf(caCert, caKey, &key.PublicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return certDER, key, opts, nil
+}
+
+// leafNotAfter returns the end of the leaf's validity period, capped at the
+// CA's NotAfter when ClampToCA is set.
+func (g *Generator) leafNotAfter(opts *config.CertificateOptions, caCert *x509.Certificate) time.Time {
+	notAfter := opts.ValidFrom.Add(opts.ValidFor)
+	if g.config.ClampToCA && notAfter.After(caCert.NotAfter) {
+		return caCert.NotAfter
 	}
+	return notAfter
+}
 
+// LeafOutlivesCA reports whether a leaf issued with the current configuration
+// would, without ClampToCA, expire after caCert.
+func (g *Generator) LeafOutlivesCA(caCert *x509.Certificate) bool {
 	opts := g.config.GetLeafCertOptions()
+	return opts.ValidFrom.Add(opts.ValidFor).After(caCert.NotAfter)
+}
 
-	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+// signLeaf builds the leaf certificate for pub from the generator's config
+// and signs it with caKey.
+func (g *Generator) signLeaf(caCert *x509.Certificate, caKey crypto.Signer, pub crypto.PublicKey) ([]byte, *config.CertificateOptions, error) {
+	if err := checkRequestPublicKey(pub); err != nil {
+		return nil, nil, err
+	}
+	if _, err := config.ExtKeyUsageForProfile(g.config.Profile); err != nil {
+		return nil, nil, err
+	}
+	opts := g.config.GetLeafCertOptions()
+
+	keyUsage, err := parseKeyUsage(opts.KeyUsage)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyUsage = keyUsageForKey(keyUsage, pub)
+	extKeyUsage, err := parseExtKeyUsage(opts.ExtKeyUsage)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, nil, err
 	}
 
-	template := &x509.Certificate{
-		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			Country:            []string{opts.Subject.Country},
-			Province:           []string{opts.Subject.State},
-			Locality:           []string{opts.Subject.Locality},
-			Organization:       []string{opts.Subject.Organization},
-			OrganizationalUnit: []string{opts.Subject.OrganizationalUnit},
-			CommonName:         opts.Subject.CommonName,
-		},
-		NotBefore:   opts.ValidFrom,
-		NotAfter:    opts.ValidFrom.Add(opts.ValidFor),
-		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
-		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-		DNSNames:    opts.DNSNames,
+	serialNumber := g.config.Serial
+	if serialNumber == nil {
+		if serialNumber, err = newSerialNumber(g.serialBits()); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create leaf certificate: %w", err)
+	template := &x509.Certificate{
+		SerialNumber:      serialNumber,
+		Subject:           opts.Subject.PKIXName(),
+		NotBefore:         opts.ValidFrom,
+		NotAfter:          g.leafNotAfter(opts, caCert),
+		KeyUsage:          keyUsage,
+		ExtKeyUsage:       extKeyUsage,
+		DNSNames:          opts.DNSNames,
+		IPAddresses:       opts.IPAddresses,
+		PolicyIdentifiers: opts.PolicyOIDs,
+	}
+	if opts.IsCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	}
+	if template.SignatureAlgorithm, err = g.signatureAlgorithm(caKey.Public()); err != nil {
+		return nil, nil, err
+	}
+	if err := setKeyIDs(template, caCert, pub); err != nil {
+		return nil, nil, err
+	}
+	template.ExtraExtensions = customExtensions(opts.Extensions)
+	if opts.Precert {
+		template.ExtraExtensions = append(template.ExtraExtensions, ctPoisonExtension())
+	}
+	if opts.Subject.CommonName == "" && (len(opts.DNSNames) > 0 || len(opts.IPAddresses) > 0) {
+		ext, err := criticalSANExtension(opts.DNSNames, opts.IPAddresses)
+		if err != nil {
+			return nil, nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+	if err := applyCriticality(template, opts); err != nil {
+		return nil, nil, err
 	}
 
-	cert, err := x509.ParseCertificate(certDER)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, pub, caKey)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to parse leaf certificate: %w", err)
+		return nil, nil, fmt.Errorf("failed to create leaf certificate: %w", err)
 	}
 
-	return cert, key, nil
+	return certDER, opts, nil
 }
 
 func (g *Generator) GenerateCertificateRequest(key *rsa.PrivateKey) (*x509.CertificateRequest, error) {
 	opts := g.config.GetLeafCertOptions()
 
 	template := &x509.CertificateRequest{
-		Subject: pkix.Name{
-			Country:            []string{opts.Subject.Country},
-			Province:           []string{opts.Subject.State},
-			Locality:           []string{opts.Subject.Locality},
-			Organization:       []string{opts.Subject.Organization},
-			OrganizationalUnit: []string{opts.Subject.OrganizationalUnit},
-			CommonName:         opts.Subject.CommonName,
-		},
-		DNSNames: opts.DNSNames,
+		Subject:     opts.Subject.PKIXName(),
+		DNSNames:    opts.DNSNames,
+		IPAddresses: opts.IPAddresses,
 	}
+	sigAlg, err := g.signatureAlgorithm(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	template.SignatureAlgorithm = sigAlg
 
 	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
 	if err != nil {
@@ -152,3 +402,169 @@ func (g *Generator) GenerateCertificateRequest(key *rsa.PrivateKey) (*x509.Certi
 
 	return csr, nil
 }
+
+// SignCSR issues a leaf certificate for an externally generated CSR, copying
+// its subject, public key and every SAN type (DNS, IP, URI and email) and
+// signing it with the given CA.
+func SignCSR(csr *x509.CertificateRequest, caCert *x509.Certificate, caKey crypto.Signer, validity time.Duration) (*x509.Certificate, error) {
+	if csr == nil {
+		return nil, fmt.Errorf("certificate request is nil")
+	}
+	if caCert == nil || caKey == nil {
+		return nil, fmt.Errorf("CA certificate and key are required")
+	}
+	if validity <= 0 {
+		return nil, fmt.Errorf("validity must be positive")
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	if err := checkRequestPublicKey(csr.PublicKey); err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := newSerialNumber(config.DefaultSerialBits)
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:   serialNumber,
+		Subject:        csr.Subject,
+		NotBefore:      notBefore,
+		NotAfter:       notBefore.Add(validity),
+		KeyUsage:       keyUsageForKey(x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment, csr.PublicKey),
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:       csr.DNSNames,
+		IPAddresses:    csr.IPAddresses,
+		URIs:           csr.URIs,
+		EmailAddresses: csr.EmailAddresses,
+	}
+	if err := setKeyIDs(template, caCert, csr.PublicKey); err != nil {
+		return nil, err
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate request: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// RenewCertificate issues a replacement for old with a fresh serial number
+// and validity period, signed by the given CA for pub. The subject, every SAN
+// type (DNS, IP, URI and email) and the key usages are copied from old, so
+// the renewed certificate identifies the same service. The subject is copied
+// in its encoded form so attributes crypto/x509 does not model survive, and
+// every other extension except the key identifiers is copied as encoded.
+func RenewCertificate(old *x509.Certificate, pub crypto.PublicKey, caCert *x509.Certificate, caKey crypto.Signer, validity time.Duration) (*x509.Certificate, error) {
+	if old == nil {
+		return nil, fmt.Errorf("certificate to renew is nil")
+	}
+	if caCert == nil || caKey == nil {
+		return nil, fmt.Errorf("CA certificate and key are required")
+	}
+	if validity <= 0 {
+		return nil, fmt.Errorf("validity must be positive")
+	}
+	if old.IsCA {
+		return nil, fmt.Errorf("%s is a CA certificate; only leaf certificates can be renewed", old.Subject.CommonName)
+	}
+	if err := checkRequestPublicKey(pub); err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := newSerialNumber(config.DefaultSerialBits)
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:    serialNumber,
+		Subject:         old.Subject,
+		RawSubject:      old.RawSubject,
+		NotBefore:       notBefore,
+		NotAfter:        notBefore.Add(validity),
+		KeyUsage:        old.KeyUsage,
+		ExtKeyUsage:     old.ExtKeyUsage,
+		DNSNames:        old.DNSNames,
+		IPAddresses:     old.IPAddresses,
+		URIs:            old.URIs,
+		EmailAddresses:  old.EmailAddresses,
+		ExtraExtensions: renewedExtensions(old),
+	}
+	if err := setKeyIDs(template, caCert, pub); err != nil {
+		return nil, err
+	}
+
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, pub, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renew certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse renewed certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// GenerateKeyLike generates a new private key of the same type and size as
+// pub: an RSA key with the same modulus size, an ECDSA key on the same curve,
+// or an Ed25519 key. It lets a renewal replace a key without changing its
+// strength.
+func GenerateKeyLike(pub crypto.PublicKey) (crypto.Signer, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return generateRSAKey(k.N.BitLen())
+	case *ecdsa.PublicKey:
+		key, err := ecdsa.GenerateKey(k.Curve, rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ECDSA key: %w", err)
+		}
+		return key, nil
+	case ed25519.PublicKey:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// keyUsageForKey drops keyEncipherment from usage unless pub is an RSA key.
+// Only RSA keys can encipher a session key; RFC 8813 forbids
+// keyEncipherment on ECDSA certificates.
+func keyUsageForKey(usage x509.KeyUsage, pub crypto.PublicKey) x509.KeyUsage {
+	if _, ok := pub.(*rsa.PublicKey); !ok {
+		usage &^= x509.KeyUsageKeyEncipherment
+	}
+	return usage
+}
+
+// checkRequestPublicKey rejects keys the generator would not issue for
+// itself, whether they come from a CSR, an existing key file or a renewal:
+// unsupported algorithms and RSA keys below config.MinKeySize.
+func checkRequestPublicKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if bits := k.N.BitLen(); bits < config.MinKeySize {
+			return fmt.Errorf("RSA key size must be at least %d bits, got %d", config.MinKeySize, bits)
+		}
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
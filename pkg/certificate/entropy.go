@@ -0,0 +1,107 @@
+package certificate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntropyAvailPath is where Linux reports its estimate, in bits, of the
+// entropy in the kernel's random pool.
+const EntropyAvailPath = "/proc/sys/kernel/random/entropy_avail"
+
+// PoolSizePath is where Linux reports the size, in bits, of the kernel's
+// random pool. The entropy estimate never exceeds it; since 5.18 both are
+// fixed at 256.
+const PoolSizePath = "/proc/sys/kernel/random/poolsize"
+
+// DefaultMinEntropy is the estimate, in bits, to wait for when no threshold
+// is given. Kernels since 5.18 report 256 once the pool is seeded.
+const DefaultMinEntropy = 256
+
+// ErrEntropyUnavailable is returned by an EntropySource on platforms that do
+// not expose an entropy estimate.
+var ErrEntropyUnavailable = errors.New("entropy estimate not available on this platform")
+
+// EntropySource returns the current entropy estimate in bits.
+type EntropySource func() (int, error)
+
+// KernelEntropy reads the estimate from EntropyAvailPath. It returns
+// ErrEntropyUnavailable outside Linux or when the file does not exist.
+func KernelEntropy() (int, error) {
+	return readKernelBits(EntropyAvailPath)
+}
+
+// KernelPoolSize reads the pool size from PoolSizePath, failing like
+// KernelEntropy. A threshold above it can never be reached.
+func KernelPoolSize() (int, error) {
+	return readKernelBits(PoolSizePath)
+}
+
+func readKernelBits(path string) (int, error) {
+	if runtime.GOOS != "linux" {
+		return 0, ErrEntropyUnavailable
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, ErrEntropyUnavailable
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+	return ReadEntropyAvail(f)
+}
+
+// ReadEntropyAvail parses an entropy_avail value from r.
+func ReadEntropyAvail(r io.Reader) (int, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 64))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read entropy estimate: %w", err)
+	}
+	bits, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || bits < 0 {
+		return 0, fmt.Errorf("invalid entropy estimate %q", strings.TrimSpace(string(data)))
+	}
+	return bits, nil
+}
+
+// CheckEntropy reports the estimate from src and whether it is at least minBits
+// bits. Where src returns ErrEntropyUnavailable the check is a no-op: avail
+// is -1 and ok is true.
+func CheckEntropy(src EntropySource, minBits int) (avail int, ok bool, err error) {
+	avail, err = src()
+	if errors.Is(err, ErrEntropyUnavailable) {
+		return -1, true, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return avail, avail >= minBits, nil
+}
+
+// WaitForEntropy polls src every interval until its estimate reaches minBits
+// bits, returning the last estimate. It returns ctx's error if ctx is done
+// first.
+func WaitForEntropy(ctx context.Context, src EntropySource, minBits int, interval time.Duration) (int, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		avail, ok, err := CheckEntropy(src, minBits)
+		if err != nil || ok {
+			return avail, err
+		}
+		select {
+		case <-ctx.Done():
+			return avail, fmt.Errorf("only %d of %d bits of entropy available: %w", avail, minBits, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// CheckExpiry reports whether cert expires within the given window. It returns
+// an error if the certificate is already expired or not yet valid; in the
+// expired case the bool is also true so callers can treat it as a warning.
+func CheckExpiry(cert *x509.Certificate, within time.Duration) (bool, error) {
+	if cert == nil {
+		return false, fmt.Errorf("certificate is nil")
+	}
+
+	now := time.Now()
+	if now.After(cert.NotAfter) {
+		return true, fmt.Errorf("certificate %q expired on %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(cert.NotBefore) {
+		return false, fmt.Errorf("certificate %q is not valid until %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+	}
+
+	return cert.NotAfter.Sub(now) <= within, nil
+}
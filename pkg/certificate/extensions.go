@@ -0,0 +1,158 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"net"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+var (
+	oidExtensionKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+	oidExtensionSubjectAltName   = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidExtensionSubjectKeyID     = asn1.ObjectIdentifier{2, 5, 29, 14}
+	oidExtensionAuthorityKeyID   = asn1.ObjectIdentifier{2, 5, 29, 35}
+)
+
+// GeneralName tags used in the subject alternative name extension.
+const (
+	sanTagDNSName   = 2
+	sanTagIPAddress = 7
+)
+
+// OIDExtensionCTPoison identifies the Certificate Transparency poison
+// extension of RFC 6962 section 3.1, which marks a pre-certificate.
+var OIDExtensionCTPoison = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+
+// ctPoisonExtension returns the poison extension: critical, so that no
+// client accepts the pre-certificate in place of the final certificate, with
+// an ASN.1 NULL value.
+func ctPoisonExtension() pkix.Extension {
+	return pkix.Extension{Id: OIDExtensionCTPoison, Critical: true, Value: asn1.NullBytes}
+}
+
+// applyCriticality adds explicit KeyUsage and BasicConstraints extensions to
+// template when opts asks for them to be non-critical. crypto/x509 always
+// marks both critical, but entries in ExtraExtensions take precedence over
+// the ones it derives from the template fields.
+func applyCriticality(template *x509.Certificate, opts *config.CertificateOptions) error {
+	if opts.NonCriticalKeyUsage && template.KeyUsage != 0 {
+		ext, err := keyUsageExtension(template.KeyUsage)
+		if err != nil {
+			return err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+	if opts.NonCriticalBasicConstraints && template.BasicConstraintsValid {
+		ext, err := basicConstraintsExtension(template)
+		if err != nil {
+			return err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+	return nil
+}
+
+// criticalSANExtension encodes dnsNames and ips as a critical subject
+// alternative name extension. RFC 5280 requires the critical bit when the
+// SANs are the only identity in the certificate, but crypto/x509 only sets
+// it when the whole subject is empty, not when just the Common Name is.
+func criticalSANExtension(dnsNames []string, ips []net.IP) (pkix.Extension, error) {
+	var names []asn1.RawValue
+	for _, name := range dnsNames {
+		names = append(names, asn1.RawValue{Tag: sanTagDNSName, Class: asn1.ClassContextSpecific, Bytes: []byte(name)})
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		names = append(names, asn1.RawValue{Tag: sanTagIPAddress, Class: asn1.ClassContextSpecific, Bytes: ip})
+	}
+
+	value, err := asn1.Marshal(names)
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("failed to marshal subject alternative names: %w", err)
+	}
+	return pkix.Extension{Id: oidExtensionSubjectAltName, Critical: true, Value: value}, nil
+}
+
+// customExtensions converts the configured custom extensions for use in
+// ExtraExtensions.
+func customExtensions(exts []config.Extension) []pkix.Extension {
+	out := make([]pkix.Extension, 0, len(exts))
+	for _, ext := range exts {
+		out = append(out, pkix.Extension{Id: ext.OID, Critical: ext.Critical, Value: ext.Value})
+	}
+	return out
+}
+
+// renewedExtensions returns the extensions of old to carry into its renewal:
+// all of them, with their criticality, except the key identifiers, which
+// belong to the new key and issuing CA. This keeps the extensions
+// crypto/x509 would not regenerate from the template, such as the CT poison,
+// certificate policies and custom extensions, as well as a critical SAN.
+func renewedExtensions(old *x509.Certificate) []pkix.Extension {
+	out := make([]pkix.Extension, 0, len(old.Extensions))
+	for _, ext := range old.Extensions {
+		if ext.Id.Equal(oidExtensionSubjectKeyID) || ext.Id.Equal(oidExtensionAuthorityKeyID) {
+			continue
+		}
+		out = append(out, ext)
+	}
+	return out
+}
+
+// keyUsageExtension encodes usage as a non-critical KeyUsage extension. The
+// BIT STRING numbers bits from the most significant end, so each byte of the
+// Go bit mask is reversed and trailing zero bits are trimmed.
+func keyUsageExtension(usage x509.KeyUsage) (pkix.Extension, error) {
+	var b [2]byte
+	b[0] = reverseBits(byte(usage))
+	b[1] = reverseBits(byte(usage >> 8))
+	n := 1
+	if b[1] != 0 {
+		n = 2
+	}
+	bits := b[:n]
+
+	bitLength := n * 8
+	for last := bits[n-1]; last != 0 && last&1 == 0; last >>= 1 {
+		bitLength--
+	}
+
+	value, err := asn1.Marshal(asn1.BitString{Bytes: bits, BitLength: bitLength})
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("failed to marshal key usage: %w", err)
+	}
+	return pkix.Extension{Id: oidExtensionKeyUsage, Value: value}, nil
+}
+
+func reverseBits(b byte) byte {
+	var r byte
+	for i := 0; i < 8; i++ {
+		r = r<<1 | b&1
+		b >>= 1
+	}
+	return r
+}
+
+// basicConstraintsExtension encodes the template's CA flag and path length
+// as a non-critical BasicConstraints extension.
+func basicConstraintsExtension(template *x509.Certificate) (pkix.Extension, error) {
+	maxPathLen := template.MaxPathLen
+	if maxPathLen == 0 && !template.MaxPathLenZero {
+		maxPathLen = -1
+	}
+	value, err := asn1.Marshal(struct {
+		IsCA       bool `asn1:"optional"`
+		MaxPathLen int  `asn1:"optional,default:-1"`
+	}{template.IsCA, maxPathLen})
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("failed to marshal basic constraints: %w", err)
+	}
+	return pkix.Extension{Id: oidExtensionBasicConstraints, Value: value}, nil
+}
@@ -0,0 +1,40 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+)
+
+// SelectIssuer returns the certificate in certs that belongs to key and may
+// issue certificates, so a CA file holding e.g. an intermediate followed by
+// its root can be used with either CA's key. A certificate matching the key
+// but lacking CA:TRUE or the certSign key usage is rejected, as is a key
+// matching none of the certificates.
+func SelectIssuer(certs []*x509.Certificate, key crypto.Signer) (*x509.Certificate, error) {
+	if key == nil {
+		return nil, fmt.Errorf("CA key is nil")
+	}
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, fmt.Errorf("unsupported CA key type %T", key.Public())
+	}
+
+	for _, cert := range certs {
+		if !pub.Equal(cert.PublicKey) {
+			continue
+		}
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			return nil, fmt.Errorf("certificate %q matches the CA key but is not a CA", cert.Subject.CommonName)
+		}
+		if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+			return nil, fmt.Errorf("certificate %q matches the CA key but lacks the certSign key usage", cert.Subject.CommonName)
+		}
+		return cert, nil
+	}
+
+	if len(certs) == 1 {
+		return nil, fmt.Errorf("CA key does not match CA certificate %q", certs[0].Subject.CommonName)
+	}
+	return nil, fmt.Errorf("CA key does not match any of the %d certificates in the CA bundle", len(certs))
+}
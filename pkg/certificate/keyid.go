@@ -0,0 +1,50 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// subjectKeyID computes a key identifier using method 1 of RFC 5280 section
+// 4.2.1.2: the SHA-1 hash of the subjectPublicKey bit string.
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	sum := sha1.Sum(spki.PublicKey.Bytes)
+	return sum[:], nil
+}
+
+// setKeyIDs fills in the subject key identifier of template and links its
+// authority key identifier to the issuing CA. A CA certificate loaded from
+// elsewhere may lack a subject key identifier; one is then derived from its
+// public key so chain builders can still match issuer and subject.
+func setKeyIDs(template, caCert *x509.Certificate, pub crypto.PublicKey) error {
+	skid, err := subjectKeyID(pub)
+	if err != nil {
+		return err
+	}
+	template.SubjectKeyId = skid
+
+	template.AuthorityKeyId = caCert.SubjectKeyId
+	if len(template.AuthorityKeyId) == 0 {
+		akid, err := subjectKeyID(caCert.PublicKey)
+		if err != nil {
+			return err
+		}
+		template.AuthorityKeyId = akid
+	}
+	return nil
+}
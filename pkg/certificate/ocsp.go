@@ -0,0 +1,41 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// GenerateOCSPResponse creates an OCSP response for leaf signed directly by
+// its issuer. status is one of ocsp.Good, ocsp.Revoked or ocsp.Unknown.
+func GenerateOCSPResponse(leaf, issuer *x509.Certificate, issuerKey crypto.Signer, status int) ([]byte, error) {
+	if leaf == nil || issuer == nil || issuerKey == nil {
+		return nil, fmt.Errorf("leaf certificate, issuer certificate and issuer key are required")
+	}
+
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: leaf.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(24 * time.Hour),
+	}
+
+	switch status {
+	case ocsp.Good, ocsp.Unknown:
+	case ocsp.Revoked:
+		template.RevokedAt = now
+		template.RevocationReason = ocsp.Unspecified
+	default:
+		return nil, fmt.Errorf("unsupported OCSP status %d", status)
+	}
+
+	resp, err := ocsp.CreateResponse(issuer, issuer, template, issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP response: %w", err)
+	}
+	return resp, nil
+}
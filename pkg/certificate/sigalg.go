@@ -0,0 +1,69 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+)
+
+// signatureAlgorithms maps config.SignatureAlgorithms names, which are those
+// x509.SignatureAlgorithm.String returns, to crypto/x509 values.
+var signatureAlgorithms = map[string]x509.SignatureAlgorithm{
+	"SHA256-RSA":    x509.SHA256WithRSA,
+	"SHA384-RSA":    x509.SHA384WithRSA,
+	"SHA512-RSA":    x509.SHA512WithRSA,
+	"SHA256-RSAPSS": x509.SHA256WithRSAPSS,
+	"SHA384-RSAPSS": x509.SHA384WithRSAPSS,
+	"SHA512-RSAPSS": x509.SHA512WithRSAPSS,
+	"ECDSA-SHA256":  x509.ECDSAWithSHA256,
+	"ECDSA-SHA384":  x509.ECDSAWithSHA384,
+	"ECDSA-SHA512":  x509.ECDSAWithSHA512,
+	"Ed25519":       x509.PureEd25519,
+}
+
+// signatureKeyTypes gives the key type each non-RSA signature algorithm
+// needs; the rest need RSA.
+var signatureKeyTypes = map[x509.SignatureAlgorithm]x509.PublicKeyAlgorithm{
+	x509.ECDSAWithSHA256: x509.ECDSA,
+	x509.ECDSAWithSHA384: x509.ECDSA,
+	x509.ECDSAWithSHA512: x509.ECDSA,
+	x509.PureEd25519:     x509.Ed25519,
+}
+
+// signatureAlgorithm returns the configured SignatureAlgorithm for signing
+// with the private half of pub, checking that it suits the key type. When
+// none is configured it returns x509.UnknownSignatureAlgorithm, which lets
+// crypto/x509 choose.
+func (g *Generator) signatureAlgorithm(pub crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	name := g.config.SignatureAlgorithm
+	if name == "" {
+		return x509.UnknownSignatureAlgorithm, nil
+	}
+	alg, ok := signatureAlgorithms[name]
+	if !ok {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unknown signature algorithm %q", name)
+	}
+
+	var have x509.PublicKeyAlgorithm
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		have = x509.RSA
+	case *ecdsa.PublicKey:
+		have = x509.ECDSA
+	case ed25519.PublicKey:
+		have = x509.Ed25519
+	default:
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported signing key type %T", pub)
+	}
+	want, ok := signatureKeyTypes[alg]
+	if !ok {
+		want = x509.RSA
+	}
+	if have != want {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("signature algorithm %s needs a key of type %v, but the signing key is %v", name, want, have)
+	}
+	return alg, nil
+}
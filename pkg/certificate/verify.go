@@ -0,0 +1,40 @@
+package certificate
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// VerifyLeaf checks that leaf chains to root and is currently valid. When
+// dnsName is set the leaf must also be valid for that host, and when
+// extKeyUsages is set (by name, e.g. "serverAuth") it must allow each of
+// them; otherwise any extended key usage is accepted.
+func VerifyLeaf(leaf, root *x509.Certificate, dnsName string, extKeyUsages []string) error {
+	if leaf == nil || root == nil {
+		return fmt.Errorf("leaf and root certificates are required")
+	}
+
+	usages := []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	if len(extKeyUsages) > 0 {
+		parsed, err := parseExtKeyUsage(extKeyUsages)
+		if err != nil {
+			return err
+		}
+		usages = parsed
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	// Verify accepts a chain if it allows any one of KeyUsages, so check
+	// each requested usage separately.
+	for _, usage := range usages {
+		if _, err := leaf.Verify(x509.VerifyOptions{
+			DNSName:   dnsName,
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{usage},
+		}); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+	}
+	return nil
+}
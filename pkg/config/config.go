@@ -3,11 +3,134 @@
 package config
 
 import (
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Built-in leaf certificate profiles.
+const (
+	ProfileServer      = "server"
+	ProfileClient      = "client"
+	ProfileBoth        = "both"
+	ProfileWebServer   = "webserver"
+	ProfileCodeSigning = "codesigning"
+	ProfileCA          = "ca"
+)
+
+// Profile is a named bundle of leaf certificate defaults. A zero
+// ValidityDays leaves the configured validity unchanged.
+type Profile struct {
+	KeyUsage     []string `json:"key_usage"`
+	ExtKeyUsage  []string `json:"ext_key_usage"`
+	IsCA         bool     `json:"is_ca"`
+	ValidityDays int      `json:"validity_days"`
+}
+
+var leafKeyUsage = []string{"digitalSignature", "keyEncipherment"}
+
+// Profiles is the registry of leaf profiles selectable by name. LoadProfiles
+// adds to it from a file.
+var Profiles = map[string]Profile{
+	ProfileServer: {KeyUsage: leafKeyUsage, ExtKeyUsage: []string{"serverAuth"}},
+	ProfileClient: {KeyUsage: leafKeyUsage, ExtKeyUsage: []string{"clientAuth"}},
+	ProfileBoth:   {KeyUsage: leafKeyUsage, ExtKeyUsage: []string{"serverAuth", "clientAuth"}},
+	// webserver follows the CA/Browser Forum 398-day limit for public TLS.
+	ProfileWebServer:   {KeyUsage: leafKeyUsage, ExtKeyUsage: []string{"serverAuth"}, ValidityDays: 398},
+	ProfileCodeSigning: {KeyUsage: []string{"digitalSignature"}, ExtKeyUsage: []string{"codeSigning"}},
+	ProfileCA:          {KeyUsage: []string{"keyCertSign", "cRLSign"}, IsCA: true, ValidityDays: 1825},
+}
+
+// LookupProfile returns the named profile. An empty name is treated as
+// ProfileBoth.
+func LookupProfile(name string) (Profile, error) {
+	if name == "" {
+		name = ProfileBoth
+	}
+	profile, ok := Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q (want one of %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// ProfileNames returns the registered profile names in sorted order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadProfiles reads a JSON object mapping profile names to profiles and
+// adds them to Profiles, replacing built-in profiles of the same name.
+func LoadProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read profiles file: %w", err)
+	}
+	var profiles map[string]Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse profiles file %s: %w", path, err)
+	}
+	for name, profile := range profiles {
+		if name == "" {
+			return fmt.Errorf("profiles file %s defines a profile without a name", path)
+		}
+		if profile.ValidityDays < 0 {
+			return fmt.Errorf("profile %q: validity_days must not be negative", name)
+		}
+		Profiles[name] = profile
+	}
+	return nil
+}
+
+// SignatureAlgorithms lists the names accepted in
+// CertificateConfig.SignatureAlgorithm. All but the ECDSA and Ed25519 ones
+// suit the RSA keys certgen generates.
+var SignatureAlgorithms = []string{
+	"SHA256-RSA", "SHA384-RSA", "SHA512-RSA",
+	"SHA256-RSAPSS", "SHA384-RSAPSS", "SHA512-RSAPSS",
+	"ECDSA-SHA256", "ECDSA-SHA384", "ECDSA-SHA512",
+	"Ed25519",
+}
+
+// DefaultValidityDays is the leaf validity NewCertificateConfig starts with,
+// and the one used when ValidityDays is zero and the profile does not set
+// one.
+const DefaultValidityDays = 3650
+
+// BrowserMaxValidityDays is the longest lifetime browsers accept for a TLS
+// server certificate under the CA/Browser Forum baseline requirements.
+const BrowserMaxValidityDays = 398
+
+// Serial number size bounds in bits. CA/Browser Forum requires at least 64
+// bits of entropy; RFC 5280 caps serials at 20 octets.
+const (
+	DefaultSerialBits = 128
+	MinSerialBits     = 64
+	MaxSerialBits     = 160
 )
 
 type CertificateConfig struct {
 	Domain             string
+	CommonName         string
+	DNSNames           []string
+	IPAddresses        []net.IP
 	Country            string
 	State              string
 	Locality           string
@@ -16,6 +139,141 @@ type CertificateConfig struct {
 	ValidityDays       int
 	KeySize            int
 	PKCS12Password     string
+	Profile            string
+	SerialBits         int
+
+	// AllowAnyCountry skips the ISO 3166 check on Country in Validate. It is
+	// never taken from JSON, so server clients cannot turn the check off.
+	AllowAnyCountry bool `json:"-"`
+
+	// ServerOnly drops clientAuth from the leaf's extended key usages, for
+	// server certificates checked by validators that reject extra EKUs.
+	ServerOnly bool
+
+	// WithWWW adds the www. variant of an apex Domain to the DNS SANs; see
+	// WWWName for when it applies.
+	WithWWW bool
+
+	// NoCommonName leaves the Common Name out of the leaf subject, so the leaf
+	// is identified by its Subject Alternative Names alone, which are then
+	// marked critical. The root CA keeps its Common Name.
+	NoCommonName bool
+
+	// RootCommonName and RootOrganization override the subject of the root CA
+	// only. When empty, the root uses the same CN and Organization as the leaf.
+	RootCommonName   string
+	RootOrganization string
+
+	// RootKeySize and LeafKeySize set the RSA key size of the root CA and of
+	// the leaf, e.g. a 4096-bit root with faster 2048-bit leaves. When zero,
+	// KeySize is used.
+	RootKeySize int
+	LeafKeySize int
+
+	// CACommonNameSuffix, when set, is appended to the root CA's Common Name
+	// after a space, labelling it e.g. "example.com Root CA". It is not
+	// applied when RootCommonName is set, nor to leaves.
+	CACommonNameSuffix string
+
+	// RootNoSAN leaves the Subject Alternative Name extension off the root CA.
+	// A pure CA has no use for DNS names of its own.
+	RootNoSAN bool
+
+	// PermittedDNSDomains and ExcludedDNSDomains become name constraints on
+	// the root CA, limiting which DNS names it may issue for.
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+
+	// NotBefore fixes the start of the validity period for both certificates,
+	// making output reproducible. When nil, the current time is used.
+	NotBefore *time.Time
+
+	// clock supplies the current time when NotBefore is unset; see SetClock.
+	clock func() time.Time
+
+	// NonCriticalBasicConstraints and NonCriticalKeyUsage clear the critical
+	// bit on those extensions, which is otherwise always set. Some legacy
+	// verifiers reject certificates with critical extensions they don't parse.
+	NonCriticalBasicConstraints bool
+	NonCriticalKeyUsage         bool
+
+	// Extensions are added verbatim to the leaf certificate.
+	Extensions []Extension
+
+	// Precert adds the critical CT poison extension to the leaf, making it an
+	// RFC 6962 pre-certificate for Certificate Transparency testing. TLS
+	// clients reject pre-certificates.
+	Precert bool
+
+	// PKCS12MACAlgorithm and PKCS12MACIterations override the PKCS#12 MAC
+	// digest and iteration count. Empty and zero keep the defaults.
+	PKCS12MACAlgorithm  string
+	PKCS12MACIterations int
+
+	// SignatureAlgorithm, one of SignatureAlgorithms, sets the algorithm used
+	// to sign the root, the leaf and certificate requests, for CAs that
+	// require a particular digest. Empty lets crypto/x509 choose.
+	SignatureAlgorithm string
+
+	// PolicyOIDs are asserted in the certificate policies extension of both
+	// the root and the leaf.
+	PolicyOIDs []asn1.ObjectIdentifier
+
+	// Serial, when set, is used as the leaf serial number instead of a
+	// random one of SerialBits bits, e.g. from a castore serial counter. It
+	// is never taken from JSON.
+	Serial *big.Int `json:"-"`
+
+	// MaxValidityDays, when positive, is an operator-imposed hard cap on the
+	// leaf validity period: Validate rejects anything longer. It is never
+	// taken from JSON, so server clients cannot lift it.
+	MaxValidityDays int `json:"-"`
+
+	// ClampToCA caps the leaf's NotAfter at the issuing CA's NotAfter, so a
+	// leaf never outlives the CA that signed it.
+	ClampToCA bool
+
+	// Validity, when positive, sets the leaf validity period instead of
+	// ValidityDays, allowing periods shorter than a day.
+	Validity time.Duration
+
+	// SubjectOverrides replaces subject fields of the leaf issued for a
+	// domain, e.g. to give each tenant's leaf its own Organization. Domains
+	// without an entry use the fields above.
+	SubjectOverrides map[string]SubjectOverride
+}
+
+// SubjectOverride holds the leaf subject fields that may differ per domain.
+// Empty fields keep the configured value.
+type SubjectOverride struct {
+	Organization       string `json:"organization"`
+	OrganizationalUnit string `json:"organizational_unit"`
+}
+
+// LoadSubjectOverrides reads a JSON object mapping leaf domains to subject
+// overrides, for use as CertificateConfig.SubjectOverrides.
+func LoadSubjectOverrides(path string) (map[string]SubjectOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subject overrides file: %w", err)
+	}
+	var overrides map[string]SubjectOverride
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse subject overrides file %s: %w", path, err)
+	}
+	for domain := range overrides {
+		if domain == "" {
+			return nil, fmt.Errorf("subject overrides file %s has an entry without a domain", path)
+		}
+	}
+	return overrides, nil
+}
+
+// Extension is a custom X.509 extension with a DER-encoded value.
+type Extension struct {
+	OID      asn1.ObjectIdentifier
+	Value    []byte
+	Critical bool
 }
 
 type Subject struct {
@@ -28,13 +286,22 @@ type Subject struct {
 }
 
 type CertificateOptions struct {
-	Subject     Subject
-	DNSNames    []string
-	ValidFrom   time.Time
-	ValidFor    time.Duration
-	IsCA        bool
-	KeyUsage    []string
-	ExtKeyUsage []string
+	Subject             Subject
+	DNSNames            []string
+	IPAddresses         []net.IP
+	ValidFrom           time.Time
+	ValidFor            time.Duration
+	IsCA                bool
+	KeyUsage            []string
+	ExtKeyUsage         []string
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+
+	NonCriticalBasicConstraints bool
+	NonCriticalKeyUsage         bool
+	Extensions                  []Extension
+	PolicyOIDs                  []asn1.ObjectIdentifier
+	Precert                     bool
 }
 
 func NewCertificateConfig() *CertificateConfig {
@@ -44,45 +311,414 @@ func NewCertificateConfig() *CertificateConfig {
 		Locality:           "Singapore",
 		Organization:       "Erfi Corp",
 		OrganizationalUnit: "Erfi Proxy",
-		ValidityDays:       3650,
+		ValidityDays:       DefaultValidityDays,
 		KeySize:            4096,
 		PKCS12Password:     "yourPKCS12Password",
+		Profile:            ProfileBoth,
+		SerialBits:         DefaultSerialBits,
+	}
+}
+
+// RedactedPassword replaces secrets in Redacted output.
+const RedactedPassword = "********"
+
+// Redacted returns a copy of the config that is safe to print, with the
+// PKCS#12 password masked.
+func (c *CertificateConfig) Redacted() *CertificateConfig {
+	redacted := *c
+	if redacted.PKCS12Password != "" {
+		redacted.PKCS12Password = RedactedPassword
+	}
+	return &redacted
+}
+
+// ExtKeyUsageForProfile returns the extended key usages for a leaf profile.
+// An empty profile is treated as ProfileBoth.
+func ExtKeyUsageForProfile(profile string) ([]string, error) {
+	p, err := LookupProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return p.ExtKeyUsage, nil
+}
+
+// Identity names the certificate's subject for file names and messages:
+// Domain, or the first IP address for an IP-only certificate.
+func (c *CertificateConfig) Identity() string {
+	if c.Domain == "" && len(c.IPAddresses) > 0 {
+		return c.IPAddresses[0].String()
+	}
+	return c.Domain
+}
+
+// FileIdentity is Identity made safe for file names: the colons of an IPv6
+// address, which Windows does not allow in file names, become dashes.
+func (c *CertificateConfig) FileIdentity() string {
+	return strings.ReplaceAll(c.Identity(), ":", "-")
+}
+
+// RootKeyBits returns the RSA key size for the root CA: RootKeySize, or
+// KeySize when that is unset.
+func (c *CertificateConfig) RootKeyBits() int {
+	if c.RootKeySize != 0 {
+		return c.RootKeySize
+	}
+	return c.KeySize
+}
+
+// LeafKeyBits returns the RSA key size for leaves: LeafKeySize, or KeySize
+// when that is unset.
+func (c *CertificateConfig) LeafKeyBits() int {
+	if c.LeafKeySize != 0 {
+		return c.LeafKeySize
+	}
+	return c.KeySize
+}
+
+// commonName returns the explicit CommonName, falling back to Identity.
+func (c *CertificateConfig) commonName() string {
+	if c.CommonName != "" {
+		return c.CommonName
+	}
+	return c.Identity()
+}
+
+// dnsNames returns Domain followed by any additional DNS SANs, without
+// duplicates. An IP-only certificate has no Domain to lead with.
+func (c *CertificateConfig) dnsNames() []string {
+	extra := c.DNSNames
+	if c.WithWWW {
+		if www, ok := WWWName(c.Domain); ok {
+			extra = append([]string{www}, extra...)
+		}
+	}
+
+	var names []string
+	if c.Domain != "" {
+		names = append(names, c.Domain)
+	}
+	seen := map[string]bool{c.Domain: true}
+	for _, name := range extra {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// WWWName returns www.domain when domain is a registrable apex such as
+// example.com or example.co.uk. Names that are already www., wildcards,
+// other subdomains, public suffixes or single labels like localhost are
+// left alone, reporting false.
+func WWWName(domain string) (string, bool) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if strings.HasPrefix(domain, "www.") || strings.HasPrefix(domain, "*.") || !strings.Contains(domain, ".") {
+		return "", false
+	}
+	apex, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil || apex != domain {
+		return "", false
+	}
+	return "www." + domain, true
+}
+
+// dnsProfile maps names the way lookups do (lowercasing, Unicode
+// normalization) but, unlike idna.Lookup, tolerates underscores, which appear
+// in service names such as _dmarc.example.com.
+var dnsProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+)
+
+// NormalizeDNSName lowercases name and converts Unicode labels to their
+// punycode A-label form, e.g. München.example.com becomes
+// xn--mnchen-3ya.example.com. A leading wildcard label is preserved.
+func NormalizeDNSName(name string) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(name, "*.") {
+		prefix, name = "*.", name[2:]
+	}
+	ascii, err := dnsProfile.ToASCII(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid DNS name %q: %w", prefix+name, err)
+	}
+	return prefix + ascii, nil
+}
+
+// Normalize applies NormalizeDNSName to Domain, DNSNames and the
+// SubjectOverrides domains, so the names end up in the certificate in the
+// form TLS clients compare against and overrides still match them.
+func (c *CertificateConfig) Normalize() error {
+	var err error
+	if c.Domain != "" {
+		if c.Domain, err = NormalizeDNSName(c.Domain); err != nil {
+			return err
+		}
+	}
+
+	for i, name := range c.DNSNames {
+		if c.DNSNames[i], err = NormalizeDNSName(name); err != nil {
+			return err
+		}
+	}
+
+	if len(c.SubjectOverrides) > 0 {
+		overrides := make(map[string]SubjectOverride, len(c.SubjectOverrides))
+		for name, override := range c.SubjectOverrides {
+			normalized, err := NormalizeDNSName(name)
+			if err != nil {
+				return err
+			}
+			overrides[normalized] = override
+		}
+		c.SubjectOverrides = overrides
+	}
+	return nil
+}
+
+// NormalizeCountry uppercases country and checks that it is an ISO 3166
+// alpha-2 code, i.e. exactly two letters, as X.509 requires. An empty
+// country is left empty.
+func NormalizeCountry(country string) (string, error) {
+	if country == "" {
+		return "", nil
+	}
+	upper := strings.ToUpper(country)
+	if len(upper) != 2 || upper[0] < 'A' || upper[0] > 'Z' || upper[1] < 'A' || upper[1] > 'Z' {
+		return "", fmt.Errorf("invalid country %q: must be a two-letter ISO 3166 code such as SG or US", country)
+	}
+	return upper, nil
+}
+
+// ParseExtension parses a custom extension given as OID:base64value or
+// OID:base64value:critical, e.g. 1.3.6.1.4.1.99999.1:BAVoZWxsbw==:critical.
+// The value must already be DER-encoded.
+func ParseExtension(spec string) (Extension, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Extension{}, fmt.Errorf("extension %q must be OID:base64value[:critical]", spec)
+	}
+
+	oid, err := ParseOID(parts[0])
+	if err != nil {
+		return Extension{}, err
+	}
+	value, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Extension{}, fmt.Errorf("extension %s: invalid base64 value: %w", parts[0], err)
+	}
+
+	ext := Extension{OID: oid, Value: value}
+	if len(parts) == 3 {
+		if parts[2] != "critical" {
+			return Extension{}, fmt.Errorf("extension %s: expected \"critical\", got %q", parts[0], parts[2])
+		}
+		ext.Critical = true
+	}
+	return ext, nil
+}
+
+// ParseOID parses a dotted-decimal object identifier such as 1.2.840.113549.
+func ParseOID(s string) (asn1.ObjectIdentifier, error) {
+	arcs := strings.Split(s, ".")
+	if len(arcs) < 2 {
+		return nil, fmt.Errorf("invalid OID %q: need at least two arcs", s)
+	}
+	oid := make(asn1.ObjectIdentifier, len(arcs))
+	for i, arc := range arcs {
+		n, err := strconv.Atoi(arc)
+		if err != nil || n < 0 || arc != strconv.Itoa(n) {
+			return nil, fmt.Errorf("invalid OID %q: arc %q is not a non-negative integer", s, arc)
+		}
+		oid[i] = n
+	}
+	if oid[0] > 2 || (oid[0] < 2 && oid[1] >= 40) {
+		return nil, fmt.Errorf("invalid OID %q: first arcs out of range", s)
+	}
+	return oid, nil
+}
+
+// ParseDNSNames splits a comma-separated list of DNS names, trimming
+// whitespace and dropping empty and duplicate entries.
+func ParseDNSNames(list string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
 	}
+	return names
+}
+
+// SetClock replaces time.Now as the source of the current time, so tests can
+// freeze it and assert exact validity periods. A nil clock restores time.Now.
+func (c *CertificateConfig) SetClock(now func() time.Time) {
+	c.clock = now
+}
+
+// validFrom returns NotBefore when set, otherwise the current time.
+func (c *CertificateConfig) validFrom() time.Time {
+	if c.NotBefore != nil {
+		return *c.NotBefore
+	}
+	if c.clock != nil {
+		return c.clock()
+	}
+	return time.Now()
+}
+
+// ForDomain returns a copy of the config for a separate leaf issued for
+// domain. The explicit CommonName, extra DNS names and IP addresses are
+// dropped, since they belong to the original domain.
+func (c *CertificateConfig) ForDomain(domain string) *CertificateConfig {
+	leaf := *c
+	leaf.Domain = domain
+	leaf.CommonName = ""
+	leaf.DNSNames = nil
+	leaf.IPAddresses = nil
+	return &leaf
+}
+
+// IndexedDomain derives the domain of the index-th of count leaves by
+// suffixing its first label, e.g. client.example.com becomes
+// client-001.example.com. Indexes are zero-padded to at least three digits.
+func IndexedDomain(domain string, index, count int) string {
+	width := len(strconv.Itoa(count))
+	if width < 3 {
+		width = 3
+	}
+	label, rest, found := strings.Cut(domain, ".")
+	name := fmt.Sprintf("%s-%0*d", label, width, index)
+	if found {
+		name += "." + rest
+	}
+	return name
 }
 
 func (c *CertificateConfig) GetRootCAOptions() *CertificateOptions {
+	commonName := c.commonName()
+	if c.RootCommonName != "" {
+		commonName = c.RootCommonName
+	} else if c.CACommonNameSuffix != "" {
+		commonName += " " + c.CACommonNameSuffix
+	}
+	organization := c.Organization
+	if c.RootOrganization != "" {
+		organization = c.RootOrganization
+	}
+	dnsNames, ipAddresses := c.dnsNames(), c.IPAddresses
+	if c.RootNoSAN {
+		dnsNames, ipAddresses = nil, nil
+	}
+
 	return &CertificateOptions{
 		Subject: Subject{
 			Country:            c.Country,
 			State:              c.State,
 			Locality:           c.Locality,
-			Organization:       c.Organization,
+			Organization:       organization,
 			OrganizationalUnit: c.OrganizationalUnit,
-			CommonName:         c.Domain,
+			CommonName:         commonName,
 		},
-		DNSNames:  []string{c.Domain},
-		ValidFrom: time.Now(),
-		ValidFor:  1024 * 24 * time.Hour,
-		IsCA:      true,
-		KeyUsage:  []string{"keyCertSign", "cRLSign"},
+		DNSNames:            dnsNames,
+		IPAddresses:         ipAddresses,
+		ValidFrom:           c.validFrom(),
+		ValidFor:            1024 * 24 * time.Hour,
+		IsCA:                true,
+		KeyUsage:            []string{"keyCertSign", "cRLSign"},
+		PermittedDNSDomains: c.PermittedDNSDomains,
+		ExcludedDNSDomains:  c.ExcludedDNSDomains,
+		PolicyOIDs:          c.PolicyOIDs,
+
+		NonCriticalBasicConstraints: c.NonCriticalBasicConstraints,
+		NonCriticalKeyUsage:         c.NonCriticalKeyUsage,
 	}
 }
 
+// ExceedsBrowserValidity reports whether the leaf is a server-auth certificate
+// valid for longer than BrowserMaxValidityDays. CA profiles are exempt.
+func (c *CertificateConfig) ExceedsBrowserValidity() bool {
+	opts := c.GetLeafCertOptions()
+	if opts.IsCA || !slices.Contains(opts.ExtKeyUsage, "serverAuth") {
+		return false
+	}
+	return opts.ValidFor > BrowserMaxValidityDays*24*time.Hour
+}
+
+// LeafValidityDays returns the leaf validity in days: ValidityDays when it is
+// set, so an explicit value always wins, otherwise the profile's validity,
+// otherwise DefaultValidityDays. NewCertificateConfig sets ValidityDays, so
+// set it to 0 to take the profile's validity.
+func (c *CertificateConfig) LeafValidityDays() int {
+	if c.ValidityDays != 0 {
+		return c.ValidityDays
+	}
+	if profile, err := LookupProfile(c.Profile); err == nil && profile.ValidityDays > 0 {
+		return profile.ValidityDays
+	}
+	return DefaultValidityDays
+}
+
 func (c *CertificateConfig) GetLeafCertOptions() *CertificateOptions {
+	profile, err := LookupProfile(c.Profile)
+	if err != nil {
+		profile = Profiles[ProfileBoth]
+	}
+
+	subject := Subject{
+		Country:            c.Country,
+		State:              c.State,
+		Locality:           c.Locality,
+		Organization:       c.Organization,
+		OrganizationalUnit: c.OrganizationalUnit,
+		CommonName:         c.commonName(),
+	}
+	if c.NoCommonName {
+		subject.CommonName = ""
+	}
+	if override, ok := c.SubjectOverrides[c.Domain]; ok {
+		if override.Organization != "" {
+			subject.Organization = override.Organization
+		}
+		if override.OrganizationalUnit != "" {
+			subject.OrganizationalUnit = override.OrganizationalUnit
+		}
+	}
+
+	validFor := time.Duration(c.LeafValidityDays()) * 24 * time.Hour
+	if c.Validity > 0 {
+		validFor = c.Validity
+	}
+
+	extKeyUsage := profile.ExtKeyUsage
+	if c.ServerOnly {
+		extKeyUsage = slices.DeleteFunc(slices.Clone(extKeyUsage), func(usage string) bool {
+			return usage == "clientAuth"
+		})
+	}
+
 	return &CertificateOptions{
-		Subject: Subject{
-			Country:            c.Country,
-			State:              c.State,
-			Locality:           c.Locality,
-			Organization:       c.Organization,
-			OrganizationalUnit: c.OrganizationalUnit,
-			CommonName:         c.Domain,
-		},
-		DNSNames:    []string{c.Domain},
-		ValidFrom:   time.Now(),
-		ValidFor:    time.Duration(c.ValidityDays) * 24 * time.Hour,
-		IsCA:        false,
-		KeyUsage:    []string{"digitalSignature", "nonRepudiation", "keyEncipherment", "dataEncipherment"},
-		ExtKeyUsage: []string{"serverAuth", "clientAuth"},
+		Subject:     subject,
+		DNSNames:    c.dnsNames(),
+		IPAddresses: c.IPAddresses,
+		ValidFrom:   c.validFrom(),
+		ValidFor:    validFor,
+		IsCA:        profile.IsCA,
+		KeyUsage:    profile.KeyUsage,
+		ExtKeyUsage: extKeyUsage,
+
+		NonCriticalBasicConstraints: c.NonCriticalBasicConstraints,
+		NonCriticalKeyUsage:         c.NonCriticalKeyUsage,
+		Extensions:                  c.Extensions,
+		PolicyOIDs:                  c.PolicyOIDs,
+		Precert:                     c.Precert,
 	}
 }
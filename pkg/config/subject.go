@@ -0,0 +1,172 @@
+package config
+
+import (
+	"crypto/x509/pkix"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// dnSpecial lists the characters RFC 4514 allows to be escaped with a
+// single backslash.
+const dnSpecial = ` "#+,;<=>\`
+
+// subjectField maps an RFC 4514 attribute type, either as a short name or a
+// dotted OID, to the Subject field it populates.
+func subjectField(s *Subject, attrType string) (*string, string, error) {
+	switch strings.ToUpper(attrType) {
+	case "CN", "2.5.4.3":
+		return &s.CommonName, "CN", nil
+	case "OU", "2.5.4.11":
+		return &s.OrganizationalUnit, "OU", nil
+	case "O", "2.5.4.10":
+		return &s.Organization, "O", nil
+	case "L", "2.5.4.7":
+		return &s.Locality, "L", nil
+	case "ST", "2.5.4.8":
+		return &s.State, "ST", nil
+	case "C", "2.5.4.6":
+		return &s.Country, "C", nil
+	}
+	return nil, "", fmt.Errorf("unsupported attribute type %q: use CN, OU, O, L, ST or C", attrType)
+}
+
+// ParseSubjectDN parses an RFC 4514 distinguished name such as
+// "CN=svc,O=Acme\, Inc.,C=US" into a Subject. Backslash escapes, including
+// \XX hex pairs, are decoded, and multi-valued RDNs joined with + are
+// accepted. Each attribute may appear at most once; attributes missing from
+// dn are left empty.
+func ParseSubjectDN(dn string) (Subject, error) {
+	var s Subject
+	if strings.TrimSpace(dn) == "" {
+		return s, fmt.Errorf("empty distinguished name")
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; ; {
+		eq := strings.IndexByte(dn[i:], '=')
+		if eq < 0 {
+			return Subject{}, fmt.Errorf("missing '=' in %q", strings.TrimSpace(dn[i:]))
+		}
+		field, name, err := subjectField(&s, strings.TrimSpace(dn[i:i+eq]))
+		if err != nil {
+			return Subject{}, err
+		}
+		value, next, err := parseDNValue(dn, i+eq+1)
+		if err != nil {
+			return Subject{}, fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if value == "" {
+			return Subject{}, fmt.Errorf("empty value for %s", name)
+		}
+		if seen[name] {
+			return Subject{}, fmt.Errorf("duplicate attribute %s", name)
+		}
+		seen[name] = true
+		*field = value
+
+		if next == len(dn) {
+			return s, nil
+		}
+		i = next + 1
+	}
+}
+
+// parseDNValue reads an attribute value starting at dn[start] up to the next
+// unescaped ',', ';' or '+'. It returns the decoded value and the index of
+// the separator, or len(dn) at the end of the string. Unescaped spaces around
+// the value are dropped.
+func parseDNValue(dn string, start int) (string, int, error) {
+	i := start
+	for i < len(dn) && dn[i] == ' ' {
+		i++
+	}
+	if i < len(dn) && dn[i] == '#' {
+		return "", 0, fmt.Errorf("hex-encoded BER values are not supported")
+	}
+
+	var buf []byte
+	keep := 0 // length of buf excluding trailing unescaped spaces
+	for ; i < len(dn); i++ {
+		c := dn[i]
+		switch {
+		case c == ',' || c == ';' || c == '+':
+			return checkUTF8(buf[:keep], i)
+		case c == '\\':
+			if i+1 >= len(dn) {
+				return "", 0, fmt.Errorf("trailing backslash")
+			}
+			n := dn[i+1]
+			if i+2 < len(dn) && isHexDigit(n) && isHexDigit(dn[i+2]) {
+				buf = append(buf, hexValue(n)<<4|hexValue(dn[i+2]))
+				i += 2
+			} else if strings.IndexByte(dnSpecial, n) >= 0 {
+				buf = append(buf, n)
+				i++
+			} else {
+				return "", 0, fmt.Errorf("invalid escape \\%c", n)
+			}
+			keep = len(buf)
+		default:
+			buf = append(buf, c)
+			if c != ' ' {
+				keep = len(buf)
+			}
+		}
+	}
+	return checkUTF8(buf[:keep], len(dn))
+}
+
+func checkUTF8(value []byte, next int) (string, int, error) {
+	if !utf8.Valid(value) {
+		return "", 0, fmt.Errorf("escaped bytes are not valid UTF-8")
+	}
+	return string(value), next, nil
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func hexValue(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c >= 'a':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// PKIXName converts the subject to a pkix.Name, leaving out empty attributes
+// instead of encoding them as empty strings.
+func (s Subject) PKIXName() pkix.Name {
+	return pkix.Name{
+		Country:            nonEmpty(s.Country),
+		Province:           nonEmpty(s.State),
+		Locality:           nonEmpty(s.Locality),
+		Organization:       nonEmpty(s.Organization),
+		OrganizationalUnit: nonEmpty(s.OrganizationalUnit),
+		CommonName:         s.CommonName,
+	}
+}
+
+func nonEmpty(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return []string{v}
+}
+
+// ApplySubject replaces the configured subject attributes with s, as
+// --subject does. Attributes absent from s are cleared; an empty CommonName
+// still defaults to the domain.
+func (c *CertificateConfig) ApplySubject(s Subject) {
+	c.Country = s.Country
+	c.State = s.State
+	c.Locality = s.Locality
+	c.Organization = s.Organization
+	c.OrganizationalUnit = s.OrganizationalUnit
+	c.CommonName = s.CommonName
+}
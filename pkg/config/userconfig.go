@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// UserConfigName is the per-user defaults file in the home directory.
+const UserConfigName = ".certgenrc"
+
+// UserConfigPaths returns the locations searched for per-user defaults, in
+// order: certgen/config under the user config directory
+// ($XDG_CONFIG_HOME, or ~/.config, on Linux), then ~/.certgenrc.
+func UserConfigPaths() []string {
+	var paths []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "certgen", "config"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, UserConfigName))
+	}
+	return paths
+}
+
+// LoadUserDefaults overlays the first per-user defaults file found in
+// UserConfigPaths onto cfg and returns its path. The file is a JSON object
+// of CertificateConfig fields, e.g. {"Organization": "Acme", "Country":
+// "US"}; fields it omits keep their value. Call it before applying flags so
+// that anything given on the command line takes precedence. A missing file
+// is not an error and yields an empty path.
+func LoadUserDefaults(cfg *CertificateConfig) (string, error) {
+	for _, path := range UserConfigPaths() {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read user config: %w", err)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return "", fmt.Errorf("failed to parse user config %s: %w", path, err)
+		}
+		return path, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MinKeySize is the smallest RSA key size the generator accepts.
+	MinKeySize = 1024
+
+	// MaxValidityDays caps ValidityDays at 100 years.
+	MaxValidityDays = 36500
+
+	// MaxValidityEnv names the environment variable holding an operator's
+	// cap on leaf validity in days; see CertificateConfig.MaxValidityDays.
+	MaxValidityEnv = "CERTGEN_MAX_VALIDITY"
+)
+
+// MaxValidityDaysFromEnv returns the cap set in MaxValidityEnv, or 0 when the
+// variable is unset or empty.
+func MaxValidityDaysFromEnv() (int, error) {
+	value := os.Getenv(MaxValidityEnv)
+	if value == "" {
+		return 0, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		return 0, fmt.Errorf("%s must be a positive number of days, got %q", MaxValidityEnv, value)
+	}
+	return days, nil
+}
+
+// Validate checks cfg for the mistakes the CLI and server reject before
+// generating anything: a missing domain, a validity period or key size out
+// of range (including above MaxValidityDays when set), an unknown profile
+// and an invalid country. All problems are
+// reported together, joined with errors.Join. Validate does not modify cfg;
+// call Normalize separately to canonicalize domain names.
+func Validate(cfg *CertificateConfig) error {
+	if cfg == nil {
+		return fmt.Errorf("configuration is nil")
+	}
+
+	var errs []error
+	if cfg.Domain == "" && len(cfg.IPAddresses) == 0 {
+		errs = append(errs, fmt.Errorf("domain or IP address is required"))
+	}
+	if cfg.NoCommonName && cfg.CommonName != "" {
+		errs = append(errs, fmt.Errorf("a common name cannot be set when the common name is omitted"))
+	}
+	if cfg.ValidityDays < 0 || cfg.ValidityDays > MaxValidityDays {
+		errs = append(errs, fmt.Errorf("validity days must be 0 (profile default) or between 1 and %d (100 years), got %d", MaxValidityDays, cfg.ValidityDays))
+	}
+	if cfg.Validity < 0 {
+		errs = append(errs, fmt.Errorf("validity must not be negative"))
+	}
+	if cfg.MaxValidityDays < 0 {
+		errs = append(errs, fmt.Errorf("maximum validity must not be negative"))
+	} else if cfg.MaxValidityDays > 0 {
+		requested := fmt.Sprintf("%d days", cfg.LeafValidityDays())
+		validFor := time.Duration(cfg.LeafValidityDays()) * 24 * time.Hour
+		if cfg.Validity > 0 {
+			requested, validFor = cfg.Validity.String(), cfg.Validity
+		}
+		if validFor > time.Duration(cfg.MaxValidityDays)*24*time.Hour {
+			errs = append(errs, fmt.Errorf("validity of %s exceeds the maximum of %d days", requested, cfg.MaxValidityDays))
+		}
+	}
+	if cfg.KeySize < MinKeySize {
+		errs = append(errs, fmt.Errorf("key size must be at least %d bits, got %d", MinKeySize, cfg.KeySize))
+	}
+	if cfg.RootKeySize != 0 && cfg.RootKeySize < MinKeySize {
+		errs = append(errs, fmt.Errorf("root key size must be at least %d bits, got %d", MinKeySize, cfg.RootKeySize))
+	}
+	if cfg.LeafKeySize != 0 && cfg.LeafKeySize < MinKeySize {
+		errs = append(errs, fmt.Errorf("leaf key size must be at least %d bits, got %d", MinKeySize, cfg.LeafKeySize))
+	}
+	if profile, err := LookupProfile(cfg.Profile); err != nil {
+		errs = append(errs, err)
+	} else if cfg.ServerOnly && !slices.Contains(profile.ExtKeyUsage, "serverAuth") {
+		errs = append(errs, fmt.Errorf("server-only needs a profile with serverAuth, not %q", cfg.Profile))
+	}
+	if cfg.SignatureAlgorithm != "" && !slices.Contains(SignatureAlgorithms, cfg.SignatureAlgorithm) {
+		errs = append(errs, fmt.Errorf("unknown signature algorithm %q (want one of %s)", cfg.SignatureAlgorithm, strings.Join(SignatureAlgorithms, ", ")))
+	}
+	if !cfg.AllowAnyCountry {
+		if _, err := NormalizeCountry(cfg.Country); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package encoding
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// DecodePEMCertificates decodes every CERTIFICATE block in pemData, in file
+// order. Other block types are ignored.
+func DecodePEMCertificates(pemData []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for rest := pemData; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != PEMTypeCertificate {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d: %w", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// ContainsCertificate reports whether pemData holds a CERTIFICATE block
+// with the same SHA-256 fingerprint as cert. Blocks are compared as raw DER
+// without parsing, so a bundle with certificates Go cannot parse, as system
+// trust bundles sometimes have, is still searched.
+func ContainsCertificate(pemData []byte, cert *x509.Certificate) bool {
+	want := sha256.Sum256(cert.Raw)
+	for rest := pemData; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return false
+		}
+		if block.Type == PEMTypeCertificate && sha256.Sum256(block.Bytes) == want {
+			return true
+		}
+	}
+}
+
+// ValidateChainOrder checks that certs is ordered leaf first, with each
+// certificate issued by the one after it, as TLS servers expect. The last
+// certificate may be a root or an intermediate. The error names the first
+// pair that breaks the chain, counting from 1.
+func ValidateChainOrder(certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("empty certificate chain")
+	}
+	for i := 0; i < len(certs)-1; i++ {
+		child, parent := certs[i], certs[i+1]
+		if issuedBy(child, parent) {
+			continue
+		}
+		if issuedBy(parent, child) {
+			return fmt.Errorf("certificate %d (%s) is issued by certificate %d (%s), not the other way round; the chain looks reversed",
+				i+2, parent.Subject, i+1, child.Subject)
+		}
+		return fmt.Errorf("certificate %d (%s) is not issued by certificate %d (%s): issuer is %s",
+			i+1, child.Subject, i+2, parent.Subject, child.Issuer)
+	}
+	return nil
+}
+
+// issuedBy reports whether parent's name and key issued child.
+func issuedBy(child, parent *x509.Certificate) bool {
+	if !bytes.Equal(child.RawIssuer, parent.RawSubject) {
+		return false
+	}
+	return child.CheckSignatureFrom(parent) == nil
+}
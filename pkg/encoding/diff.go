@@ -0,0 +1,134 @@
+package encoding
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// DiffOptions selects the fields DiffCertificatesWithOptions compares in
+// addition to the defaults. Serial numbers and validity periods always
+// change on rotation, so they are skipped unless asked for.
+type DiffOptions struct {
+	IncludeSerial   bool
+	IncludeValidity bool
+}
+
+// DiffCertificates lists the fields that differ between a and b, ignoring
+// the serial number and validity period, e.g. to check that a rotated
+// certificate matches the one it replaces. Each entry has the form
+// "field: old -> new"; an empty result means the certificates are
+// equivalent.
+func DiffCertificates(a, b *x509.Certificate) []string {
+	return DiffCertificatesWithOptions(a, b, DiffOptions{})
+}
+
+// DiffCertificatesWithOptions is DiffCertificates with control over the
+// fields that are skipped by default.
+func DiffCertificatesWithOptions(a, b *x509.Certificate, opts DiffOptions) []string {
+	var diffs []string
+	add := func(field string, before, after interface{}) {
+		if !reflect.DeepEqual(before, after) {
+			diffs = append(diffs, fmt.Sprintf("%s: %v -> %v", field, before, after))
+		}
+	}
+
+	add("version", a.Version, b.Version)
+	if opts.IncludeSerial {
+		add("serial", a.SerialNumber, b.SerialNumber)
+	}
+	if opts.IncludeValidity {
+		add("not before", a.NotBefore.UTC().Format(time.RFC3339), b.NotBefore.UTC().Format(time.RFC3339))
+		add("not after", a.NotAfter.UTC().Format(time.RFC3339), b.NotAfter.UTC().Format(time.RFC3339))
+	}
+	add("subject", a.Subject.String(), b.Subject.String())
+	add("issuer", a.Issuer.String(), b.Issuer.String())
+	add("signature algorithm", a.SignatureAlgorithm, b.SignatureAlgorithm)
+	add("public key algorithm", a.PublicKeyAlgorithm, b.PublicKeyAlgorithm)
+	if !publicKeysEqual(a.PublicKey, b.PublicKey) {
+		diffs = append(diffs, "public key: differs")
+	}
+	add("DNS names", a.DNSNames, b.DNSNames)
+	add("IP addresses", a.IPAddresses, b.IPAddresses)
+	add("email addresses", a.EmailAddresses, b.EmailAddresses)
+	add("URIs", a.URIs, b.URIs)
+	add("CA", a.IsCA, b.IsCA)
+	add("max path length", pathLen(a), pathLen(b))
+	add("key usage", keyUsageNames(a.KeyUsage), keyUsageNames(b.KeyUsage))
+	add("extended key usage", extKeyUsageNames(a.ExtKeyUsage), extKeyUsageNames(b.ExtKeyUsage))
+	add("policies", a.PolicyIdentifiers, b.PolicyIdentifiers)
+	add("permitted DNS domains", a.PermittedDNSDomains, b.PermittedDNSDomains)
+	add("excluded DNS domains", a.ExcludedDNSDomains, b.ExcludedDNSDomains)
+	add("OCSP servers", a.OCSPServer, b.OCSPServer)
+	add("issuing certificate URLs", a.IssuingCertificateURL, b.IssuingCertificateURL)
+	add("CRL distribution points", a.CRLDistributionPoints, b.CRLDistributionPoints)
+	add("authority key ID", fmt.Sprintf("%X", a.AuthorityKeyId), fmt.Sprintf("%X", b.AuthorityKeyId))
+	return diffs
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	pub, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(b)
+}
+
+// pathLen describes the path length constraint of a CA certificate.
+func pathLen(cert *x509.Certificate) string {
+	switch {
+	case !cert.IsCA:
+		return "n/a"
+	case cert.MaxPathLen > 0 || cert.MaxPathLenZero:
+		return fmt.Sprint(cert.MaxPathLen)
+	default:
+		return "unlimited"
+	}
+}
+
+// keyUsageBits names the key usage bits in the spelling profiles use.
+var keyUsageBits = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digitalSignature"},
+	{x509.KeyUsageContentCommitment, "nonRepudiation"},
+	{x509.KeyUsageKeyEncipherment, "keyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "dataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "keyAgreement"},
+	{x509.KeyUsageCertSign, "keyCertSign"},
+	{x509.KeyUsageCRLSign, "cRLSign"},
+	{x509.KeyUsageEncipherOnly, "encipherOnly"},
+	{x509.KeyUsageDecipherOnly, "decipherOnly"},
+}
+
+func keyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for _, bit := range keyUsageBits {
+		if usage&bit.usage != 0 {
+			names = append(names, bit.name)
+		}
+	}
+	return names
+}
+
+var extKeyUsageLabels = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "any",
+	x509.ExtKeyUsageServerAuth:      "serverAuth",
+	x509.ExtKeyUsageClientAuth:      "clientAuth",
+	x509.ExtKeyUsageCodeSigning:     "codeSigning",
+	x509.ExtKeyUsageEmailProtection: "emailProtection",
+	x509.ExtKeyUsageTimeStamping:    "timeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+func extKeyUsageNames(usages []x509.ExtKeyUsage) []string {
+	var names []string
+	for _, usage := range usages {
+		name, ok := extKeyUsageLabels[usage]
+		if !ok {
+			name = fmt.Sprintf("extKeyUsage(%d)", usage)
+		}
+		names = append(names, name)
+	}
+	return names
+}
@@ -3,11 +3,22 @@
 package encoding
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
 )
 
 func EncodeCertificateToPEM(cert *x509.Certificate) ([]byte, error) {
@@ -15,13 +26,33 @@ func EncodeCertificateToPEM(cert *x509.Certificate) ([]byte, error) {
 		return nil, fmt.Errorf("certificate is nil")
 	}
 	pemBlock := &pem.Block{
-		Type:  "CERTIFICATE",
+		Type:  PEMTypeCertificate,
 		Bytes: cert.Raw,
 	}
 	return pem.EncodeToMemory(pemBlock), nil
 }
 
+func EncodeCSRToPEM(csr *x509.CertificateRequest) ([]byte, error) {
+	if csr == nil {
+		return nil, fmt.Errorf("certificate request is nil")
+	}
+	pemBlock := &pem.Block{
+		Type:  PEMTypeCertificateRequest,
+		Bytes: csr.Raw,
+	}
+	return pem.EncodeToMemory(pemBlock), nil
+}
+
 func EncodePrivateKeyToPEM(key *rsa.PrivateKey) ([]byte, error) {
+	if key == nil {
+		return nil, fmt.Errorf("private key is nil")
+	}
+	return EncodeSignerToPEM(key)
+}
+
+// EncodeSignerToPEM encodes a private key of any supported type (RSA, ECDSA
+// or Ed25519) as a PKCS#8 "PRIVATE KEY" block.
+func EncodeSignerToPEM(key crypto.Signer) ([]byte, error) {
 	if key == nil {
 		return nil, fmt.Errorf("private key is nil")
 	}
@@ -30,13 +61,75 @@ func EncodePrivateKeyToPEM(key *rsa.PrivateKey) ([]byte, error) {
 		return nil, fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
+	defer Zero(keyBytes)
+
 	pemBlock := &pem.Block{
-		Type:  "PRIVATE KEY",
+		Type:  PEMTypePrivateKey,
 		Bytes: keyBytes,
 	}
 	return pem.EncodeToMemory(pemBlock), nil
 }
 
+// PEM block types. Each names the encoding of the block's contents, so
+// decoders can pick the right parser without guessing.
+const (
+	PEMTypeCertificate         = "CERTIFICATE"
+	PEMTypeCertificateRequest  = "CERTIFICATE REQUEST"
+	PEMTypePrivateKey          = "PRIVATE KEY"
+	PEMTypeEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+	PEMTypeRSAPrivateKey       = "RSA PRIVATE KEY"
+	PEMTypeECPrivateKey        = "EC PRIVATE KEY"
+	PEMTypePublicKey           = "PUBLIC KEY"
+)
+
+// Private key output formats.
+const (
+	KeyFormatPKCS8 = "pkcs8"
+	KeyFormatPKCS1 = "pkcs1"
+)
+
+// EncodePrivateKeyToPEMPKCS1 encodes key as a PKCS#1 "RSA PRIVATE KEY" block
+// for legacy tools that do not understand PKCS#8.
+func EncodePrivateKeyToPEMPKCS1(key *rsa.PrivateKey) []byte {
+	if key == nil {
+		return nil
+	}
+	keyBytes := x509.MarshalPKCS1PrivateKey(key)
+	defer Zero(keyBytes)
+
+	pemBlock := &pem.Block{
+		Type:  PEMTypeRSAPrivateKey,
+		Bytes: keyBytes,
+	}
+	return pem.EncodeToMemory(pemBlock)
+}
+
+// EncodePrivateKey encodes key to PEM in the given format (KeyFormatPKCS8 or
+// KeyFormatPKCS1).
+func EncodePrivateKey(key *rsa.PrivateKey, format string) ([]byte, error) {
+	switch format {
+	case KeyFormatPKCS8, "":
+		return EncodePrivateKeyToPEM(key)
+	case KeyFormatPKCS1:
+		if key == nil {
+			return nil, fmt.Errorf("private key is nil")
+		}
+		return EncodePrivateKeyToPEMPKCS1(key), nil
+	default:
+		return nil, fmt.Errorf("unknown key format %q (want %s or %s)", format, KeyFormatPKCS8, KeyFormatPKCS1)
+	}
+}
+
+// Zero overwrites b with zeros. It is a best-effort measure for serialized key
+// material: the Go runtime may have copied the bytes elsewhere (e.g. during
+// slice growth or GC), and the big.Int values inside an *rsa.PrivateKey are
+// not touched, so this only shortens the lifetime of the buffer it is given.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
 func ConvertPEMToDER(pemData []byte) ([]byte, error) {
 	block, _ := pem.Decode(pemData)
 	if block == nil {
@@ -49,6 +142,137 @@ func EncodeDERToBase64(derData []byte) string {
 	return base64.StdEncoding.EncodeToString(derData)
 }
 
+// EncodePublicKeyToPEM encodes pub as a PKIX SubjectPublicKeyInfo "PUBLIC
+// KEY" block, as used by JWT verifiers and for key pinning. RSA, ECDSA and
+// Ed25519 keys are supported.
+func EncodePublicKeyToPEM(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMTypePublicKey, Bytes: der}), nil
+}
+
+// EncodePublicKeyToSSH encodes pub in the OpenSSH authorized_keys format,
+// e.g. "ssh-rsa AAAA...\n", for services that reuse a certificate's key
+// for SSH.
+func EncodePublicKeyToSSH(pub crypto.PublicKey) ([]byte, error) {
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert public key to SSH format: %w", err)
+	}
+	return ssh.MarshalAuthorizedKey(sshPub), nil
+}
+
+// EncodeDERToBase64Writer writes derData to w as standard base64 without
+// building the whole encoded string in memory, for large inputs such as
+// chains. The output matches EncodeDERToBase64.
+func EncodeDERToBase64Writer(w io.Writer, derData []byte) error {
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := enc.Write(derData); err != nil {
+		return fmt.Errorf("failed to write base64: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write base64: %w", err)
+	}
+	return nil
+}
+
+// FingerprintSHA256 returns the SHA-256 digest of the certificate's DER
+// encoding as colon-separated uppercase hex, the form printed by
+// "openssl x509 -fingerprint -sha256" and used for pinning.
+func FingerprintSHA256(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// Base64LineLength is the column at which wrapped base64 output breaks
+// lines, matching the body of a PEM block.
+const Base64LineLength = 64
+
+// WrapBase64 breaks encoded into lines of Base64LineLength characters, each
+// ending in a newline, for tools that reject very long lines.
+func WrapBase64(encoded string) string {
+	var b strings.Builder
+	for len(encoded) > Base64LineLength {
+		b.WriteString(encoded[:Base64LineLength])
+		b.WriteByte('\n')
+		encoded = encoded[Base64LineLength:]
+	}
+	if encoded != "" {
+		b.WriteString(encoded)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// IsPEM reports whether data starts with a PEM block, ignoring leading
+// blank lines.
+func IsPEM(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, "\r\n"), []byte("-----BEGIN "))
+}
+
+// PEMToCRLF returns a copy of data with every LF line ending turned into
+// CRLF, for Windows tools that reject PEM files with bare LFs. Existing
+// CRLF endings are kept as they are. pem.Decode accepts either form.
+func PEMToCRLF(data []byte) []byte {
+	out := make([]byte, 0, len(data)+bytes.Count(data, []byte{'\n'}))
+	for i, b := range data {
+		if b == '\n' && (i == 0 || data[i-1] != '\r') {
+			out = append(out, '\r')
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// EncodeDERToBase64WrappedWriter is EncodeDERToBase64Writer with the output
+// wrapped as WrapBase64 does.
+func EncodeDERToBase64WrappedWriter(w io.Writer, derData []byte) error {
+	lw := &lineWrapper{w: w}
+	if err := EncodeDERToBase64Writer(lw, derData); err != nil {
+		return err
+	}
+	if lw.col > 0 {
+		if _, err := w.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("failed to write base64: %w", err)
+		}
+	}
+	return nil
+}
+
+// lineWrapper inserts a newline after every Base64LineLength bytes written
+// through it.
+type lineWrapper struct {
+	w   io.Writer
+	col int
+}
+
+func (l *lineWrapper) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		chunk := min(Base64LineLength-l.col, len(p))
+		written, err := l.w.Write(p[:chunk])
+		n += written
+		if err != nil {
+			return n, err
+		}
+		l.col += chunk
+		p = p[chunk:]
+		if l.col == Base64LineLength {
+			if _, err := l.w.Write([]byte{'\n'}); err != nil {
+				return n, err
+			}
+			l.col = 0
+		}
+	}
+	return n, nil
+}
+
 func ConvertCertificateToBase64DER(cert *x509.Certificate) (string, error) {
 	return EncodeDERToBase64(cert.Raw), nil
 }
@@ -67,21 +291,155 @@ func DecodePEMCertificate(pemData []byte) (*x509.Certificate, error) {
 	return cert, nil
 }
 
-func DecodePEMPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+func DecodePEMCertificateRequest(pemData []byte) (*x509.CertificateRequest, error) {
 	block, _ := pem.Decode(pemData)
 	if block == nil {
 		return nil, fmt.Errorf("failed to parse PEM block")
 	}
 
-	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request: %w", err)
+	}
+
+	return csr, nil
+}
+
+// DecodePEMSigner decodes a private key of any supported type (RSA, ECDSA or
+// Ed25519), choosing the parser from the PEM block type. Unsupported keys,
+// such as DSA, are rejected with an error naming the detected type.
+func DecodePEMSigner(pemData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse PEM block")
+	}
+	if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		return nil, fmt.Errorf("encrypted private keys are not supported")
+	}
+
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case PEMTypePrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			if name := pkcs8AlgorithmName(block.Bytes); name != "" {
+				return nil, fmt.Errorf("unsupported private key type %s", name)
+			}
+		}
+	case PEMTypeRSAPrivateKey:
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case PEMTypeECPrivateKey:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case PEMTypeEncryptedPrivateKey:
+		return nil, fmt.Errorf("encrypted private keys are not supported")
+	case "DSA PRIVATE KEY":
+		return nil, fmt.Errorf("unsupported private key type DSA")
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q for a private key", block.Type)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
 
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func DecodePEMPrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	key, err := DecodePEMSigner(pemData)
+	if err != nil {
+		return nil, err
+	}
+
 	rsaKey, ok := key.(*rsa.PrivateKey)
 	if !ok {
-		return nil, fmt.Errorf("key is not an RSA private key")
+		return nil, fmt.Errorf("key is an %s private key, not RSA", KeyTypeName(key))
 	}
 
 	return rsaKey, nil
 }
+
+// DecodePEMPublicKey decodes a PKIX public key. A private key of any type
+// DecodePEMSigner accepts is also accepted, and its public half returned.
+func DecodePEMPublicKey(pemData []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse PEM block")
+	}
+	if block.Type != PEMTypePublicKey {
+		signer, err := DecodePEMSigner(pemData)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return pub, nil
+}
+
+// KeyTypeName returns a short human-readable name for a private or public
+// key, e.g. "RSA 2048", "ECDSA P-256" or "Ed25519".
+func KeyTypeName(key interface{}) string {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *ecdsa.PrivateKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+var (
+	oidPublicKeyRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyDSA     = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+	oidPublicKeyECDSA   = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPublicKeyEd25519 = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+// pkcs8 is the outer PKCS#8 structure, parsed only to name the algorithm of
+// keys the standard library refuses.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// pkcs8AlgorithmName names the algorithm of a PKCS#8 key whose type is not
+// supported. It returns "" for supported types, whose parse errors are more
+// useful as they are, and for data that is not PKCS#8 at all.
+func pkcs8AlgorithmName(der []byte) string {
+	var info pkcs8
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return ""
+	}
+	switch oid := info.Algo.Algorithm; {
+	case oid.Equal(oidPublicKeyDSA):
+		return "DSA"
+	case oid.Equal(oidPublicKeyRSA), oid.Equal(oidPublicKeyECDSA), oid.Equal(oidPublicKeyEd25519):
+		return ""
+	default:
+		return oid.String()
+	}
+}
@@ -0,0 +1,20 @@
+package encoding
+
+import "bytes"
+
+// EncodeHAProxyPEM concatenates the PEM key, leaf certificate and, when
+// non-nil, CA certificate into the single file HAProxy's "crt" directive
+// loads. Each block is newline-terminated so the blocks never run together.
+func EncodeHAProxyPEM(keyPEM, certPEM, caPEM []byte) []byte {
+	var buf bytes.Buffer
+	for _, block := range [][]byte{keyPEM, certPEM, caPEM} {
+		if len(block) == 0 {
+			continue
+		}
+		buf.Write(block)
+		if !bytes.HasSuffix(block, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
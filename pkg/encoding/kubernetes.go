@@ -0,0 +1,59 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"text/template"
+)
+
+// dns1123Subdomain matches the names Kubernetes accepts for Secrets.
+var dns1123Subdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+var tlsSecretTemplate = template.Must(template.New("secret").Parse(`apiVersion: v1
+kind: Secret
+metadata:
+  name: {{.Name}}
+type: kubernetes.io/tls
+data:
+  tls.crt: {{.Cert}}
+  tls.key: {{.Key}}
+{{- if .CA}}
+  ca.crt: {{.CA}}
+{{- end}}
+`))
+
+// ValidateSecretName reports whether name is usable as a Kubernetes Secret
+// name, i.e. a lowercase RFC 1123 subdomain.
+func ValidateSecretName(name string) error {
+	if len(name) > 253 || !dns1123Subdomain.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: must be a lowercase RFC 1123 subdomain", name)
+	}
+	return nil
+}
+
+// EncodeKubernetesTLSSecret renders a kubernetes.io/tls Secret manifest
+// holding the PEM certificate and key. caPEM is added as ca.crt when non-nil.
+func EncodeKubernetesTLSSecret(name string, certPEM, keyPEM, caPEM []byte) ([]byte, error) {
+	if err := ValidateSecretName(name); err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Name, Cert, Key, CA string
+	}{
+		Name: name,
+		Cert: base64.StdEncoding.EncodeToString(certPEM),
+		Key:  base64.StdEncoding.EncodeToString(keyPEM),
+	}
+	if caPEM != nil {
+		data.CA = base64.StdEncoding.EncodeToString(caPEM)
+	}
+
+	var buf bytes.Buffer
+	if err := tlsSecretTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render secret: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,109 @@
+package encoding
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// MobileConfigIdentifierPrefix starts the PayloadIdentifier of profiles
+// built by EncodeMobileConfig.
+const MobileConfigIdentifierPrefix = "com.github.erfianugrah.certgen"
+
+var mobileConfigTemplate = template.Must(template.New("mobileconfig").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>PayloadContent</key>
+	<array>
+		<dict>
+			<key>PayloadCertificateFileName</key>
+			<string>{{xml .FileName}}</string>
+			<key>PayloadContent</key>
+			<data>
+{{.Data}}			</data>
+			<key>PayloadDisplayName</key>
+			<string>{{xml .Name}}</string>
+			<key>PayloadIdentifier</key>
+			<string>{{.Identifier}}.root</string>
+			<key>PayloadType</key>
+			<string>com.apple.security.root</string>
+			<key>PayloadUUID</key>
+			<string>{{.PayloadUUID}}</string>
+			<key>PayloadVersion</key>
+			<integer>1</integer>
+		</dict>
+	</array>
+	<key>PayloadDescription</key>
+	<string>Installs {{xml .Name}} as a trusted root certificate.</string>
+	<key>PayloadDisplayName</key>
+	<string>{{xml .Name}}</string>
+	<key>PayloadIdentifier</key>
+	<string>{{.Identifier}}</string>
+	<key>PayloadRemovalDisallowed</key>
+	<false/>
+	<key>PayloadType</key>
+	<string>Configuration</string>
+	<key>PayloadUUID</key>
+	<string>{{.ProfileUUID}}</string>
+	<key>PayloadVersion</key>
+	<integer>1</integer>
+</dict>
+</plist>
+`))
+
+func xmlEscape(s string) (string, error) {
+	var b strings.Builder
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// EncodeMobileConfig renders an unsigned Apple configuration profile
+// (.mobileconfig) that installs cert as a root certificate on iOS, iPadOS
+// and macOS. fileName is the name shown for the embedded certificate. The
+// identifier and UUIDs are derived from the certificate's SHA-256 digest,
+// so the same root always yields the same profile and reinstalling it
+// replaces the earlier copy.
+func EncodeMobileConfig(cert *x509.Certificate, fileName string) ([]byte, error) {
+	sum := sha256.Sum256(cert.Raw)
+	name := cert.Subject.CommonName
+	if name == "" {
+		name = "certgen root CA"
+	}
+
+	data := struct {
+		Name, FileName, Data, Identifier, PayloadUUID, ProfileUUID string
+	}{
+		Name:        name,
+		FileName:    fileName,
+		Data:        WrapBase64(EncodeDERToBase64(cert.Raw)),
+		Identifier:  MobileConfigIdentifierPrefix + "." + hex.EncodeToString(sum[:8]),
+		PayloadUUID: digestUUID(sum[:16]),
+		ProfileUUID: digestUUID(sum[16:]),
+	}
+
+	var buf bytes.Buffer
+	if err := mobileConfigTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render mobileconfig: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// digestUUID formats 16 digest bytes as an RFC 4122 name-based UUID.
+func digestUUID(b []byte) string {
+	u := make([]byte, 16)
+	copy(u, b)
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	h := strings.ToUpper(hex.EncodeToString(u))
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
@@ -0,0 +1,59 @@
+package fileio
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// CreatedFiles records the paths a run has created, so that an interrupted
+// run can remove its partial output. It is safe for concurrent use.
+type CreatedFiles struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func NewCreatedFiles() *CreatedFiles {
+	return &CreatedFiles{}
+}
+
+// Add records path as created by this run. Directories are removed with
+// their contents.
+func (c *CreatedFiles) Add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, path)
+}
+
+// Paths returns the recorded paths in the order they were added.
+func (c *CreatedFiles) Paths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.paths...)
+}
+
+// RemoveAll removes every recorded path, newest first, and forgets them. It
+// returns the paths that were removed; paths already gone are skipped.
+func (c *CreatedFiles) RemoveAll() ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		removed []string
+		errs    []error
+	)
+	for i := len(c.paths) - 1; i >= 0; i-- {
+		path := c.paths[i]
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove %s: %w", path, err))
+			continue
+		}
+		removed = append(removed, path)
+	}
+	c.paths = nil
+	return removed, errors.Join(errs...)
+}
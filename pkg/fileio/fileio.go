@@ -3,79 +3,523 @@
 package fileio
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/template"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
 )
 
+// DefaultNameTemplate reproduces the built-in file names, e.g.
+// "example_rootCA.key" for the domain example.com.
+const DefaultNameTemplate = "{{.Subdomain}}_{{.Kind}}.{{.Ext}}"
+
+// FullNameTemplate prefixes files with the full domain instead, e.g.
+// "api.example.com_leaf.pem", for outputs whose first labels may collide.
+const FullNameTemplate = "{{.Domain}}_{{.Kind}}.{{.Ext}}"
+
+// NameData is the data available to a file name template. Kind is the
+// output's role (rootCA, leaf, leaf_ecdsa, certs, secret, haproxy,
+// truststore, trust, rootCA_base64 or leaf_base64) and Ext its extension without the leading dot.
+type NameData struct {
+	Domain    string
+	Subdomain string
+	Kind      string
+	Ext       string
+}
+
 type FileWriter struct {
+	domain    string
 	subdomain string
+	template  *template.Template
+	checksums bool
+	created   *CreatedFiles
+
+	// base64Wrap wraps base64 output at encoding.Base64LineLength columns.
+	base64Wrap bool
+
+	// crlf writes PEM files with CRLF line endings.
+	crlf bool
+
+	// overrides maps "kind.ext" to an explicit path that bypasses the
+	// name template.
+	overrides map[string]string
 }
 
 func NewFileWriter(domain string) *FileWriter {
 	subdomain := strings.Split(domain, ".")[0]
 	return &FileWriter{
+		domain:    domain,
 		subdomain: subdomain,
 	}
 }
 
+// output is a file the writer names, identified by its kind and extension.
+type output struct {
+	kind, ext string
+	// private outputs hold a private key and are written with mode 0600
+	// whatever name the template gives them.
+	private bool
+}
+
+// outputs lists every file named by the Get*Path methods.
+var outputs = []output{
+	{"rootCA", "key", true},
+	{"rootCA", "pem", false},
+	{"rootCA", "der", false},
+	{"rootCA", "crt", false},
+	{"rootCA", "mobileconfig", false},
+	{"rootCA_base64", "txt", false},
+	{"leaf", "key", true},
+	{"leaf", "pem", false},
+	{"leaf", "der", false},
+	{"leaf", "csr", false},
+	{"leaf", "csr.der", false},
+	{"leaf", "ocsp", false},
+	{"leaf", "sha256.txt", false},
+	{"leaf", "pub", false},
+	{"leaf", "pub.pem", false},
+	{"leaf_base64", "txt", false},
+	{"leaf_ecdsa", "key", true},
+	{"leaf_ecdsa", "pem", false},
+	{"secret", "yaml", true},
+	{"haproxy", "pem", true},
+	{"certs", "p12", false},
+	{"truststore", "p12", false},
+	{"trust", "p12", false},
+	{"bundle", "json", true},
+}
+
+// ParseNameTemplate parses a file name template and renders every output
+// name for sample data, so that unknown fields, and templates that would
+// give two outputs the same name, are reported up front rather than on first
+// use. A template must use both {{.Kind}} and {{.Ext}}.
+func ParseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name template: %w", err)
+	}
+
+	render := func(kind, ext string) (string, error) {
+		var buf bytes.Buffer
+		sample := NameData{Domain: "example.com", Subdomain: "example", Kind: kind, Ext: ext}
+		if err := tmpl.Execute(&buf, sample); err != nil {
+			return "", fmt.Errorf("invalid name template: %w", err)
+		}
+		return buf.String(), nil
+	}
+
+	seen := make(map[string]string, len(outputs))
+	for _, o := range outputs {
+		name, err := render(o.kind, o.ext)
+		if err != nil {
+			return nil, err
+		}
+		key := o.kind + "." + o.ext
+		if prev, ok := seen[name]; ok {
+			if !strings.Contains(text, ".Kind") {
+				return nil, fmt.Errorf("invalid name template: it must use {{.Kind}}")
+			}
+			if !strings.Contains(text, ".Ext") {
+				return nil, fmt.Errorf("invalid name template: it must use {{.Ext}}")
+			}
+			return nil, fmt.Errorf("invalid name template: %s and %s would both be written to %q", prev, key, name)
+		}
+		seen[name] = key
+	}
+	return tmpl, nil
+}
+
+// SetNameTemplate replaces the default file naming with tmpl, as returned by
+// ParseNameTemplate. A nil template restores the default.
+func (fw *FileWriter) SetNameTemplate(tmpl *template.Template) {
+	fw.template = tmpl
+}
+
+// path renders the file name for an output. Templates are validated by
+// ParseNameTemplate, so an execution error falls back to the default naming.
+func (fw *FileWriter) path(kind, ext string) string {
+	if p, ok := fw.overrides[kind+"."+ext]; ok {
+		return p
+	}
+	if fw.template != nil {
+		var buf bytes.Buffer
+		data := NameData{Domain: fw.domain, Subdomain: fw.subdomain, Kind: kind, Ext: ext}
+		if err := fw.template.Execute(&buf, data); err == nil {
+			return buf.String()
+		}
+	}
+	return fmt.Sprintf("%s_%s.%s", fw.subdomain, kind, ext)
+}
+
+// SetLeafCertPath makes GetLeafCertPath return path instead of the templated
+// name, for tools that expect fixed names such as tls.crt. An empty path
+// restores the default.
+func (fw *FileWriter) SetLeafCertPath(path string) {
+	fw.setOverride("leaf.pem", path)
+}
+
+// SetLeafKeyPath makes GetLeafKeyPath return path instead of the templated
+// name. An empty path restores the default.
+func (fw *FileWriter) SetLeafKeyPath(path string) {
+	fw.setOverride("leaf.key", path)
+}
+
+// SetJSONBundlePath makes GetJSONBundlePath return path instead of the
+// templated name. An empty path restores the default.
+func (fw *FileWriter) SetJSONBundlePath(path string) {
+	fw.setOverride("bundle.json", path)
+}
+
+func (fw *FileWriter) setOverride(key, path string) {
+	if path == "" {
+		delete(fw.overrides, key)
+		return
+	}
+	if fw.overrides == nil {
+		fw.overrides = make(map[string]string)
+	}
+	fw.overrides[key] = path
+}
+
 func (fw *FileWriter) GetRootKeyPath() string {
-	return fmt.Sprintf("%s_rootCA.key", fw.subdomain)
+	return fw.path("rootCA", "key")
 }
 
 func (fw *FileWriter) GetRootCertPath() string {
-	return fmt.Sprintf("%s_rootCA.pem", fw.subdomain)
+	return fw.path("rootCA", "pem")
 }
 
 func (fw *FileWriter) GetLeafKeyPath() string {
-	return fmt.Sprintf("%s_leaf.key", fw.subdomain)
+	return fw.path("leaf", "key")
 }
 
 func (fw *FileWriter) GetLeafCertPath() string {
-	return fmt.Sprintf("%s_leaf.pem", fw.subdomain)
+	return fw.path("leaf", "pem")
+}
+
+func (fw *FileWriter) GetLeafECDSAKeyPath() string {
+	return fw.path("leaf_ecdsa", "key")
+}
+
+func (fw *FileWriter) GetLeafECDSACertPath() string {
+	return fw.path("leaf_ecdsa", "pem")
+}
+
+func (fw *FileWriter) GetRootDERPath() string {
+	return fw.path("rootCA", "der")
+}
+
+// GetRootCRTPath is the root certificate in DER form with the .crt
+// extension Android and iOS expect when installing a CA.
+func (fw *FileWriter) GetRootCRTPath() string {
+	return fw.path("rootCA", "crt")
+}
+
+func (fw *FileWriter) GetRootMobileConfigPath() string {
+	return fw.path("rootCA", "mobileconfig")
+}
+
+func (fw *FileWriter) GetLeafDERPath() string {
+	return fw.path("leaf", "der")
 }
 
 func (fw *FileWriter) GetLeafCSRPath() string {
-	return fmt.Sprintf("%s_leaf.csr", fw.subdomain)
+	return fw.path("leaf", "csr")
+}
+
+func (fw *FileWriter) GetLeafCSRDERPath() string {
+	return fw.path("leaf", "csr.der")
+}
+
+func (fw *FileWriter) GetLeafOCSPPath() string {
+	return fw.path("leaf", "ocsp")
+}
+
+func (fw *FileWriter) GetLeafFingerprintPath() string {
+	return fw.path("leaf", "sha256.txt")
+}
+
+func (fw *FileWriter) GetLeafSSHPublicKeyPath() string {
+	return fw.path("leaf", "pub")
+}
+
+func (fw *FileWriter) GetLeafPublicKeyPEMPath() string {
+	return fw.path("leaf", "pub.pem")
+}
+
+func (fw *FileWriter) GetK8sSecretPath() string {
+	return fw.path("secret", "yaml")
+}
+
+func (fw *FileWriter) GetHAProxyPEMPath() string {
+	return fw.path("haproxy", "pem")
 }
 
 func (fw *FileWriter) GetPKCS12Path() string {
-	return fmt.Sprintf("%s_certs.p12", fw.subdomain)
+	return fw.path("certs", "p12")
+}
+
+func (fw *FileWriter) GetTruststorePath() string {
+	return fw.path("truststore", "p12")
+}
+
+// GetPKCS12TrustPath is the key-less PKCS#12 bundle of the leaf and root
+// certificates written with --p12-trust.
+func (fw *FileWriter) GetPKCS12TrustPath() string {
+	return fw.path("trust", "p12")
+}
+
+// GetJSONBundlePath is the JSON document holding both certificates and keys
+// written with --json-bundle.
+func (fw *FileWriter) GetJSONBundlePath() string {
+	return fw.path("bundle", "json")
 }
 
 func (fw *FileWriter) GetRootBase64Path() string {
-	return fmt.Sprintf("%s_rootCA_base64.txt", fw.subdomain)
+	return fw.path("rootCA_base64", "txt")
 }
 
 func (fw *FileWriter) GetLeafBase64Path() string {
-	return fmt.Sprintf("%s_leaf_base64.txt", fw.subdomain)
+	return fw.path("leaf_base64", "txt")
+}
+
+// SetChecksums makes WriteFile also write a sha256sum-compatible
+// "<path>.sha256" sidecar next to every file.
+func (fw *FileWriter) SetChecksums(enabled bool) {
+	fw.checksums = enabled
+}
+
+// SetBase64Wrap makes WriteBase64File and WriteBase64Stream break their
+// output into 64-column lines instead of writing a single line.
+func (fw *FileWriter) SetBase64Wrap(enabled bool) {
+	fw.base64Wrap = enabled
+}
+
+// SetCRLF makes WriteFile and AppendFile write PEM data with CRLF line
+// endings instead of LF. Other files are written unchanged.
+func (fw *FileWriter) SetCRLF(enabled bool) {
+	fw.crlf = enabled
+}
+
+// SetCreatedFiles makes the writer record every file it creates in created.
+// Files that already existed are not recorded, as removing them would lose
+// more than the interrupted run wrote.
+func (fw *FileWriter) SetCreatedFiles(created *CreatedFiles) {
+	fw.created = created
+}
+
+// track records path with the CreatedFiles, if any, unless it already exists.
+func (fw *FileWriter) track(path string) {
+	if fw.created != nil && !fw.FileExists(path) {
+		fw.created.Add(path)
+	}
 }
 
 func (fw *FileWriter) WriteFile(path string, data []byte) error {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return err
 	}
+	fw.track(path)
 
-	// Use restrictive permissions for key files
-	perm := os.FileMode(0644)
-	if strings.Contains(path, ".key") {
-		perm = 0600
+	if fw.crlf && encoding.IsPEM(data) {
+		// The copy may hold a private key, like the caller's buffer
+		data = encoding.PEMToCRLF(data)
+		defer encoding.Zero(data)
 	}
 
-	if err := os.WriteFile(path, data, perm); err != nil {
+	if err := writeFileMode(path, data, fw.filePerm(path)); err != nil {
 		return fmt.Errorf("failed to write file %s: %w", path, err)
 	}
 
+	if fw.checksums {
+		return fw.writeChecksum(path, data)
+	}
+
+	return nil
+}
+
+// writeFileMode is os.WriteFile, except that an existing file is also given
+// perm, before anything is written to it, so overwriting a 0644 file with a
+// private key does not leave the key readable.
+func writeFileMode(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// AppendFile appends data to path, creating it if needed. It is meant for
+// accumulating PEM blocks, so data is written on a fresh line and in a single
+// write call, which O_APPEND keeps intact against concurrent appenders. The
+// checksum sidecar, if enabled, covers the whole resulting file.
+func (fw *FileWriter) AppendFile(path string, data []byte) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && len(existing) > 0 && existing[len(existing)-1] != '\n' {
+		data = append([]byte{'\n'}, data...)
+	}
+	fw.track(path)
+
+	if fw.crlf && encoding.IsPEM(data) {
+		data = encoding.PEMToCRLF(data)
+		defer encoding.Zero(data)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fw.filePerm(path))
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to append to file %s: %w", path, err)
+	}
+
+	if fw.checksums {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", path, err)
+		}
+		return fw.writeChecksum(path, contents)
+	}
+
+	return nil
+}
+
+// AppendCertificate appends cert as PEM to the bundle at path with
+// AppendFile, unless the bundle already holds a certificate with the same
+// fingerprint, so repeated runs don't add duplicates. It reports whether the
+// certificate was appended.
+func (fw *FileWriter) AppendCertificate(path string, cert *x509.Certificate) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to read file %s: %w", path, err)
+	}
+	if encoding.ContainsCertificate(existing, cert) {
+		return false, nil
+	}
+
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		return false, err
+	}
+	if err := fw.AppendFile(path, certPEM); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// mkdirAttempts bounds the retries in ensureDir.
+const mkdirAttempts = 3
+
+// ensureDir creates dir and its parents. Concurrent writers may race to
+// create the same new directory, so a failure is retried, and ignored once
+// the directory turns out to exist.
+func ensureDir(dir string) error {
+	var err error
+	for i := 0; i < mkdirAttempts; i++ {
+		if err = os.MkdirAll(dir, 0755); err == nil {
+			return nil
+		}
+		if info, statErr := os.Stat(dir); statErr == nil && info.IsDir() {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to create directory %s: %w", dir, err)
+}
+
+// filePerm uses restrictive permissions for files holding private keys: the
+// private outputs, under whatever name the template or an override such as
+// SetLeafKeyPath gives them, and any other path with a .key extension, such
+// as a key in a CA directory.
+func (fw *FileWriter) filePerm(path string) os.FileMode {
+	if filepath.Ext(path) == ".key" {
+		return 0600
+	}
+	for _, o := range outputs {
+		if o.private && path == fw.path(o.kind, o.ext) {
+			return 0600
+		}
+	}
+	return 0644
+}
+
+// writeChecksum records the base name rather than the full path so that
+// "sha256sum -c" works from the directory holding the file.
+func (fw *FileWriter) writeChecksum(path string, data []byte) error {
+	sum := sha256.Sum256(data)
+	return fw.writeChecksumSum(path, sum[:])
+}
+
+func (fw *FileWriter) writeChecksumSum(path string, sum []byte) error {
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum), filepath.Base(path))
+	sumPath := path + ".sha256"
+	fw.track(sumPath)
+	if err := os.WriteFile(sumPath, []byte(line), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum file %s: %w", sumPath, err)
+	}
 	return nil
 }
 
 func (fw *FileWriter) WriteBase64File(path string, base64Data string) error {
-	if err := fw.WriteFile(path, []byte(base64Data)); err != nil {
+	if fw.base64Wrap {
+		base64Data = encoding.WrapBase64(base64Data)
+	}
+	return fw.WriteFile(path, []byte(base64Data))
+}
+
+// WriteBase64Stream writes der to path as base64, encoding it directly into
+// the file rather than through an intermediate string.
+func (fw *FileWriter) WriteBase64Stream(path string, der []byte) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
 		return err
 	}
-	fmt.Printf("Base64-encoded DER content written to %s:\n%s\n\n", path, base64Data)
+	fw.track(path)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fw.filePerm(path))
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	hash := sha256.New()
+	encode := encoding.EncodeDERToBase64Writer
+	if fw.base64Wrap {
+		encode = encoding.EncodeDERToBase64WrappedWriter
+	}
+	if err := encode(io.MultiWriter(f, hash), der); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write file %s: %w", path, err)
+	}
+
+	if fw.checksums {
+		return fw.writeChecksumSum(path, hash.Sum(nil))
+	}
+
 	return nil
 }
 
@@ -0,0 +1,69 @@
+// Package ghoutput writes step outputs for GitHub Actions, so later steps in
+// a workflow can read generated paths as ${{ steps.<id>.outputs.<name> }}.
+package ghoutput
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvVar names the environment variable GitHub Actions sets to the file
+// that step outputs are appended to.
+const EnvVar = "GITHUB_OUTPUT"
+
+// Output is a single step output.
+type Output struct {
+	Name  string
+	Value string
+}
+
+// Path returns the output file named by $GITHUB_OUTPUT, or "" outside of
+// GitHub Actions.
+func Path() string {
+	return os.Getenv(EnvVar)
+}
+
+// Append appends outputs to the file at path as name=value lines, in order.
+// Values containing newlines are written in the name<<delimiter form with a
+// random delimiter, as GitHub documents for multiline values.
+func Append(path string, outputs []Output) error {
+	var b strings.Builder
+	for _, out := range outputs {
+		if out.Name == "" || strings.ContainsAny(out.Name, "=<\r\n") {
+			return fmt.Errorf("invalid output name %q", out.Name)
+		}
+		if !strings.ContainsAny(out.Value, "\r\n") {
+			fmt.Fprintf(&b, "%s=%s\n", out.Name, out.Value)
+			continue
+		}
+		delim, err := delimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", out.Name, delim, out.Value, delim)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GitHub output file %s: %w", path, err)
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write GitHub output file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write GitHub output file %s: %w", path, err)
+	}
+	return nil
+}
+
+func delimiter() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate output delimiter: %w", err)
+	}
+	return "ghadelimiter_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,185 @@
+// Package hook runs user-supplied commands after certificates are generated,
+// e.g. to reload a server or push the files to a secret store.
+package hook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTimeout bounds how long a hook may run.
+const DefaultTimeout = 30 * time.Second
+
+// Data is available to a hook command template. Paths of outputs that were
+// not written are empty.
+type Data struct {
+	Domain       string
+	RootCertPath string
+	RootKeyPath  string
+	LeafCertPath string
+	LeafKeyPath  string
+	PKCS12Path   string
+}
+
+// Hook is a parsed post-generation command.
+type Hook struct {
+	args    []*template.Template
+	shell   *template.Template
+	timeout time.Duration
+}
+
+// Parse parses a command template such as "cp {{.LeafCertPath}} /etc/tls".
+// By default the command is split into arguments before templating and run
+// directly, so paths cannot inject shell syntax; single and double quotes
+// group words. With shell set, the whole rendered command is passed to
+// "sh -c" instead, allowing pipes and redirects.
+func Parse(command string, shell bool) (*Hook, error) {
+	h := &Hook{timeout: DefaultTimeout}
+	if shell {
+		tmpl, err := parseTemplate(command)
+		if err != nil {
+			return nil, err
+		}
+		h.shell = tmpl
+		return h, nil
+	}
+
+	words, err := splitWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty hook command")
+	}
+	for _, word := range words {
+		tmpl, err := parseTemplate(word)
+		if err != nil {
+			return nil, err
+		}
+		h.args = append(h.args, tmpl)
+	}
+	return h, nil
+}
+
+// SetTimeout changes how long Run waits before killing the command. A
+// non-positive timeout restores DefaultTimeout.
+func (h *Hook) SetTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	h.timeout = timeout
+}
+
+// Run renders the command with data and runs it, returning its combined
+// stdout and stderr. A non-zero exit or timeout is reported as an error.
+func (h *Hook) Run(ctx context.Context, data Data) ([]byte, error) {
+	var args []string
+	if h.shell != nil {
+		command, err := render(h.shell, data)
+		if err != nil {
+			return nil, err
+		}
+		args = []string{"sh", "-c", command}
+	} else {
+		for _, tmpl := range h.args {
+			arg, err := render(tmpl, data)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("hook %s timed out after %s", args[0], h.timeout)
+	}
+	if err != nil {
+		return output, fmt.Errorf("hook %s failed: %w", args[0], err)
+	}
+	return output, nil
+}
+
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("hook").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hook command: %w", err)
+	}
+	if _, err := render(tmpl, Data{}); err != nil {
+		return nil, fmt.Errorf("invalid hook command: %w", err)
+	}
+	return tmpl, nil
+}
+
+func render(tmpl *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render hook command: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// splitWords splits a command on whitespace, keeping quoted strings and
+// {{...}} actions together and dropping the quotes.
+func splitWords(command string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		actions int
+	)
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case actions > 0:
+			word.WriteRune(r)
+			if r == '}' && i+1 < len(runes) && runes[i+1] == '}' {
+				word.WriteRune('}')
+				i++
+				actions--
+			}
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			word.WriteString("{{")
+			i++
+			actions++
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in hook command")
+	}
+	if actions > 0 {
+		return nil, fmt.Errorf("unterminated {{ in hook command")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,132 @@
+// Package logging routes certgen's progress and summary output through a
+// single writer so the CLI can scale it from verbose diagnostics down to
+// complete silence. Errors are not handled here; they always go to stderr.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type Level int
+
+const (
+	// LevelSilent prints nothing; the exit code is the only signal.
+	LevelSilent Level = iota
+	// LevelQuiet prints only the final summary.
+	LevelQuiet
+	// LevelNormal prints progress and the final summary.
+	LevelNormal
+	// LevelVerbose additionally prints diagnostics.
+	LevelVerbose
+)
+
+type Logger struct {
+	out   io.Writer
+	level Level
+
+	// json, when set, receives every message as a JSON line instead of out.
+	json *slog.Logger
+	// lastStep is when the previous step finished, for step durations.
+	lastStep time.Time
+}
+
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{
+		out:   out,
+		level: level,
+	}
+}
+
+// NewJSON returns a Logger that writes one JSON object per message, for log
+// pipelines, filtered by level like New. Steps carry "step", "path" and
+// "duration_ms" (time since the previous step) fields; summary lines are
+// marked with "summary": true.
+func NewJSON(out io.Writer, level Level) *Logger {
+	handler := slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return &Logger{
+		out:      out,
+		level:    level,
+		json:     slog.New(handler),
+		lastStep: time.Now(),
+	}
+}
+
+func (l *Logger) Level() Level {
+	return l.level
+}
+
+// Infof prints a progress message.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.printf(LevelNormal, format, args...)
+}
+
+// Step reports a completed step, optionally with the file it produced.
+func (l *Logger) Step(msg, path string) {
+	if l.json != nil {
+		if l.level < LevelNormal {
+			return
+		}
+		now := time.Now()
+		attrs := []slog.Attr{
+			slog.String("step", msg),
+			slog.Int64("duration_ms", now.Sub(l.lastStep).Milliseconds()),
+		}
+		if path != "" {
+			attrs = append(attrs, slog.String("path", path))
+		}
+		l.lastStep = now
+		l.json.LogAttrs(context.Background(), slog.LevelInfo, msg, attrs...)
+		return
+	}
+	if path == "" {
+		l.printf(LevelNormal, "✓ %s\n", msg)
+		return
+	}
+	l.printf(LevelNormal, "✓ %s: %s\n", msg, path)
+}
+
+// Summaryf prints part of the final summary.
+func (l *Logger) Summaryf(format string, args ...interface{}) {
+	l.printf(LevelQuiet, format, args...)
+}
+
+// Debugf prints a diagnostic message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.printf(LevelVerbose, format, args...)
+}
+
+func (l *Logger) printf(level Level, format string, args ...interface{}) {
+	if l.level < level {
+		return
+	}
+	if l.json != nil {
+		l.logJSON(level, fmt.Sprintf(format, args...))
+		return
+	}
+	fmt.Fprintf(l.out, format, args...)
+}
+
+// logJSON emits each non-blank line of a text message as its own record,
+// dropping the indentation, alignment and decorations meant for terminals.
+func (l *Logger) logJSON(level Level, text string) {
+	slogLevel := slog.LevelInfo
+	if level == LevelVerbose {
+		slogLevel = slog.LevelDebug
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(line), "✓-")), " ")
+		if line == "" {
+			continue
+		}
+		if level == LevelQuiet {
+			l.json.Log(context.Background(), slogLevel, line, slog.Bool("summary", true))
+			continue
+		}
+		l.json.Log(context.Background(), slogLevel, line)
+	}
+}
@@ -10,33 +10,160 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/erfianugrah/certgen/pkg/encoding"
 )
 
-type Generator struct{}
+type Generator struct {
+	tempDir       string
+	includeCA     bool
+	macAlgorithm  string
+	macIterations int
+
+	// opensslBin is the binary set with SetOpenSSLPath; empty means
+	// "openssl". It is resolved once, on first use, into opensslPath.
+	opensslBin  string
+	opensslOnce sync.Once
+	opensslPath string
+	opensslErr  error
+
+	versionOnce sync.Once
+	version     *OpenSSLVersion
+	versionErr  error
+}
+
+// passwordEnv is the environment variable through which the PKCS#12
+// password reaches openssl and keytool. Unlike argv, a process's
+// environment is only readable by its owner.
+const passwordEnv = "CERTGEN_PKCS12_PASSWORD"
+
+// MACAlgorithms lists the digests accepted by SetMAC.
+var MACAlgorithms = []string{"sha1", "sha256", "sha384", "sha512"}
 
 func NewGenerator() *Generator {
-	return &Generator{}
+	return &Generator{
+		includeCA: true,
+	}
+}
+
+// SetIncludeCA controls whether the CA certificate passed to GeneratePKCS12 is
+// bundled alongside the leaf. It is included by default.
+func (g *Generator) SetIncludeCA(include bool) {
+	g.includeCA = include
+}
+
+// SetMAC overrides the MAC digest and the iteration count used for the MAC
+// and key encryption, for keystores that need specific legacy settings. An
+// empty algorithm or zero iteration count keeps the default.
+func (g *Generator) SetMAC(algorithm string, iterations int) {
+	g.macAlgorithm = algorithm
+	g.macIterations = iterations
+}
+
+// SetTempDir sets the base directory used for the intermediate files handed to
+// openssl. An empty value falls back to os.TempDir, which honors TMPDIR.
+func (g *Generator) SetTempDir(dir string) {
+	g.tempDir = dir
 }
 
+// SetOpenSSLPath sets the openssl binary to run, for installs outside PATH
+// such as a Homebrew keg. A name without a path separator is looked up in
+// PATH. It must be called before the generator is first used.
+func (g *Generator) SetOpenSSLPath(bin string) {
+	g.opensslBin = bin
+}
+
+// OpenSSLPath resolves the openssl binary once and returns its path, so a
+// batch of bundles does not search PATH for every one. It is safe for
+// concurrent use.
+func (g *Generator) OpenSSLPath() (string, error) {
+	g.opensslOnce.Do(func() {
+		bin := g.opensslBin
+		if bin == "" {
+			bin = "openssl"
+		}
+		g.opensslPath, g.opensslErr = exec.LookPath(bin)
+		if g.opensslErr != nil {
+			g.opensslErr = fmt.Errorf("openssl command not found: %w", g.opensslErr)
+		}
+	})
+	return g.opensslPath, g.opensslErr
+}
+
+// OpenSSLVersion detects (once) and returns the variant and version of the
+// openssl binary.
+func (g *Generator) OpenSSLVersion() (*OpenSSLVersion, error) {
+	g.versionOnce.Do(func() {
+		bin, err := g.OpenSSLPath()
+		if err != nil {
+			g.versionErr = err
+			return
+		}
+		g.version, g.versionErr = detectOpenSSLVersion(bin)
+	})
+	return g.version, g.versionErr
+}
+
+// CreateTempDir creates a private (0700) working directory under base.
+func CreateTempDir(base string) (string, error) {
+	dir, err := os.MkdirTemp(base, "certgen")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to restrict temp dir permissions: %w", err)
+	}
+	return dir, nil
+}
+
+// shredFile overwrites a file with zeros before it is removed so the
+// plaintext key does not linger in freed disk blocks longer than necessary.
+func shredFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(make([]byte, info.Size())); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// GeneratePKCS12 bundles the leaf certificate and key together with caCert,
+// so importers receive the issuer chain. caCert is left out when it is nil or
+// excluded via SetIncludeCA.
 func (g *Generator) GeneratePKCS12(leafCert *x509.Certificate, leafKey *rsa.PrivateKey, caCert *x509.Certificate, password string) ([]byte, error) {
 	// Check if OpenSSL is available
-	if _, err := exec.LookPath("openssl"); err != nil {
-		return nil, fmt.Errorf("openssl command not found in PATH: %w", err)
+	openssl, err := g.OpenSSLPath()
+	if err != nil {
+		return nil, err
 	}
 
 	// Create temporary files for the certificates and key
-	tempDir, err := os.MkdirTemp("", "certgen")
+	tempDir, err := CreateTempDir(g.tempDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir)
 
 	leafCertPath := filepath.Join(tempDir, "leaf.pem")
 	leafKeyPath := filepath.Join(tempDir, "leaf.key")
+	caCertPath := filepath.Join(tempDir, "ca.pem")
 	p12Path := filepath.Join(tempDir, "bundle.p12")
 
+	defer func() {
+		_ = shredFile(leafKeyPath)
+		os.RemoveAll(tempDir)
+	}()
+
 	// Write leaf certificate
 	leafCertPEM, err := encoding.EncodeCertificateToPEM(leafCert)
 	if err != nil {
@@ -51,21 +178,51 @@ func (g *Generator) GeneratePKCS12(leafCert *x509.Certificate, leafKey *rsa.Priv
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode leaf key: %w", err)
 	}
-	if err := os.WriteFile(leafKeyPath, leafKeyPEM, 0600); err != nil {
+	err = os.WriteFile(leafKeyPath, leafKeyPEM, 0600)
+	encoding.Zero(leafKeyPEM)
+	if err != nil {
 		return nil, fmt.Errorf("failed to write leaf key: %w", err)
 	}
 
-	// Generate PKCS#12 using openssl
-	cmd := exec.Command("openssl", "pkcs12", "-export",
+	// Generate PKCS#12 using openssl, falling back to its defaults if the
+	// version can't be determined
+	args := []string{"pkcs12", "-export",
 		"-out", p12Path,
 		"-inkey", leafKeyPath,
 		"-in", leafCertPath,
-		"-password", fmt.Sprintf("pass:%s", password))
+		"-password", "env:" + passwordEnv}
+	if caCert != nil && g.includeCA {
+		caCertPEM, err := encoding.EncodeCertificateToPEM(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode CA cert: %w", err)
+		}
+		if err := os.WriteFile(caCertPath, caCertPEM, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write CA cert: %w", err)
+		}
+		args = append(args, "-certfile", caCertPath)
+	}
+	if version, err := g.OpenSSLVersion(); err == nil {
+		args = append(args, version.ExportArgs()...)
+	}
+	if args, err = g.macArgs(args); err != nil {
+		return nil, err
+	}
+	cmd := passwordCommand(password, openssl, args...)
 
 	if err := cmd.Run(); err != nil {
 		return nil, fmt.Errorf("failed to generate PKCS#12: %w", err)
 	}
 
+	// An empty password is still used for encryption and the MAC; it is
+	// never exported with -nomac, since Go, Java and macOS importers reject
+	// MAC-less bundles. Implementations disagree on how an empty password is
+	// encoded, so make sure openssl can read back what it wrote.
+	if password == "" {
+		if err := verifyPKCS12(openssl, p12Path, password); err != nil {
+			return nil, err
+		}
+	}
+
 	// Read the generated PKCS#12 file
 	pfxData, err := os.ReadFile(p12Path)
 	if err != nil {
@@ -74,3 +231,45 @@ func (g *Generator) GeneratePKCS12(leafCert *x509.Certificate, leafKey *rsa.Priv
 
 	return pfxData, nil
 }
+
+// verifyPKCS12 checks that openssl can verify the MAC of and decrypt the
+// bundle at path with password.
+func verifyPKCS12(openssl, path, password string) error {
+	cmd := passwordCommand(password, openssl, "pkcs12", "-in", path, "-noout",
+		"-passin", "env:"+passwordEnv)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("generated PKCS#12 does not open with its password: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// passwordCommand returns a command running bin with args and password in
+// its environment as passwordEnv, for args that refer to it with openssl's
+// "env:" or keytool's ":env" syntax rather than carrying the password.
+func passwordCommand(password, bin string, args ...string) *exec.Cmd {
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append(os.Environ(), passwordEnv+"="+password)
+	return cmd
+}
+
+// macArgs applies the SetMAC overrides to the openssl arguments, replacing
+// any -macalg already chosen for the detected openssl version. It fails when
+// a MAC algorithm is set but the openssl variant cannot apply it.
+func (g *Generator) macArgs(args []string) ([]string, error) {
+	if g.macAlgorithm != "" {
+		if version, err := g.OpenSSLVersion(); err == nil && !version.SupportsMACAlg() {
+			return nil, fmt.Errorf("%s does not support choosing the PKCS#12 MAC algorithm", version)
+		}
+		for i := 0; i < len(args)-1; i++ {
+			if args[i] == "-macalg" {
+				args = append(args[:i], args[i+2:]...)
+				break
+			}
+		}
+		args = append(args, "-macalg", g.macAlgorithm)
+	}
+	if g.macIterations > 0 {
+		args = append(args, "-iter", strconv.Itoa(g.macIterations))
+	}
+	return args, nil
+}
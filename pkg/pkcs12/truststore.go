@@ -0,0 +1,166 @@
+package pkcs12
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+// TruststoreAlias is the entry name given to the CA certificate in a
+// truststore.
+const TruststoreAlias = "certgen-ca"
+
+// GenerateTruststore returns a PKCS#12 truststore holding only caCert, with
+// no private key, for Java clients that need to trust the CA. keytool is
+// used when it is in PATH and can honour the request, since it marks the
+// entry as a trusted certificate natively; otherwise openssl writes the
+// bundle, adding Java's trust attribute on OpenSSL 3.2 and later. See
+// JavaTrusted.
+func (g *Generator) GenerateTruststore(caCert *x509.Certificate, password string) ([]byte, error) {
+	if !g.useKeytool(password) {
+		return g.GeneratePKCS12TrustStore([]*x509.Certificate{caCert}, password, TruststoreAlias)
+	}
+
+	tempDir, err := CreateTempDir(g.tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	caCertPath := filepath.Join(tempDir, "ca.pem")
+	storePath := filepath.Join(tempDir, "truststore.p12")
+
+	caCertPEM, err := encoding.EncodeCertificateToPEM(caCert)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode CA cert: %w", err)
+	}
+	if err := os.WriteFile(caCertPath, caCertPEM, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write CA cert: %w", err)
+	}
+
+	cmd := passwordCommand(password, "keytool", "-importcert", "-noprompt",
+		"-alias", TruststoreAlias,
+		"-file", caCertPath,
+		"-keystore", storePath,
+		"-storetype", "PKCS12",
+		"-storepass:env", passwordEnv)
+
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to generate truststore with keytool: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read truststore: %w", err)
+	}
+	return data, nil
+}
+
+// JavaTrusted reports whether GenerateTruststore, given password, will mark
+// the CA as a trusted certificate entry. Without keytool or OpenSSL 3.2+,
+// Java's PKCS12 keystore loads the bundle but ignores the certificate, and
+// it has to be imported with "keytool -importcert" instead.
+func (g *Generator) JavaTrusted(password string) bool {
+	if g.useKeytool(password) {
+		return true
+	}
+	version, err := g.OpenSSLVersion()
+	return err == nil && version.SupportsJDKTrust()
+}
+
+// keytoolMinPassword is the shortest store password keytool accepts.
+const keytoolMinPassword = 6
+
+// useKeytool reports whether GenerateTruststore can hand the truststore to
+// keytool: it must be in PATH, and the request must not need what keytool
+// cannot do, namely a password shorter than six characters (including the
+// empty one) or the MAC settings from SetMAC.
+func (g *Generator) useKeytool(password string) bool {
+	if len(password) < keytoolMinPassword || g.macAlgorithm != "" || g.macIterations > 0 {
+		return false
+	}
+	_, err := exec.LookPath("keytool")
+	return err == nil
+}
+
+// GeneratePKCS12TrustStore returns a PKCS#12 bundle holding certs and no
+// private key, e.g. a leaf and its issuer for importing into a truststore.
+// Unlike GenerateTruststore it always uses openssl and accepts any number of
+// certificates; they are stored in the order given. aliases, if given, name
+// the entries in the same order.
+func (g *Generator) GeneratePKCS12TrustStore(certs []*x509.Certificate, password string, aliases ...string) ([]byte, error) {
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates for the trust store")
+	}
+	openssl, err := g.OpenSSLPath()
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := CreateTempDir(g.tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	certsPath := filepath.Join(tempDir, "certs.pem")
+	storePath := filepath.Join(tempDir, "trust.p12")
+
+	var certsPEM []byte
+	for _, cert := range certs {
+		certPEM, err := encoding.EncodeCertificateToPEM(cert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode certificate: %w", err)
+		}
+		certsPEM = append(certsPEM, certPEM...)
+	}
+	if err := os.WriteFile(certsPath, certsPEM, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write certificates: %w", err)
+	}
+
+	args := []string{"pkcs12", "-export", "-nokeys",
+		"-in", certsPath,
+		"-out", storePath,
+		"-password", "env:" + passwordEnv}
+	for _, alias := range aliases {
+		args = append(args, "-caname", alias)
+	}
+	if version, err := g.OpenSSLVersion(); err == nil {
+		args = append(args, version.ExportArgs()...)
+		if version.SupportsJDKTrust() {
+			args = append(args, "-jdktrust", "anyExtendedKeyUsage")
+		}
+	}
+	if args, err = g.macArgs(args); err != nil {
+		return nil, err
+	}
+	cmd := passwordCommand(password, openssl, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to generate PKCS#12 trust store: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if password == "" {
+		if err := verifyPKCS12(openssl, storePath, password); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#12 trust store: %w", err)
+	}
+	return data, nil
+}
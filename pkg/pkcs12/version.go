@@ -0,0 +1,85 @@
+package pkcs12
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OpenSSL command-line variants.
+const (
+	VariantOpenSSL  = "OpenSSL"
+	VariantLibreSSL = "LibreSSL"
+)
+
+// OpenSSLVersion describes the openssl binary used for PKCS#12 export.
+type OpenSSLVersion struct {
+	Variant string
+	Major   int
+	Minor   int
+	Patch   int
+}
+
+var versionPattern = regexp.MustCompile(`^(OpenSSL|LibreSSL)\s+(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseOpenSSLVersion parses the output of "openssl version", e.g.
+// "OpenSSL 3.0.13 30 Jan 2024" or "LibreSSL 3.3.6".
+func ParseOpenSSLVersion(output string) (*OpenSSLVersion, error) {
+	m := versionPattern.FindStringSubmatch(strings.TrimSpace(output))
+	if m == nil {
+		return nil, fmt.Errorf("unrecognized openssl version output: %q", strings.TrimSpace(output))
+	}
+
+	v := &OpenSSLVersion{Variant: m[1]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.Patch, _ = strconv.Atoi(m[4])
+	}
+	return v, nil
+}
+
+func (v *OpenSSLVersion) String() string {
+	return fmt.Sprintf("%s %d.%d.%d", v.Variant, v.Major, v.Minor, v.Patch)
+}
+
+// ExportArgs returns extra "openssl pkcs12 -export" flags that keep the
+// bundle importable by older Windows and macOS keystores. OpenSSL 3 defaults
+// to AES-256/PBKDF2 with a SHA-256 MAC, which those importers reject, so it
+// is pinned back to 3DES with a SHA-1 MAC. OpenSSL 1.x and LibreSSL already
+// use compatible defaults, and LibreSSL does not accept -macalg.
+func (v *OpenSSLVersion) ExportArgs() []string {
+	if v == nil || v.Variant != VariantOpenSSL || v.Major < 3 {
+		return nil
+	}
+	return []string{
+		"-certpbe", "PBE-SHA1-3DES",
+		"-keypbe", "PBE-SHA1-3DES",
+		"-macalg", "sha1",
+	}
+}
+
+// SupportsJDKTrust reports whether "openssl pkcs12" accepts -jdktrust,
+// added in OpenSSL 3.2.
+func (v *OpenSSLVersion) SupportsJDKTrust() bool {
+	if v == nil || v.Variant != VariantOpenSSL {
+		return false
+	}
+	return v.Major > 3 || (v.Major == 3 && v.Minor >= 2)
+}
+
+// SupportsMACAlg reports whether "openssl pkcs12 -export" accepts -macalg.
+// LibreSSL does not; an undetected version is assumed to.
+func (v *OpenSSLVersion) SupportsMACAlg() bool {
+	return v == nil || v.Variant != VariantLibreSSL
+}
+
+func detectOpenSSLVersion(bin string) (*OpenSSLVersion, error) {
+	out, err := exec.Command(bin, "version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s version: %w", bin, err)
+	}
+	return ParseOpenSSLVersion(string(out))
+}
@@ -0,0 +1,89 @@
+// Package report scans directories of issued certificates and summarizes
+// when they expire.
+package report
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+// Certificate statuses.
+const (
+	StatusOK       = "ok"
+	StatusExpiring = "expiring"
+	StatusExpired  = "expired"
+)
+
+// Entry describes one certificate found by Scan.
+type Entry struct {
+	Path          string    `json:"path"`
+	Subject       string    `json:"subject"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysRemaining int       `json:"days_remaining"`
+	Status        string    `json:"status"`
+}
+
+// Flagged reports whether the certificate is expired or expiring.
+func (e Entry) Flagged() bool {
+	return e.Status != StatusOK
+}
+
+// Scan walks dir for *.pem files and returns an entry for each one whose
+// first PEM block is a certificate, soonest expiry first. Keys, CSRs and
+// unparsable files are skipped. Certificates expiring within the window of
+// now are marked StatusExpiring.
+func Scan(dir string, within time.Duration, now time.Time) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".pem" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if block, _ := pem.Decode(data); block == nil || block.Type != encoding.PEMTypeCertificate {
+			return nil
+		}
+		cert, err := encoding.DecodePEMCertificate(data)
+		if err != nil {
+			return nil
+		}
+
+		remaining := cert.NotAfter.Sub(now)
+		entry := Entry{
+			Path:          path,
+			Subject:       cert.Subject.String(),
+			NotAfter:      cert.NotAfter,
+			DaysRemaining: int(remaining.Hours() / 24),
+			Status:        StatusOK,
+		}
+		switch {
+		case remaining <= 0:
+			entry.Status = StatusExpired
+		case remaining <= within:
+			entry.Status = StatusExpiring
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].NotAfter.Before(entries[j].NotAfter)
+	})
+	return entries, nil
+}
@@ -0,0 +1,232 @@
+// Package server exposes certificate generation over HTTP. A POST with a JSON
+// CertificateConfig returns a zip archive of the generated artifacts.
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certgen"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/fileio"
+	"github.com/erfianugrah/certgen/pkg/pkcs12"
+)
+
+const (
+	DefaultMaxBodyBytes = 64 << 10
+	DefaultTimeout      = 60 * time.Second
+
+	// DefaultMaxConcurrent bounds the generations running at once. A timed
+	// out request's generation keeps its slot until the key it is working
+	// on is done, so slow clients cannot pile up unbounded RSA work.
+	DefaultMaxConcurrent = 4
+
+	// Key sizes are bounded so a single request can't tie up the server
+	// generating an enormous key.
+	minKeySize = 2048
+	maxKeySize = 4096
+)
+
+type Handler struct {
+	maxBodyBytes    int64
+	timeout         time.Duration
+	maxValidityDays int
+	slots           chan struct{}
+}
+
+func NewHandler() *Handler {
+	return &Handler{
+		maxBodyBytes: DefaultMaxBodyBytes,
+		timeout:      DefaultTimeout,
+		slots:        make(chan struct{}, DefaultMaxConcurrent),
+	}
+}
+
+func (h *Handler) SetMaxBodyBytes(n int64) {
+	h.maxBodyBytes = n
+}
+
+func (h *Handler) SetTimeout(d time.Duration) {
+	h.timeout = d
+}
+
+// SetMaxConcurrent sets how many generations may run at once; requests
+// beyond that get 429. It must be called before the handler serves requests.
+func (h *Handler) SetMaxConcurrent(n int) {
+	if n < 1 {
+		n = 1
+	}
+	h.slots = make(chan struct{}, n)
+}
+
+// SetMaxValidityDays rejects requests for leaves valid longer than days.
+// Zero allows any validity Validate accepts.
+func (h *Handler) SetMaxValidityDays(days int) {
+	h.maxValidityDays = days
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	cfg := config.NewCertificateConfig()
+	// As with the CLI, the profile's validity applies unless the request
+	// sets one
+	cfg.ValidityDays = 0
+	if err := json.NewDecoder(r.Body).Decode(cfg); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	cfg.MaxValidityDays = h.maxValidityDays
+	if err := validate(cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many certificate generations in progress", http.StatusTooManyRequests)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	type result struct {
+		archive []byte
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		// The slot is released when generation stops, not when the
+		// handler returns
+		defer func() { <-h.slots }()
+		archive, err := generateArchive(ctx, cfg)
+		done <- result{archive, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		http.Error(w, "certificate generation timed out", http.StatusServiceUnavailable)
+	case res := <-done:
+		if ctx.Err() != nil && errors.Is(res.err, ctx.Err()) {
+			http.Error(w, "certificate generation timed out", http.StatusServiceUnavailable)
+			return
+		}
+		if res.err != nil {
+			http.Error(w, res.err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", cfg.FileIdentity()+".zip"))
+		w.Write(res.archive)
+	}
+}
+
+func validate(cfg *config.CertificateConfig) error {
+	if err := config.Validate(cfg); err != nil {
+		return err
+	}
+	if cfg.KeySize < minKeySize || cfg.KeySize > maxKeySize {
+		return fmt.Errorf("key size must be between %d and %d bits", minKeySize, maxKeySize)
+	}
+	for _, size := range []int{cfg.RootKeySize, cfg.LeafKeySize} {
+		if size != 0 && (size < minKeySize || size > maxKeySize) {
+			return fmt.Errorf("key size must be between %d and %d bits", minKeySize, maxKeySize)
+		}
+	}
+	cfg.Country, _ = config.NormalizeCountry(cfg.Country)
+	if cfg.PKCS12MACAlgorithm != "" && !slices.Contains(pkcs12.MACAlgorithms, cfg.PKCS12MACAlgorithm) {
+		return fmt.Errorf("PKCS12MACAlgorithm must be one of %s", strings.Join(pkcs12.MACAlgorithms, ", "))
+	}
+	if cfg.PKCS12MACIterations < 0 {
+		return fmt.Errorf("PKCS12MACIterations must not be negative")
+	}
+	return cfg.Normalize()
+}
+
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// generateArchive runs the standard root + leaf flow in memory and returns the
+// artifacts as a zip, named the same way the CLI names its output files.
+// The PKCS#12 bundle is included only when openssl is available. Generation
+// stops between steps once ctx is done.
+func generateArchive(ctx context.Context, cfg *config.CertificateConfig) ([]byte, error) {
+	names := fileio.NewFileWriter(cfg.FileIdentity())
+
+	bundle, err := certgen.GenerateContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	rootCert, leafCert := bundle.RootCert, bundle.LeafCert
+
+	rootCertPEM, rootKeyPEM, leafCertPEM, leafKeyPEM, err := bundle.PEM()
+	if err != nil {
+		return nil, err
+	}
+	defer encoding.Zero(rootKeyPEM)
+	defer encoding.Zero(leafKeyPEM)
+
+	files := []archiveFile{
+		{names.GetRootKeyPath(), rootKeyPEM},
+		{names.GetRootCertPath(), rootCertPEM},
+		{names.GetLeafKeyPath(), leafKeyPEM},
+		{names.GetLeafCertPath(), leafCertPEM},
+		{names.GetRootBase64Path(), []byte(encoding.EncodeDERToBase64(rootCert.Raw))},
+		{names.GetLeafBase64Path(), []byte(encoding.EncodeDERToBase64(leafCert.Raw))},
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pkcs12Gen := pkcs12.NewGenerator()
+	if _, err := pkcs12Gen.OpenSSLPath(); err == nil {
+		pkcs12Gen.SetMAC(cfg.PKCS12MACAlgorithm, cfg.PKCS12MACIterations)
+		pfxData, err := pkcs12Gen.GeneratePKCS12(leafCert, bundle.LeafKey, rootCert, cfg.PKCS12Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate PKCS#12: %w", err)
+		}
+		files = append(files, archiveFile{names.GetPKCS12Path(), pfxData})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", f.name, err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", f.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package castore_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/castore"
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func newTestGenerator() *certificate.Generator {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "store.example.com"
+	cfg.KeySize = 2048
+	return certificate.NewGenerator(cfg)
+}
+
+func TestStore_Paths(t *testing.T) {
+	store := castore.NewStore("/tmp/ca")
+
+	if store.CertPath() != filepath.Join("/tmp/ca", "rootCA.pem") {
+		t.Errorf("CertPath() = %s, want /tmp/ca/rootCA.pem", store.CertPath())
+	}
+	if store.KeyPath() != filepath.Join("/tmp/ca", "rootCA.key") {
+		t.Errorf("KeyPath() = %s, want /tmp/ca/rootCA.key", store.KeyPath())
+	}
+}
+
+func TestStore_LoadOrCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+	store := castore.NewStore(dir)
+	gen := newTestGenerator()
+
+	// First run generates and persists the CA
+	cert1, key1, created, err := store.LoadOrCreate(gen)
+	if err != nil {
+		t.Fatalf("LoadOrCreate failed: %v", err)
+	}
+	if !created {
+		t.Error("First LoadOrCreate should create a new CA")
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Failed to stat CA dir: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("CA dir permissions = %o, want 0700", perm)
+	}
+	keyInfo, err := os.Stat(store.KeyPath())
+	if err != nil {
+		t.Fatalf("Failed to stat CA key: %v", err)
+	}
+	if perm := keyInfo.Mode().Perm(); perm != 0600 {
+		t.Errorf("CA key permissions = %o, want 0600", perm)
+	}
+
+	// Second run loads the same CA
+	cert2, key2, created, err := store.LoadOrCreate(gen)
+	if err != nil {
+		t.Fatalf("Second LoadOrCreate failed: %v", err)
+	}
+	if created {
+		t.Error("Second LoadOrCreate should load the existing CA")
+	}
+	if cert1.SerialNumber.Cmp(cert2.SerialNumber) != 0 {
+		t.Error("Loaded CA certificate differs from the stored one")
+	}
+	if !key1.Equal(key2) {
+		t.Error("Loaded CA key differs from the stored one")
+	}
+
+	// A leaf signed by the loaded CA chains to the original
+	leafCert, _, err := gen.GenerateLeafCertificate(cert2, key2)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+	if err := leafCert.CheckSignatureFrom(cert1); err != nil {
+		t.Errorf("Leaf certificate signature verification failed: %v", err)
+	}
+}
+
+func TestStore_Incomplete(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(*castore.Store) string
+	}{
+		{"missing key", (*castore.Store).KeyPath},
+		{"missing cert", (*castore.Store).CertPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := castore.NewStore(t.TempDir())
+			if _, _, _, err := store.LoadOrCreate(newTestGenerator()); err != nil {
+				t.Fatalf("LoadOrCreate failed: %v", err)
+			}
+
+			if err := os.Remove(tt.remove(store)); err != nil {
+				t.Fatalf("Failed to remove file: %v", err)
+			}
+
+			if _, err := store.Exists(); err == nil {
+				t.Error("Exists should fail for an incomplete store")
+			}
+			if _, _, _, err := store.LoadOrCreate(newTestGenerator()); err == nil {
+				t.Error("LoadOrCreate should fail for an incomplete store")
+			}
+		})
+	}
+}
+
+func TestStore_Checksums(t *testing.T) {
+	store := castore.NewStore(filepath.Join(t.TempDir(), "ca"))
+	store.SetChecksums(true)
+
+	rootCert, rootKey, _, err := store.LoadOrCreate(newTestGenerator())
+	if err != nil {
+		t.Fatalf("LoadOrCreate failed: %v", err)
+	}
+	leaf, _, err := newTestGenerator().GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+	if err := store.AppendIndex(leaf); err != nil {
+		t.Fatalf("AppendIndex failed: %v", err)
+	}
+
+	for _, path := range []string{store.CertPath(), store.KeyPath(), store.IndexPath()} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", path, err)
+		}
+		sidecar, err := os.ReadFile(path + ".sha256")
+		if err != nil {
+			t.Errorf("No checksum written for %s: %v", filepath.Base(path), err)
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if want := hex.EncodeToString(sum[:]) + "  " + filepath.Base(path) + "\n"; string(sidecar) != want {
+			t.Errorf("%s.sha256 = %q, want %q", filepath.Base(path), sidecar, want)
+		}
+	}
+}
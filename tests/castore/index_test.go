@@ -0,0 +1,115 @@
+package castore_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/castore"
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestFormatIndexEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		serial int64
+		want   string
+	}{
+		{"odd length serial", 0xABC, "0ABC"},
+		{"even length serial", 0x1F, "1F"},
+		{"small serial", 1, "01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &x509.Certificate{
+				SerialNumber: big.NewInt(tt.serial),
+				NotAfter:     time.Date(2031, 2, 3, 4, 5, 6, 0, time.FixedZone("UTC+8", 8*3600)),
+				Subject:      pkix.Name{CommonName: "x"},
+			}
+
+			line := castore.FormatIndexEntry(cert)
+			if !strings.HasSuffix(line, "\n") {
+				t.Fatalf("FormatIndexEntry() = %q, want a trailing newline", line)
+			}
+			cols := strings.Split(strings.TrimSuffix(line, "\n"), "\t")
+			if len(cols) != 6 {
+				t.Fatalf("FormatIndexEntry() has %d columns, want 6: %q", len(cols), line)
+			}
+			if cols[0] != "V" {
+				t.Errorf("status = %q, want V", cols[0])
+			}
+			if cols[1] != "310202200506Z" {
+				t.Errorf("expiry = %q, want 310202200506Z", cols[1])
+			}
+			if cols[2] != "" {
+				t.Errorf("revocation date = %q, want empty", cols[2])
+			}
+			if cols[3] != tt.want {
+				t.Errorf("serial = %q, want %q", cols[3], tt.want)
+			}
+			if cols[4] != "unknown" {
+				t.Errorf("file name = %q, want unknown", cols[4])
+			}
+		})
+	}
+}
+
+func TestStore_AppendIndex(t *testing.T) {
+	store := castore.NewStore(filepath.Join(t.TempDir(), "ca"))
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "index.example.com"
+	cfg.KeySize = 2048
+
+	rootCert, rootKey, _, err := store.LoadOrCreate(certificate.NewGenerator(cfg))
+	if err != nil {
+		t.Fatalf("LoadOrCreate failed: %v", err)
+	}
+
+	var leaves []*x509.Certificate
+	for i := 0; i < 2; i++ {
+		leaf, _, err := certificate.NewGenerator(cfg).GenerateLeafCertificate(rootCert, rootKey)
+		if err != nil {
+			t.Fatalf("GenerateLeafCertificate failed: %v", err)
+		}
+		if err := store.AppendIndex(leaf); err != nil {
+			t.Fatalf("AppendIndex failed: %v", err)
+		}
+		leaves = append(leaves, leaf)
+	}
+
+	data, err := os.ReadFile(store.IndexPath())
+	if err != nil {
+		t.Fatalf("Failed to read index file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != len(leaves) {
+		t.Fatalf("index has %d lines, want %d", len(lines), len(leaves))
+	}
+
+	// openssl ca: status, expiry, revocation date, serial, file, DN
+	entry := regexp.MustCompile(`^V\t\d{12}Z\t\t([0-9A-F]{2})+\tunknown\t(/[A-Za-z]+=[^/\t]*)+$`)
+	for i, line := range lines {
+		if !entry.MatchString(line) {
+			t.Errorf("index line %d = %q, does not match openssl's format", i, line)
+		}
+		cols := strings.Split(line, "\t")
+		if want := leaves[i].NotAfter.UTC().Format("060102150405Z"); cols[1] != want {
+			t.Errorf("line %d expiry = %s, want %s", i, cols[1], want)
+		}
+		serial, ok := new(big.Int).SetString(cols[3], 16)
+		if !ok || serial.Cmp(leaves[i].SerialNumber) != 0 {
+			t.Errorf("line %d serial = %s, want %X", i, cols[3], leaves[i].SerialNumber)
+		}
+		if !strings.HasSuffix(cols[5], "/CN=index.example.com") {
+			t.Errorf("line %d DN = %s, want it to end with /CN=index.example.com", i, cols[5])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package castore_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/castore"
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestStore_NextSerialSequential(t *testing.T) {
+	store := castore.NewStore(filepath.Join(t.TempDir(), "ca"))
+
+	for want := int64(1); want <= 3; want++ {
+		serial, err := store.NextSerial()
+		if err != nil {
+			t.Fatalf("NextSerial failed: %v", err)
+		}
+		if serial.Int64() != want {
+			t.Errorf("NextSerial() = %d, want %d", serial.Int64(), want)
+		}
+	}
+
+	data, err := os.ReadFile(store.SerialPath())
+	if err != nil {
+		t.Fatalf("Failed to read serial file: %v", err)
+	}
+	if string(data) != "4\n" {
+		t.Errorf("serial file = %q, want %q", data, "4\n")
+	}
+}
+
+func TestStore_NextSerialConcurrent(t *testing.T) {
+	store := castore.NewStore(filepath.Join(t.TempDir(), "ca"))
+
+	const n = 32
+	serials := make([]int64, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			serial, err := store.NextSerial()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			serials[i] = serial.Int64()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool)
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("NextSerial failed: %v", errs[i])
+		}
+		if seen[serials[i]] {
+			t.Errorf("serial %d issued twice", serials[i])
+		}
+		seen[serials[i]] = true
+	}
+}
+
+func TestStore_NextSerialInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not hex", "xyz\n"},
+		{"zero", "0\n"},
+		{"negative", "-5\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := castore.NewStore(dir)
+			if err := os.WriteFile(store.SerialPath(), []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write serial file: %v", err)
+			}
+			if _, err := store.NextSerial(); err == nil {
+				t.Errorf("NextSerial() with %q should fail", tt.content)
+			}
+		})
+	}
+}
+
+func TestStore_ConsecutiveLeafSerials(t *testing.T) {
+	store := castore.NewStore(filepath.Join(t.TempDir(), "ca"))
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "serial.example.com"
+	cfg.KeySize = 2048
+
+	rootCert, rootKey, _, err := store.LoadOrCreate(certificate.NewGenerator(cfg))
+	if err != nil {
+		t.Fatalf("LoadOrCreate failed: %v", err)
+	}
+
+	var prev int64
+	for i := 0; i < 2; i++ {
+		serial, err := store.NextSerial()
+		if err != nil {
+			t.Fatalf("NextSerial failed: %v", err)
+		}
+		leafCfg := *cfg
+		leafCfg.Serial = serial
+		leaf, _, err := certificate.NewGenerator(&leafCfg).GenerateLeafCertificate(rootCert, rootKey)
+		if err != nil {
+			t.Fatalf("GenerateLeafCertificate failed: %v", err)
+		}
+		got := leaf.SerialNumber.Int64()
+		if i > 0 && got != prev+1 {
+			t.Errorf("second leaf serial = %d, want %d", got, prev+1)
+		}
+		prev = got
+	}
+}
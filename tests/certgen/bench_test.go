@@ -0,0 +1,26 @@
+package certgen_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certgen"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+// BenchmarkGenerate measures a full single-domain run: a root CA and one
+// leaf, each with a fresh key.
+func BenchmarkGenerate(b *testing.B) {
+	for _, bits := range []int{2048, 4096} {
+		b.Run(fmt.Sprintf("%d", bits), func(b *testing.B) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "bench.example.com"
+			cfg.KeySize = bits
+			for i := 0; i < b.N; i++ {
+				if _, err := certgen.Generate(cfg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
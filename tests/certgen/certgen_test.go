@@ -0,0 +1,209 @@
+package certgen_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certgen"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func ExampleGenerateInMemory() {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "svc.example.com"
+	cfg.KeySize = 2048
+
+	rootPEM, rootKeyPEM, leafPEM, leafKeyPEM, err := certgen.GenerateInMemory(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer encoding.Zero(rootKeyPEM)
+	defer encoding.Zero(leafKeyPEM)
+
+	leaf, err := encoding.DecodePEMCertificate(leafPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root, err := encoding.DecodePEMCertificate(rootPEM)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(leaf.Subject.CommonName, leaf.CheckSignatureFrom(root) == nil)
+	// Output: svc.example.com true
+}
+
+func TestGenerateInMemory(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "memory.test.com"
+	cfg.KeySize = 2048
+
+	rootPEM, rootKeyPEM, leafPEM, leafKeyPEM, err := certgen.GenerateInMemory(cfg)
+	if err != nil {
+		t.Fatalf("GenerateInMemory failed: %v", err)
+	}
+
+	rootCert, err := encoding.DecodePEMCertificate(rootPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode root certificate: %v", err)
+	}
+	leafCert, err := encoding.DecodePEMCertificate(leafPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode leaf certificate: %v", err)
+	}
+	rootKey, err := encoding.DecodePEMPrivateKey(rootKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode root key: %v", err)
+	}
+	leafKey, err := encoding.DecodePEMPrivateKey(leafKeyPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode leaf key: %v", err)
+	}
+
+	if !rootCert.IsCA {
+		t.Error("Root certificate is not a CA")
+	}
+	if !rootKey.PublicKey.Equal(rootCert.PublicKey) {
+		t.Error("Root key does not match root certificate")
+	}
+	if !leafKey.PublicKey.Equal(leafCert.PublicKey) {
+		t.Error("Leaf key does not match leaf certificate")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	if _, err := leafCert.Verify(x509.VerifyOptions{DNSName: cfg.Domain, Roots: roots}); err != nil {
+		t.Errorf("Leaf does not verify against root: %v", err)
+	}
+}
+
+func TestGenerate_ProfileValidity(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  string
+		days     int
+		wantDays int
+	}{
+		{"profile validity", config.ProfileWebServer, 0, 398},
+		{"explicit days win", config.ProfileWebServer, 30, 30},
+		{"profile without validity", config.ProfileServer, 0, config.DefaultValidityDays},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "profile.example.com"
+			cfg.KeySize = 2048
+			cfg.Profile = tt.profile
+			cfg.ValidityDays = tt.days
+
+			bundle, err := certgen.Generate(cfg)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			want := time.Duration(tt.wantDays) * 24 * time.Hour
+			if got := bundle.LeafCert.NotAfter.Sub(bundle.LeafCert.NotBefore); got != want {
+				t.Errorf("leaf validity = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerate_InvalidConfig(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "invalid.test.com"
+	cfg.KeySize = 512
+
+	if _, err := certgen.Generate(cfg); err == nil {
+		t.Error("Generate should fail with an invalid key size")
+	}
+}
+
+func TestBundle_JSON(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "json.test.com"
+	cfg.KeySize = 2048
+
+	bundle, err := certgen.Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		p12  []byte
+	}{
+		{"with PKCS#12", []byte("not really a p12")},
+		{"without PKCS#12", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bundle.JSON(tt.p12)
+			if err != nil {
+				t.Fatalf("JSON failed: %v", err)
+			}
+
+			var doc certgen.JSONBundle
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatalf("Failed to unmarshal JSON bundle: %v", err)
+			}
+
+			rootCert, err := encoding.DecodePEMCertificate([]byte(doc.RootCert))
+			if err != nil {
+				t.Fatalf("Failed to decode root_cert: %v", err)
+			}
+			leafCert, err := encoding.DecodePEMCertificate([]byte(doc.LeafCert))
+			if err != nil {
+				t.Fatalf("Failed to decode leaf_cert: %v", err)
+			}
+			if _, err := encoding.DecodePEMPrivateKey([]byte(doc.RootKey)); err != nil {
+				t.Errorf("Failed to decode root_key: %v", err)
+			}
+			leafKey, err := encoding.DecodePEMPrivateKey([]byte(doc.LeafKey))
+			if err != nil {
+				t.Fatalf("Failed to decode leaf_key: %v", err)
+			}
+			if !leafKey.PublicKey.Equal(leafCert.PublicKey) {
+				t.Error("leaf_key does not match leaf_cert")
+			}
+			if err := leafCert.CheckSignatureFrom(rootCert); err != nil {
+				t.Errorf("leaf_cert is not signed by root_cert: %v", err)
+			}
+
+			if tt.p12 == nil {
+				if bytes.Contains(data, []byte("p12_base64")) {
+					t.Error("JSON bundle contains p12_base64 without PKCS#12 data")
+				}
+				return
+			}
+			p12, err := base64.StdEncoding.DecodeString(doc.P12Base64)
+			if err != nil {
+				t.Fatalf("Failed to decode p12_base64: %v", err)
+			}
+			if !bytes.Equal(p12, tt.p12) {
+				t.Errorf("p12_base64 = %q, want %q", p12, tt.p12)
+			}
+		})
+	}
+}
+
+func TestGenerateContext_Canceled(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "svc.example.com"
+	cfg.KeySize = 2048
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := certgen.GenerateContext(ctx, cfg); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateContext() error = %v, want context.Canceled", err)
+	}
+}
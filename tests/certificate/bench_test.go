@@ -0,0 +1,43 @@
+package certificate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func BenchmarkGeneratePrivateKey(b *testing.B) {
+	for _, bits := range []int{2048, 4096} {
+		b.Run(fmt.Sprintf("%d", bits), func(b *testing.B) {
+			gen := certificate.NewGenerator(&config.CertificateConfig{KeySize: bits})
+			for i := 0; i < b.N; i++ {
+				if _, err := gen.GeneratePrivateKey(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGenerateLeafCertificate measures issuing one leaf from an existing
+// root, as each extra --count or --leaf-domain leaf costs.
+func BenchmarkGenerateLeafCertificate(b *testing.B) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "bench.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := gen.GenerateLeafCertificate(caCert, caKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
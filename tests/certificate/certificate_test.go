@@ -1,7 +1,15 @@
 package certificate_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
 	"testing"
 	"time"
 
@@ -134,6 +142,8 @@ func TestGenerator_GenerateLeafCertificate(t *testing.T) {
 	cfg.Domain = "leaf.example.com"
 	cfg.ValidityDays = 90
 	cfg.KeySize = 2048
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg.SetClock(func() time.Time { return now })
 
 	gen := certificate.NewGenerator(cfg)
 
@@ -198,12 +208,13 @@ func TestGenerator_GenerateLeafCertificate(t *testing.T) {
 		t.Errorf("DNSNames = %v, want [%s]", leafCert.DNSNames, cfg.Domain)
 	}
 
-	// Verify validity period
-	expectedDuration := time.Duration(cfg.ValidityDays) * 24 * time.Hour
-	actualDuration := leafCert.NotAfter.Sub(leafCert.NotBefore)
-	// Allow small time difference due to execution time
-	if actualDuration < expectedDuration-time.Minute || actualDuration > expectedDuration+time.Minute {
-		t.Errorf("Certificate validity = %v, want ~%v", actualDuration, expectedDuration)
+	// Verify validity period against the frozen clock
+	if !leafCert.NotBefore.Equal(now) {
+		t.Errorf("NotBefore = %v, want %v", leafCert.NotBefore, now)
+	}
+	wantNotAfter := now.Add(time.Duration(cfg.ValidityDays) * 24 * time.Hour)
+	if !leafCert.NotAfter.Equal(wantNotAfter) {
+		t.Errorf("NotAfter = %v, want %v", leafCert.NotAfter, wantNotAfter)
 	}
 
 	// Verify certificate is signed by CA
@@ -217,6 +228,33 @@ func TestGenerator_GenerateLeafCertificate(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateLeafCertificate_ServerOnly(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "leaf.example.com"
+	cfg.KeySize = 2048
+	cfg.ServerOnly = true
+
+	gen := certificate.NewGenerator(cfg)
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	leafCert, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	want := []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	if !reflect.DeepEqual(leafCert.ExtKeyUsage, want) {
+		t.Errorf("ExtKeyUsage = %v, want %v", leafCert.ExtKeyUsage, want)
+	}
+	for _, usage := range leafCert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageClientAuth {
+			t.Error("Leaf certificate has ClientAuth extended key usage with ServerOnly set")
+		}
+	}
+}
+
 func TestGenerator_GenerateCertificateRequest(t *testing.T) {
 	cfg := config.NewCertificateConfig()
 	cfg.Domain = "csr.example.com"
@@ -262,6 +300,98 @@ func TestGenerator_GenerateCertificateRequest(t *testing.T) {
 	}
 }
 
+func TestGenerator_GenerateCertificateRequestDER(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "csr.example.com"
+	cfg.KeySize = 2048
+
+	gen := certificate.NewGenerator(cfg)
+	key, err := gen.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	csr, err := gen.GenerateCertificateRequest(key)
+	if err != nil {
+		t.Fatalf("GenerateCertificateRequest failed: %v", err)
+	}
+
+	// Raw is the DER written by --csr-der
+	parsed, err := x509.ParseCertificateRequest(csr.Raw)
+	if err != nil {
+		t.Fatalf("ParseCertificateRequest of raw DER failed: %v", err)
+	}
+	if parsed.Subject.CommonName != cfg.Domain {
+		t.Errorf("DER CSR CN = %s, want %s", parsed.Subject.CommonName, cfg.Domain)
+	}
+}
+
+func TestGenerator_SignatureAlgorithm(t *testing.T) {
+	tests := []struct {
+		name string
+		want x509.SignatureAlgorithm
+	}{
+		{"SHA384-RSA", x509.SHA384WithRSA},
+		{"SHA512-RSA", x509.SHA512WithRSA},
+		{"SHA256-RSAPSS", x509.SHA256WithRSAPSS},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "sigalg.example.com"
+			cfg.KeySize = 2048
+			cfg.SignatureAlgorithm = tt.name
+			gen := certificate.NewGenerator(cfg)
+
+			key, err := gen.GeneratePrivateKey()
+			if err != nil {
+				t.Fatalf("Failed to generate key: %v", err)
+			}
+			csr, err := gen.GenerateCertificateRequest(key)
+			if err != nil {
+				t.Fatalf("GenerateCertificateRequest failed: %v", err)
+			}
+			if csr.SignatureAlgorithm != tt.want {
+				t.Errorf("CSR SignatureAlgorithm = %v, want %v", csr.SignatureAlgorithm, tt.want)
+			}
+			if err := csr.CheckSignature(); err != nil {
+				t.Errorf("CSR signature verification failed: %v", err)
+			}
+
+			root, rootKey, err := gen.GenerateRootCA()
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+			leaf, _, err := gen.GenerateLeafCertificate(root, rootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+			if root.SignatureAlgorithm != tt.want || leaf.SignatureAlgorithm != tt.want {
+				t.Errorf("SignatureAlgorithm = %v (root), %v (leaf), want %v", root.SignatureAlgorithm, leaf.SignatureAlgorithm, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_SignatureAlgorithmKeyMismatch(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "sigalg.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	key, err := gen.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	for _, name := range []string{"ECDSA-SHA256", "Ed25519", "MD5-RSA"} {
+		cfg.SignatureAlgorithm = name
+		if _, err := gen.GenerateCertificateRequest(key); err == nil {
+			t.Errorf("GenerateCertificateRequest with %s and an RSA key succeeded, want an error", name)
+		}
+	}
+}
+
 func TestGenerator_MultipleCertificates(t *testing.T) {
 	cfg := config.NewCertificateConfig()
 	cfg.Domain = "multi.example.com"
@@ -384,3 +514,485 @@ func TestGenerator_SpecialCharacterDomain(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerator_GenerateDER(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "der.example.com"
+	cfg.KeySize = 2048
+
+	gen := certificate.NewGenerator(cfg)
+
+	rootDER, rootKey, err := gen.GenerateRootCADER()
+	if err != nil {
+		t.Fatalf("GenerateRootCADER failed: %v", err)
+	}
+	rootCert, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("Failed to parse root DER: %v", err)
+	}
+	if !rootCert.IsCA {
+		t.Error("Root CA certificate IsCA = false, want true")
+	}
+
+	leafDER, leafKey, err := gen.GenerateLeafCertificateDER(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificateDER failed: %v", err)
+	}
+	if leafKey == nil {
+		t.Fatal("GenerateLeafCertificateDER returned nil key")
+	}
+	leafCert, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("Failed to parse leaf DER: %v", err)
+	}
+
+	if err := leafCert.CheckSignatureFrom(rootCert); err != nil {
+		t.Errorf("Leaf certificate signature verification failed: %v", err)
+	}
+}
+
+func TestSignCSR(t *testing.T) {
+	caCfg := config.NewCertificateConfig()
+	caCfg.Domain = "ca.example.com"
+	caCfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(caCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	reqCfg := config.NewCertificateConfig()
+	reqCfg.Domain = "external.example.com"
+	reqCfg.Organization = "External Org"
+	reqCfg.KeySize = 2048
+	reqGen := certificate.NewGenerator(reqCfg)
+	key, err := reqGen.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	csr, err := reqGen.GenerateCertificateRequest(key)
+	if err != nil {
+		t.Fatalf("Failed to generate CSR: %v", err)
+	}
+
+	validity := 30 * 24 * time.Hour
+	cert, err := certificate.SignCSR(csr, caCert, caKey, validity)
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+
+	if cert.Subject.CommonName != reqCfg.Domain {
+		t.Errorf("Certificate CN = %s, want %s", cert.Subject.CommonName, reqCfg.Domain)
+	}
+	if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] != reqCfg.Organization {
+		t.Errorf("Certificate Organization = %v, want [%s]", cert.Subject.Organization, reqCfg.Organization)
+	}
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != reqCfg.Domain {
+		t.Errorf("DNSNames = %v, want [%s]", cert.DNSNames, reqCfg.Domain)
+	}
+	if cert.NotAfter.Sub(cert.NotBefore) != validity {
+		t.Errorf("Certificate validity = %v, want %v", cert.NotAfter.Sub(cert.NotBefore), validity)
+	}
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		t.Error("Certificate public key does not match CSR public key")
+	}
+	if err := cert.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("Signed certificate signature verification failed: %v", err)
+	}
+	if want := x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment; cert.KeyUsage != want {
+		t.Errorf("KeyUsage = %v, want %v for an RSA key", cert.KeyUsage, want)
+	}
+}
+
+func TestSignCSR_AllSANTypes(t *testing.T) {
+	caCfg := config.NewCertificateConfig()
+	caCfg.Domain = "ca.example.com"
+	caCfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(caCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://example.com/ns/default/sa/api")
+	template := &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: "api.example.com"},
+		DNSNames:       []string{"api.example.com", "api.internal"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1").To4(), net.ParseIP("2001:db8::1")},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"ops@example.com"},
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+
+	cert, err := certificate.SignCSR(csr, caCert, caKey, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cert.DNSNames, template.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", cert.DNSNames, template.DNSNames)
+	}
+	if len(cert.IPAddresses) != len(template.IPAddresses) {
+		t.Fatalf("IPAddresses = %v, want %v", cert.IPAddresses, template.IPAddresses)
+	}
+	for i, ip := range template.IPAddresses {
+		if !cert.IPAddresses[i].Equal(ip) {
+			t.Errorf("IPAddresses[%d] = %v, want %v", i, cert.IPAddresses[i], ip)
+		}
+	}
+	if len(cert.URIs) != 1 || cert.URIs[0].String() != spiffe.String() {
+		t.Errorf("URIs = %v, want [%v]", cert.URIs, spiffe)
+	}
+	if !reflect.DeepEqual(cert.EmailAddresses, template.EmailAddresses) {
+		t.Errorf("EmailAddresses = %v, want %v", cert.EmailAddresses, template.EmailAddresses)
+	}
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		t.Error("Certificate public key does not match CSR public key")
+	}
+	if cert.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
+		t.Error("ECDSA certificate should not have keyEncipherment")
+	}
+	if cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		t.Error("ECDSA certificate should have digitalSignature")
+	}
+}
+
+func TestSignCSR_InvalidSignature(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "tampered.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	key, err := gen.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	csr, err := gen.GenerateCertificateRequest(key)
+	if err != nil {
+		t.Fatalf("Failed to generate CSR: %v", err)
+	}
+
+	// Corrupt the signature so CheckSignature fails
+	csr.Signature[0] ^= 0xff
+
+	if _, err := certificate.SignCSR(csr, caCert, caKey, 24*time.Hour); err == nil {
+		t.Error("SignCSR should reject a CSR with an invalid signature")
+	}
+}
+
+func TestSignCSR_InvalidInput(t *testing.T) {
+	if _, err := certificate.SignCSR(nil, nil, nil, time.Hour); err == nil {
+		t.Error("SignCSR should fail with nil CSR")
+	}
+}
+
+func TestGenerator_NameConstraints(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "ca.internal.example.com"
+	cfg.KeySize = 2048
+	cfg.PermittedDNSDomains = []string{"internal.example.com"}
+	cfg.ExcludedDNSDomains = []string{"secret.internal.example.com"}
+
+	caCert, caKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	if !caCert.PermittedDNSDomainsCritical {
+		t.Error("PermittedDNSDomainsCritical = false, want true")
+	}
+	if len(caCert.PermittedDNSDomains) != 1 || caCert.PermittedDNSDomains[0] != "internal.example.com" {
+		t.Errorf("PermittedDNSDomains = %v, want [internal.example.com]", caCert.PermittedDNSDomains)
+	}
+	if len(caCert.ExcludedDNSDomains) != 1 || caCert.ExcludedDNSDomains[0] != "secret.internal.example.com" {
+		t.Errorf("ExcludedDNSDomains = %v, want [secret.internal.example.com]", caCert.ExcludedDNSDomains)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+
+	tests := []struct {
+		domain  string
+		wantErr bool
+	}{
+		{"svc.internal.example.com", false},
+		{"svc.example.org", true},
+		{"db.secret.internal.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			leafCfg := config.NewCertificateConfig()
+			leafCfg.Domain = tt.domain
+			leafCfg.KeySize = 2048
+
+			leafCert, _, err := certificate.NewGenerator(leafCfg).GenerateLeafCertificate(caCert, caKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+
+			_, err = leafCert.Verify(x509.VerifyOptions{Roots: roots})
+			if tt.wantErr && err == nil {
+				t.Errorf("Verify succeeded for %s, want name constraint violation", tt.domain)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Verify failed for %s: %v", tt.domain, err)
+			}
+		})
+	}
+}
+
+func TestGenerator_LeafProfiles(t *testing.T) {
+	tests := []struct {
+		profile    string
+		wantServer bool
+		wantClient bool
+	}{
+		{config.ProfileServer, true, false},
+		{config.ProfileClient, false, true},
+		{config.ProfileBoth, true, true},
+		{config.ProfileWebServer, true, false},
+		{config.ProfileCodeSigning, false, false},
+	}
+
+	caCfg := config.NewCertificateConfig()
+	caCfg.Domain = "ca.example.com"
+	caCfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(caCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "profile.example.com"
+			cfg.KeySize = 2048
+			cfg.Profile = tt.profile
+
+			leafCert, _, err := certificate.NewGenerator(cfg).GenerateLeafCertificate(caCert, caKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+
+			hasServerAuth := false
+			hasClientAuth := false
+			for _, usage := range leafCert.ExtKeyUsage {
+				switch usage {
+				case x509.ExtKeyUsageServerAuth:
+					hasServerAuth = true
+				case x509.ExtKeyUsageClientAuth:
+					hasClientAuth = true
+				}
+			}
+			if hasServerAuth != tt.wantServer {
+				t.Errorf("ServerAuth present = %v, want %v", hasServerAuth, tt.wantServer)
+			}
+			if hasClientAuth != tt.wantClient {
+				t.Errorf("ClientAuth present = %v, want %v", hasClientAuth, tt.wantClient)
+			}
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		cfg := config.NewCertificateConfig()
+		cfg.Domain = "profile.example.com"
+		cfg.KeySize = 2048
+		cfg.Profile = "bogus"
+
+		if _, _, err := certificate.NewGenerator(cfg).GenerateLeafCertificate(caCert, caKey); err == nil {
+			t.Error("GenerateLeafCertificate should fail with unknown profile")
+		}
+	})
+}
+
+func TestGenerator_CAProfile(t *testing.T) {
+	caCfg := config.NewCertificateConfig()
+	caCfg.Domain = "ca.example.com"
+	caCfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(caCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "intermediate.example.com"
+	cfg.KeySize = 2048
+	cfg.Profile = config.ProfileCA
+
+	cert, _, err := certificate.NewGenerator(cfg).GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+	if !cert.IsCA || !cert.BasicConstraintsValid {
+		t.Error("Certificate issued with the ca profile should be a CA")
+	}
+	if want := x509.KeyUsageCertSign | x509.KeyUsageCRLSign; cert.KeyUsage != want {
+		t.Errorf("KeyUsage = %v, want %v", cert.KeyUsage, want)
+	}
+}
+
+func TestGenerator_RootNoSAN(t *testing.T) {
+	for _, noSAN := range []bool{false, true} {
+		t.Run(fmt.Sprintf("noSAN=%v", noSAN), func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "ca.example.com"
+			cfg.KeySize = 2048
+			cfg.RootNoSAN = noSAN
+			gen := certificate.NewGenerator(cfg)
+
+			rootCert, rootKey, err := gen.GenerateRootCA()
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+
+			hasSAN := false
+			for _, ext := range rootCert.Extensions {
+				if ext.Id.Equal([]int{2, 5, 29, 17}) {
+					hasSAN = true
+				}
+			}
+			if hasSAN == noSAN {
+				t.Errorf("root SAN extension present = %v, want %v (DNSNames %v)", hasSAN, !noSAN, rootCert.DNSNames)
+			}
+
+			// The leaf keeps its SAN either way
+			leafCert, _, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+			if !reflect.DeepEqual(leafCert.DNSNames, []string{cfg.Domain}) {
+				t.Errorf("leaf DNSNames = %v, want [%s]", leafCert.DNSNames, cfg.Domain)
+			}
+		})
+	}
+}
+
+func TestGenerator_IPOnly(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.IPAddresses = []net.IP{net.ParseIP("10.0.0.5"), net.ParseIP("fd00::5")}
+	cfg.KeySize = 2048
+	if err := config.Validate(cfg); err != nil {
+		t.Fatalf("Validate rejected an IP-only config: %v", err)
+	}
+	gen := certificate.NewGenerator(cfg)
+
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	leafCert, _, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	if len(leafCert.DNSNames) != 0 {
+		t.Errorf("DNSNames = %v, want none", leafCert.DNSNames)
+	}
+	if leafCert.Subject.CommonName != "10.0.0.5" {
+		t.Errorf("CommonName = %q, want 10.0.0.5", leafCert.Subject.CommonName)
+	}
+	for _, host := range []string{"10.0.0.5", "fd00::5"} {
+		if err := leafCert.VerifyHostname(host); err != nil {
+			t.Errorf("VerifyHostname(%q) failed: %v", host, err)
+		}
+	}
+	if err := leafCert.VerifyHostname("10.0.0.6"); err == nil {
+		t.Error("VerifyHostname(10.0.0.6) succeeded, want an error")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	if _, err := leafCert.Verify(x509.VerifyOptions{Roots: roots}); err != nil {
+		t.Errorf("Leaf does not verify against root: %v", err)
+	}
+}
+
+func TestGenerator_SeparateKeySizes(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "keysize.example.com"
+	cfg.RootKeySize = 3072
+	cfg.LeafKeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	leafCert, leafKey, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	if got := rootKey.N.BitLen(); got != 3072 {
+		t.Errorf("root key size = %d, want 3072", got)
+	}
+	if got := leafKey.N.BitLen(); got != 2048 {
+		t.Errorf("leaf key size = %d, want 2048", got)
+	}
+	if !leafKey.PublicKey.Equal(leafCert.PublicKey) {
+		t.Error("leaf certificate does not hold the generated leaf key")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+	if _, err := leafCert.Verify(x509.VerifyOptions{Roots: roots}); err != nil {
+		t.Errorf("Leaf does not verify against root: %v", err)
+	}
+}
+
+func TestGenerator_SerialBits(t *testing.T) {
+	tests := []struct {
+		name    string
+		bits    int
+		wantErr bool
+	}{
+		{"default", 0, false},
+		{"64-bit", 64, false},
+		{"128-bit", 128, false},
+		{"160-bit", 160, false},
+		{"too small", 32, true},
+		{"too large", 256, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "serial.example.com"
+			cfg.KeySize = 2048
+			cfg.SerialBits = tt.bits
+
+			cert, _, err := certificate.NewGenerator(cfg).GenerateRootCA()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GenerateRootCA should fail with %d serial bits", tt.bits)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+
+			maxBits := tt.bits
+			if maxBits == 0 {
+				maxBits = config.DefaultSerialBits
+			}
+			if bitLen := cert.SerialNumber.BitLen(); bitLen == 0 || bitLen > maxBits {
+				t.Errorf("Serial number bit length = %d, want 1..%d", bitLen, maxBits)
+			}
+		})
+	}
+}
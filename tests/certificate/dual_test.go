@@ -0,0 +1,84 @@
+package certificate_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"reflect"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestGenerator_DualLeaves(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "dual.example.com"
+	cfg.DNSNames = []string{"www.dual.example.com"}
+	cfg.KeySize = 2048
+
+	rsaRoot, rsaRootKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	otherRoot, otherRootKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		ecdsaRoot *x509.Certificate
+		ecdsaKey  *rsa.PrivateKey
+	}{
+		{"same root", rsaRoot, rsaRootKey},
+		{"separate roots", otherRoot, otherRootKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := certificate.NewGenerator(cfg)
+			rsaLeaf, _, err := gen.GenerateLeafCertificate(rsaRoot, rsaRootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+			ecLeaf, ecKey, err := gen.GenerateECDSALeafCertificate(tt.ecdsaRoot, tt.ecdsaKey)
+			if err != nil {
+				t.Fatalf("GenerateECDSALeafCertificate failed: %v", err)
+			}
+
+			if _, ok := rsaLeaf.PublicKey.(*rsa.PublicKey); !ok {
+				t.Errorf("RSA leaf public key is %T", rsaLeaf.PublicKey)
+			}
+			pub, ok := ecLeaf.PublicKey.(*ecdsa.PublicKey)
+			if !ok || !pub.Equal(&ecKey.PublicKey) {
+				t.Errorf("ECDSA leaf public key does not match the generated key")
+			}
+
+			if !reflect.DeepEqual(rsaLeaf.DNSNames, ecLeaf.DNSNames) {
+				t.Errorf("SANs differ: RSA %v, ECDSA %v", rsaLeaf.DNSNames, ecLeaf.DNSNames)
+			}
+			if ecLeaf.KeyUsage&x509.KeyUsageKeyEncipherment != 0 {
+				t.Error("ECDSA leaf should not assert keyEncipherment")
+			}
+
+			for _, c := range []struct {
+				leaf *x509.Certificate
+				root *x509.Certificate
+			}{
+				{rsaLeaf, rsaRoot},
+				{ecLeaf, tt.ecdsaRoot},
+			} {
+				roots := x509.NewCertPool()
+				roots.AddCert(c.root)
+				if _, err := c.leaf.Verify(x509.VerifyOptions{
+					DNSName:   "www.dual.example.com",
+					Roots:     roots,
+					KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+				}); err != nil {
+					t.Errorf("%s leaf does not verify against its root: %v", c.leaf.PublicKeyAlgorithm, err)
+				}
+			}
+		})
+	}
+}
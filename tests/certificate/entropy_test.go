@@ -0,0 +1,112 @@
+package certificate_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+)
+
+// readerSource mocks the kernel interface by parsing each reading in turn,
+// repeating the last one once they run out.
+func readerSource(readings ...string) certificate.EntropySource {
+	i := 0
+	return func() (int, error) {
+		r := strings.NewReader(readings[min(i, len(readings)-1)])
+		i++
+		return certificate.ReadEntropyAvail(r)
+	}
+}
+
+func TestReadEntropyAvail(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"256\n", 256, false},
+		{"  3754 \n", 3754, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"-5", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := certificate.ReadEntropyAvail(strings.NewReader(tt.input))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ReadEntropyAvail(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ReadEntropyAvail(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCheckEntropy(t *testing.T) {
+	unavailable := func() (int, error) { return 0, certificate.ErrEntropyUnavailable }
+
+	tests := []struct {
+		name      string
+		src       certificate.EntropySource
+		min       int
+		wantAvail int
+		wantOK    bool
+		wantErr   bool
+	}{
+		{"above threshold", readerSource("3000\n"), 256, 3000, true, false},
+		{"at threshold", readerSource("256\n"), 256, 256, true, false},
+		{"below threshold", readerSource("40\n"), 256, 40, false, false},
+		{"unreadable", readerSource("garbage"), 256, 0, false, true},
+		{"platform without interface", unavailable, 256, -1, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avail, ok, err := certificate.CheckEntropy(tt.src, tt.min)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckEntropy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if avail != tt.wantAvail || ok != tt.wantOK {
+				t.Errorf("CheckEntropy() = %d, %v, want %d, %v", avail, ok, tt.wantAvail, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWaitForEntropy(t *testing.T) {
+	avail, err := certificate.WaitForEntropy(context.Background(), readerSource("10", "100", "300"), 256, time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForEntropy failed: %v", err)
+	}
+	if avail != 300 {
+		t.Errorf("WaitForEntropy() = %d, want 300", avail)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = certificate.WaitForEntropy(ctx, readerSource("10"), 256, time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForEntropy() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestKernelPoolSize(t *testing.T) {
+	pool, err := certificate.KernelPoolSize()
+	if errors.Is(err, certificate.ErrEntropyUnavailable) {
+		t.Skip("no kernel entropy estimate on this platform")
+	}
+	if err != nil {
+		t.Fatalf("KernelPoolSize failed: %v", err)
+	}
+	if pool <= 0 {
+		t.Errorf("KernelPoolSize() = %d, want a positive size", pool)
+	}
+	if avail, err := certificate.KernelEntropy(); err == nil && avail > pool {
+		t.Errorf("entropy estimate %d exceeds pool size %d", avail, pool)
+	}
+}
@@ -0,0 +1,72 @@
+package certificate_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+)
+
+func certWithValidity(t *testing.T, key *rsa.PrivateKey, notBefore, notAfter time.Time) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "expiry.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestCheckExpiry(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	now := time.Now()
+	within := 30 * 24 * time.Hour
+
+	tests := []struct {
+		name         string
+		notBefore    time.Time
+		notAfter     time.Time
+		wantExpiring bool
+		wantErr      bool
+	}{
+		{"expired", now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), true, true},
+		{"expiring soon", now.Add(-time.Hour), now.Add(10 * 24 * time.Hour), true, false},
+		{"healthy", now.Add(-time.Hour), now.Add(365 * 24 * time.Hour), false, false},
+		{"not yet valid", now.Add(24 * time.Hour), now.Add(365 * 24 * time.Hour), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := certWithValidity(t, key, tt.notBefore, tt.notAfter)
+
+			expiring, err := certificate.CheckExpiry(cert, within)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckExpiry error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if expiring != tt.wantExpiring {
+				t.Errorf("CheckExpiry expiring = %v, want %v", expiring, tt.wantExpiring)
+			}
+		})
+	}
+
+	if _, err := certificate.CheckExpiry(nil, within); err == nil {
+		t.Error("CheckExpiry should fail with nil certificate")
+	}
+}
@@ -0,0 +1,284 @@
+package certificate_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+var (
+	oidKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+)
+
+func extensionCritical(t *testing.T, cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	t.Helper()
+	found := false
+	critical := false
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			if found {
+				t.Fatalf("extension %v appears more than once", oid)
+			}
+			found, critical = true, ext.Critical
+		}
+	}
+	if !found {
+		t.Fatalf("extension %v not found", oid)
+	}
+	return critical
+}
+
+func TestGenerator_ExtensionCriticality(t *testing.T) {
+	tests := []struct {
+		name        string
+		nonCritical bool
+	}{
+		{"default", false},
+		{"non-critical", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "critical.test.com"
+			cfg.KeySize = 2048
+			cfg.NonCriticalBasicConstraints = tt.nonCritical
+			cfg.NonCriticalKeyUsage = tt.nonCritical
+
+			gen := certificate.NewGenerator(cfg)
+			rootCert, rootKey, err := gen.GenerateRootCA()
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+
+			if got := extensionCritical(t, rootCert, oidBasicConstraints); got == tt.nonCritical {
+				t.Errorf("root BasicConstraints critical = %v, want %v", got, !tt.nonCritical)
+			}
+			if got := extensionCritical(t, rootCert, oidKeyUsage); got == tt.nonCritical {
+				t.Errorf("root KeyUsage critical = %v, want %v", got, !tt.nonCritical)
+			}
+			if !rootCert.IsCA || !rootCert.BasicConstraintsValid {
+				t.Error("root certificate lost its CA basic constraints")
+			}
+			if rootCert.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+				t.Errorf("root KeyUsage = %v, want %v", rootCert.KeyUsage, x509.KeyUsageCertSign|x509.KeyUsageCRLSign)
+			}
+
+			leafCert, _, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+			if got := extensionCritical(t, leafCert, oidKeyUsage); got == tt.nonCritical {
+				t.Errorf("leaf KeyUsage critical = %v, want %v", got, !tt.nonCritical)
+			}
+			want := x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+			if leafCert.KeyUsage != want {
+				t.Errorf("leaf KeyUsage = %v, want %v", leafCert.KeyUsage, want)
+			}
+
+			pool := x509.NewCertPool()
+			pool.AddCert(rootCert)
+			if _, err := leafCert.Verify(x509.VerifyOptions{Roots: pool, DNSName: cfg.Domain}); err != nil {
+				t.Errorf("leaf does not verify against root: %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerator_CustomExtensions(t *testing.T) {
+	policy, err := config.ParseExtension("1.3.6.1.4.1.99999.1:DAVpbnRyYQ==")
+	if err != nil {
+		t.Fatalf("ParseExtension failed: %v", err)
+	}
+	marker, err := config.ParseExtension("1.3.6.1.4.1.99999.2:BQA=:critical")
+	if err != nil {
+		t.Fatalf("ParseExtension failed: %v", err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "custom.test.com"
+	cfg.KeySize = 2048
+	cfg.Extensions = []config.Extension{policy, marker}
+
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	leafCert, _, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	parsed, err := x509.ParseCertificate(leafCert.Raw)
+	if err != nil {
+		t.Fatalf("ParseCertificate failed: %v", err)
+	}
+	for _, want := range cfg.Extensions {
+		found := false
+		for _, ext := range parsed.Extensions {
+			if !ext.Id.Equal(want.OID) {
+				continue
+			}
+			found = true
+			if ext.Critical != want.Critical {
+				t.Errorf("extension %v critical = %v, want %v", want.OID, ext.Critical, want.Critical)
+			}
+			if string(ext.Value) != string(want.Value) {
+				t.Errorf("extension %v value = %x, want %x", want.OID, ext.Value, want.Value)
+			}
+		}
+		if !found {
+			t.Errorf("extension %v missing from leaf", want.OID)
+		}
+	}
+
+	for _, ext := range rootCert.Extensions {
+		if ext.Id.Equal(policy.OID) {
+			t.Error("custom extension should only be added to the leaf")
+		}
+	}
+}
+
+func TestGenerator_PolicyOIDs(t *testing.T) {
+	cps, err := config.ParseOID("1.3.6.1.4.1.99999.10.1")
+	if err != nil {
+		t.Fatalf("ParseOID failed: %v", err)
+	}
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "policy.test.com"
+	cfg.KeySize = 2048
+	cfg.PolicyOIDs = []asn1.ObjectIdentifier{cps}
+
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	leafCert, _, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	for name, cert := range map[string]*x509.Certificate{"root": rootCert, "leaf": leafCert} {
+		parsed, err := x509.ParseCertificate(cert.Raw)
+		if err != nil {
+			t.Fatalf("ParseCertificate failed: %v", err)
+		}
+		if len(parsed.PolicyIdentifiers) != 1 || !parsed.PolicyIdentifiers[0].Equal(cps) {
+			t.Errorf("%s PolicyIdentifiers = %v, want [%v]", name, parsed.PolicyIdentifiers, cps)
+		}
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(rootCert)
+	if _, err := leafCert.Verify(x509.VerifyOptions{Roots: pool, DNSName: cfg.Domain}); err != nil {
+		t.Errorf("leaf with policies does not verify: %v", err)
+	}
+}
+
+func TestGenerator_Precert(t *testing.T) {
+	for _, precert := range []bool{false, true} {
+		cfg := config.NewCertificateConfig()
+		cfg.Domain = "precert.test.com"
+		cfg.KeySize = 2048
+		cfg.Precert = precert
+
+		gen := certificate.NewGenerator(cfg)
+		caCert, caKey, err := gen.GenerateRootCA()
+		if err != nil {
+			t.Fatalf("GenerateRootCA failed: %v", err)
+		}
+		leaf, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+		if err != nil {
+			t.Fatalf("GenerateLeafCertificate failed: %v", err)
+		}
+
+		var poison []pkix.Extension
+		for _, ext := range leaf.Extensions {
+			if ext.Id.Equal(certificate.OIDExtensionCTPoison) {
+				poison = append(poison, ext)
+			}
+		}
+		if !precert {
+			if len(poison) != 0 {
+				t.Error("poison extension present without Precert")
+			}
+			continue
+		}
+		if len(poison) != 1 {
+			t.Fatalf("leaf has %d poison extensions, want 1", len(poison))
+		}
+		if !poison[0].Critical {
+			t.Error("poison extension is not critical")
+		}
+		if !bytes.Equal(poison[0].Value, asn1.NullBytes) {
+			t.Errorf("poison extension value = %x, want ASN.1 NULL", poison[0].Value)
+		}
+
+		for _, ext := range caCert.Extensions {
+			if ext.Id.Equal(certificate.OIDExtensionCTPoison) {
+				t.Error("root CA carries the poison extension")
+			}
+		}
+	}
+}
+
+func TestGenerator_NoCommonNameCriticalSAN(t *testing.T) {
+	oidSubjectAltName := asn1.ObjectIdentifier{2, 5, 29, 17}
+
+	for _, noCN := range []bool{false, true} {
+		cfg := config.NewCertificateConfig()
+		cfg.Domain = "nocn.test.com"
+		cfg.DNSNames = []string{"alt.test.com"}
+		cfg.IPAddresses = []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("2001:db8::1")}
+		cfg.KeySize = 2048
+		cfg.NoCommonName = noCN
+
+		gen := certificate.NewGenerator(cfg)
+		caCert, caKey, err := gen.GenerateRootCA()
+		if err != nil {
+			t.Fatalf("GenerateRootCA failed: %v", err)
+		}
+		leaf, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+		if err != nil {
+			t.Fatalf("GenerateLeafCertificate failed: %v", err)
+		}
+
+		if got := extensionCritical(t, leaf, oidSubjectAltName); got != noCN {
+			t.Errorf("NoCommonName=%v: SAN critical = %v, want %v", noCN, got, noCN)
+		}
+		if noCN && leaf.Subject.CommonName != "" {
+			t.Errorf("leaf Common Name = %q, want none", leaf.Subject.CommonName)
+		}
+		if caCert.Subject.CommonName != "nocn.test.com" {
+			t.Errorf("root Common Name = %q, want nocn.test.com", caCert.Subject.CommonName)
+		}
+
+		// The hand-built extension must carry the same names Go would encode
+		wantDNS := []string{"nocn.test.com", "alt.test.com"}
+		if !reflect.DeepEqual(leaf.DNSNames, wantDNS) {
+			t.Errorf("DNSNames = %v, want %v", leaf.DNSNames, wantDNS)
+		}
+		if len(leaf.IPAddresses) != 2 || !leaf.IPAddresses[0].Equal(cfg.IPAddresses[0]) || !leaf.IPAddresses[1].Equal(cfg.IPAddresses[1]) {
+			t.Errorf("IPAddresses = %v, want %v", leaf.IPAddresses, cfg.IPAddresses)
+		}
+		if _, err := leaf.Verify(x509.VerifyOptions{DNSName: "alt.test.com", Roots: rootPool(caCert)}); err != nil {
+			t.Errorf("Verify failed: %v", err)
+		}
+	}
+}
+
+func rootPool(cert *x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
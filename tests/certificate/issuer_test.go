@@ -0,0 +1,79 @@
+package certificate_test
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestSelectIssuer(t *testing.T) {
+	rootCfg := config.NewCertificateConfig()
+	rootCfg.Domain = "root.example.com"
+	rootCfg.KeySize = 2048
+	rootCert, rootKey, err := certificate.NewGenerator(rootCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	intCfg := config.NewCertificateConfig()
+	intCfg.Domain = "intermediate.example.com"
+	intCfg.KeySize = 2048
+	intCfg.Profile = config.ProfileCA
+	intCert, intKey, err := certificate.NewGenerator(intCfg).GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("Failed to generate intermediate: %v", err)
+	}
+
+	leafCfg := config.NewCertificateConfig()
+	leafCfg.Domain = "leaf.example.com"
+	leafCfg.KeySize = 2048
+	leafCert, leafKey, err := certificate.NewGenerator(leafCfg).GenerateLeafCertificate(intCert, intKey)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf: %v", err)
+	}
+
+	_, otherKey, err := certificate.NewGenerator(rootCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	bundle := []*x509.Certificate{intCert, rootCert}
+
+	tests := []struct {
+		name    string
+		certs   []*x509.Certificate
+		key     crypto.Signer
+		want    *x509.Certificate
+		wantErr bool
+	}{
+		{"intermediate key", bundle, intKey, intCert, false},
+		{"root key", bundle, rootKey, rootCert, false},
+		{"single certificate", []*x509.Certificate{rootCert}, rootKey, rootCert, false},
+		{"key matches no certificate", bundle, otherKey, nil, true},
+		{"key matches a non-CA certificate", []*x509.Certificate{leafCert, intCert}, leafKey, nil, true},
+		{"empty bundle", nil, rootKey, nil, true},
+		{"nil key", bundle, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := certificate.SelectIssuer(tt.certs, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectIssuer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SelectIssuer() = %v, want %v", subjectCN(got), subjectCN(tt.want))
+			}
+		})
+	}
+}
+
+func subjectCN(cert *x509.Certificate) string {
+	if cert == nil {
+		return "<nil>"
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,72 @@
+package certificate_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func TestGenerateLeafCertificate_AuthorityKeyIdFromLoadedCA(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "keyid.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate root CA: %v", err)
+	}
+
+	// Round-trip the CA through PEM as the load-existing-CA path does.
+	certPEM, err := encoding.EncodeCertificateToPEM(rootCert)
+	if err != nil {
+		t.Fatalf("Failed to encode CA certificate: %v", err)
+	}
+	keyPEM, err := encoding.EncodePrivateKeyToPEM(rootKey)
+	if err != nil {
+		t.Fatalf("Failed to encode CA key: %v", err)
+	}
+	caCert, err := encoding.DecodePEMCertificate(certPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode CA certificate: %v", err)
+	}
+	caKey, err := encoding.DecodePEMPrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode CA key: %v", err)
+	}
+
+	if len(caCert.SubjectKeyId) == 0 {
+		t.Fatal("CA certificate has no SubjectKeyId")
+	}
+
+	leafCert, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+	if !bytes.Equal(leafCert.AuthorityKeyId, caCert.SubjectKeyId) {
+		t.Errorf("Leaf AuthorityKeyId = %x, want CA SubjectKeyId %x", leafCert.AuthorityKeyId, caCert.SubjectKeyId)
+	}
+	if len(leafCert.SubjectKeyId) == 0 {
+		t.Error("Leaf certificate has no SubjectKeyId")
+	}
+
+	key, err := gen.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	csr, err := gen.GenerateCertificateRequest(key)
+	if err != nil {
+		t.Fatalf("Failed to generate CSR: %v", err)
+	}
+	signed, err := certificate.SignCSR(csr, caCert, caKey, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+	if !bytes.Equal(signed.AuthorityKeyId, caCert.SubjectKeyId) {
+		t.Errorf("Signed AuthorityKeyId = %x, want CA SubjectKeyId %x", signed.AuthorityKeyId, caCert.SubjectKeyId)
+	}
+}
@@ -0,0 +1,65 @@
+package certificate_test
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/ocsp"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestGenerateOCSPResponse(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "ocsp.example.com"
+	cfg.KeySize = 2048
+
+	gen := certificate.NewGenerator(cfg)
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	leafCert, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf certificate: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"good", ocsp.Good},
+		{"revoked", ocsp.Revoked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			respDER, err := certificate.GenerateOCSPResponse(leafCert, caCert, caKey, tt.status)
+			if err != nil {
+				t.Fatalf("GenerateOCSPResponse failed: %v", err)
+			}
+
+			// ParseResponseForCert verifies the signature against the issuer
+			resp, err := ocsp.ParseResponseForCert(respDER, leafCert, caCert)
+			if err != nil {
+				t.Fatalf("Failed to parse OCSP response: %v", err)
+			}
+
+			if resp.Status != tt.status {
+				t.Errorf("OCSP status = %d, want %d", resp.Status, tt.status)
+			}
+			if resp.SerialNumber.Cmp(leafCert.SerialNumber) != 0 {
+				t.Errorf("OCSP serial = %v, want %v", resp.SerialNumber, leafCert.SerialNumber)
+			}
+			if tt.status == ocsp.Revoked && resp.RevokedAt.IsZero() {
+				t.Error("Revoked OCSP response has no revocation time")
+			}
+		})
+	}
+}
+
+func TestGenerateOCSPResponse_InvalidInput(t *testing.T) {
+	if _, err := certificate.GenerateOCSPResponse(nil, nil, nil, ocsp.Good); err == nil {
+		t.Error("GenerateOCSPResponse should fail with nil inputs")
+	}
+}
@@ -0,0 +1,224 @@
+package certificate_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func TestRenewCertificate(t *testing.T) {
+	caCfg := config.NewCertificateConfig()
+	caCfg.Domain = "ca.example.com"
+	caCfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(caCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://example.com/ns/default/sa/api")
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: "api.example.com", Organization: []string{"Example Org"}, OrganizationalUnit: []string{"Platform"}},
+		DNSNames:       []string{"api.example.com", "api.internal"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1").To4(), net.ParseIP("2001:db8::1")},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"ops@example.com"},
+	}, oldKey)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+	old, err := certificate.SignCSR(csr, caCert, caKey, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("SignCSR failed: %v", err)
+	}
+
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	validity := 90 * 24 * time.Hour
+	renewed, err := certificate.RenewCertificate(old, &newKey.PublicKey, caCert, caKey, validity)
+	if err != nil {
+		t.Fatalf("RenewCertificate failed: %v", err)
+	}
+
+	if !bytes.Equal(renewed.RawSubject, old.RawSubject) {
+		t.Errorf("Subject = %v, want %v", renewed.Subject, old.Subject)
+	}
+	if !reflect.DeepEqual(renewed.DNSNames, old.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", renewed.DNSNames, old.DNSNames)
+	}
+	if !reflect.DeepEqual(renewed.IPAddresses, old.IPAddresses) {
+		t.Errorf("IPAddresses = %v, want %v", renewed.IPAddresses, old.IPAddresses)
+	}
+	if !reflect.DeepEqual(renewed.URIs, old.URIs) {
+		t.Errorf("URIs = %v, want %v", renewed.URIs, old.URIs)
+	}
+	if !reflect.DeepEqual(renewed.EmailAddresses, old.EmailAddresses) {
+		t.Errorf("EmailAddresses = %v, want %v", renewed.EmailAddresses, old.EmailAddresses)
+	}
+	if !reflect.DeepEqual(renewed.ExtKeyUsage, old.ExtKeyUsage) || renewed.KeyUsage != old.KeyUsage {
+		t.Errorf("key usages = %v/%v, want %v/%v", renewed.KeyUsage, renewed.ExtKeyUsage, old.KeyUsage, old.ExtKeyUsage)
+	}
+
+	if renewed.SerialNumber.Cmp(old.SerialNumber) == 0 {
+		t.Error("Renewed certificate reuses the old serial number")
+	}
+	if renewed.NotAfter.Equal(old.NotAfter) {
+		t.Error("Renewed certificate has the old expiry")
+	}
+	if renewed.NotAfter.Sub(renewed.NotBefore) != validity {
+		t.Errorf("Certificate validity = %v, want %v", renewed.NotAfter.Sub(renewed.NotBefore), validity)
+	}
+	if !newKey.PublicKey.Equal(renewed.PublicKey) {
+		t.Error("Renewed certificate is not for the new key")
+	}
+	if err := renewed.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("Renewed certificate not signed by CA: %v", err)
+	}
+}
+
+func TestRenewCertificate_KeepsExtensions(t *testing.T) {
+	oidSubjectAltName := asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidCustom := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+	policy := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "renew-ext.example.com"
+	cfg.KeySize = 2048
+	cfg.NoCommonName = true
+	cfg.Precert = true
+	cfg.PolicyOIDs = []asn1.ObjectIdentifier{policy}
+	cfg.Extensions = []config.Extension{{OID: oidCustom, Value: []byte{0x05, 0x00}, Critical: false}}
+	gen := certificate.NewGenerator(cfg)
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	old, oldKey, err := gen.GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf: %v", err)
+	}
+
+	renewed, err := certificate.RenewCertificate(old, &oldKey.PublicKey, caCert, caKey, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("RenewCertificate failed: %v", err)
+	}
+
+	if !extensionCritical(t, renewed, oidSubjectAltName) {
+		t.Error("renewed SAN is not critical for a leaf without a Common Name")
+	}
+	if !extensionCritical(t, renewed, certificate.OIDExtensionCTPoison) {
+		t.Error("renewed certificate lost the critical poison extension")
+	}
+	if extensionCritical(t, renewed, oidCustom) {
+		t.Error("renewed custom extension became critical")
+	}
+	if !reflect.DeepEqual(renewed.PolicyIdentifiers, old.PolicyIdentifiers) {
+		t.Errorf("PolicyIdentifiers = %v, want %v", renewed.PolicyIdentifiers, old.PolicyIdentifiers)
+	}
+	if !bytes.Equal(renewed.SubjectKeyId, old.SubjectKeyId) || !bytes.Equal(renewed.AuthorityKeyId, caCert.SubjectKeyId) {
+		t.Errorf("key identifiers = %x/%x, want %x/%x", renewed.SubjectKeyId, renewed.AuthorityKeyId, old.SubjectKeyId, caCert.SubjectKeyId)
+	}
+	if err := renewed.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("Renewed certificate not signed by CA: %v", err)
+	}
+}
+
+func TestRenewCertificate_RejectsCA(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "ca.example.com"
+	cfg.KeySize = 2048
+	caCert, caKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+
+	if _, err := certificate.RenewCertificate(caCert, &caKey.PublicKey, caCert, caKey, 24*time.Hour); err == nil {
+		t.Error("expected an error renewing a CA certificate")
+	}
+}
+
+func TestRenewCertificate_RejectsWeakKey(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "weak.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+	caCert, caKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %v", err)
+	}
+	old, _, err := gen.GenerateLeafCertificate(caCert, caKey)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf: %v", err)
+	}
+
+	weak := &rsa.PublicKey{N: new(big.Int).SetBit(big.NewInt(1), 511, 1), E: 65537}
+	if _, err := certificate.RenewCertificate(old, weak, caCert, caKey, 24*time.Hour); err == nil {
+		t.Error("expected an error renewing for a 512-bit RSA key")
+	}
+}
+
+func TestGenerateKeyLike(t *testing.T) {
+	rsaKey, err := certificate.NewGenerator(&config.CertificateConfig{KeySize: 2048}).GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pub  crypto.PublicKey
+		want string
+	}{
+		{"RSA", &rsaKey.PublicKey, "RSA 2048"},
+		{"ECDSA", &ecKey.PublicKey, "ECDSA P-384"},
+		{"Ed25519", edPub, "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := certificate.GenerateKeyLike(tt.pub)
+			if err != nil {
+				t.Fatalf("GenerateKeyLike failed: %v", err)
+			}
+			if got := encoding.KeyTypeName(key.Public()); got != tt.want {
+				t.Errorf("key type = %s, want %s", got, tt.want)
+			}
+			if k, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || k.Equal(tt.pub) {
+				t.Error("GenerateKeyLike returned the original key, want a new one")
+			}
+		})
+	}
+}
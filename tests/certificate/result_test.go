@@ -0,0 +1,57 @@
+package certificate_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestGenerator_ResultOptions(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "audit.test.com"
+	cfg.DNSNames = []string{"www.audit.test.com"}
+	cfg.KeySize = 2048
+	cfg.ValidityDays = 90
+	cfg.Profile = config.ProfileServer
+	cfg.NotBefore = &notBefore
+
+	gen := certificate.NewGenerator(cfg)
+	root, err := gen.GenerateRootCAResult()
+	if err != nil {
+		t.Fatalf("GenerateRootCAResult failed: %v", err)
+	}
+	if !reflect.DeepEqual(root.Options, cfg.GetRootCAOptions()) {
+		t.Errorf("root Options = %+v, want %+v", root.Options, cfg.GetRootCAOptions())
+	}
+	if !root.Certificate.IsCA || len(root.DER) == 0 || root.PrivateKey == nil {
+		t.Error("root Result is incomplete")
+	}
+
+	leaf, err := gen.GenerateLeafCertificateResult(root.Certificate, root.PrivateKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificateResult failed: %v", err)
+	}
+	want := cfg.GetLeafCertOptions()
+	if !reflect.DeepEqual(leaf.Options, want) {
+		t.Errorf("leaf Options = %+v, want %+v", leaf.Options, want)
+	}
+
+	if !leaf.Certificate.NotBefore.Equal(leaf.Options.ValidFrom) {
+		t.Errorf("NotBefore = %v, want %v", leaf.Certificate.NotBefore, leaf.Options.ValidFrom)
+	}
+	if got := leaf.Certificate.NotAfter.Sub(leaf.Certificate.NotBefore); got != leaf.Options.ValidFor {
+		t.Errorf("validity = %v, want %v", got, leaf.Options.ValidFor)
+	}
+	if !reflect.DeepEqual(leaf.Certificate.DNSNames, leaf.Options.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", leaf.Certificate.DNSNames, leaf.Options.DNSNames)
+	}
+
+	if _, err := json.Marshal(leaf.Options); err != nil {
+		t.Errorf("Options are not serializable: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package certificate_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+// opaqueSigner hides the concrete key type, as an HSM-backed signer would.
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (s opaqueSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.Sign(rand, digest, opts)
+}
+
+func TestGenerator_GenerateLeafCertificate_Signer(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "HSM Root CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "signer.example.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+
+	leafCert, _, err := gen.GenerateLeafCertificate(caCert, opaqueSigner{caKey})
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+	if err := leafCert.CheckSignatureFrom(caCert); err != nil {
+		t.Errorf("Leaf certificate signature verification failed: %v", err)
+	}
+	if leafCert.SignatureAlgorithm != x509.ECDSAWithSHA256 {
+		t.Errorf("SignatureAlgorithm = %v, want %v", leafCert.SignatureAlgorithm, x509.ECDSAWithSHA256)
+	}
+}
@@ -0,0 +1,100 @@
+package certificate_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestGenerator_SubDayValidity(t *testing.T) {
+	frozen := time.Date(2030, 6, 15, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		validity time.Duration
+		days     int
+		want     time.Time
+	}{
+		{"one hour", time.Hour, 3650, frozen.Add(time.Hour)},
+		{"thirty minutes", 30 * time.Minute, 3650, frozen.Add(30 * time.Minute)},
+		{"days when unset", 0, 2, frozen.Add(48 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "short.test.com"
+			cfg.KeySize = 2048
+			cfg.ValidityDays = tt.days
+			cfg.Validity = tt.validity
+			cfg.SetClock(func() time.Time { return frozen })
+
+			gen := certificate.NewGenerator(cfg)
+			root, rootKey, err := gen.GenerateRootCA()
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+			leaf, _, err := gen.GenerateLeafCertificate(root, rootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+
+			if !leaf.NotBefore.Equal(frozen) {
+				t.Errorf("NotBefore = %v, want %v", leaf.NotBefore, frozen)
+			}
+			if !leaf.NotAfter.Equal(tt.want) {
+				t.Errorf("NotAfter = %v, want %v", leaf.NotAfter, tt.want)
+			}
+			if !root.NotAfter.Equal(frozen.Add(1024 * 24 * time.Hour)) {
+				t.Errorf("root NotAfter = %v, want the usual 1024 days", root.NotAfter)
+			}
+		})
+	}
+}
+
+func TestGenerator_ClampToCA(t *testing.T) {
+	frozen := time.Date(2030, 6, 15, 8, 30, 0, 0, time.UTC)
+	rootExpiry := frozen.Add(1024 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		days     int
+		clamp    bool
+		outlives bool
+		want     time.Time
+	}{
+		{"outlives CA", 3650, false, true, frozen.Add(3650 * 24 * time.Hour)},
+		{"clamped to CA", 3650, true, true, rootExpiry},
+		{"within CA", 365, true, false, frozen.Add(365 * 24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "clamp.test.com"
+			cfg.KeySize = 2048
+			cfg.ValidityDays = tt.days
+			cfg.ClampToCA = tt.clamp
+			cfg.SetClock(func() time.Time { return frozen })
+
+			gen := certificate.NewGenerator(cfg)
+			root, rootKey, err := gen.GenerateRootCA()
+			if err != nil {
+				t.Fatalf("GenerateRootCA failed: %v", err)
+			}
+			if got := gen.LeafOutlivesCA(root); got != tt.outlives {
+				t.Errorf("LeafOutlivesCA() = %v, want %v", got, tt.outlives)
+			}
+
+			leaf, _, err := gen.GenerateLeafCertificate(root, rootKey)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificate failed: %v", err)
+			}
+			if !leaf.NotAfter.Equal(tt.want) {
+				t.Errorf("NotAfter = %v, want %v", leaf.NotAfter, tt.want)
+			}
+		})
+	}
+}
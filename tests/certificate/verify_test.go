@@ -0,0 +1,72 @@
+package certificate_test
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestVerifyLeaf(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "verify.test.com"
+	cfg.KeySize = 2048
+	cfg.Profile = config.ProfileServer
+
+	gen := certificate.NewGenerator(cfg)
+	root, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+	leaf, _, err := gen.GenerateLeafCertificate(root, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	otherCfg := config.NewCertificateConfig()
+	otherCfg.Domain = "other.test.com"
+	otherCfg.KeySize = 2048
+	otherRoot, _, err := certificate.NewGenerator(otherCfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	past := time.Now().Add(-48 * time.Hour)
+	expiredCfg := *cfg
+	expiredCfg.ValidityDays = 1
+	expiredCfg.NotBefore = &past
+	expired, _, err := certificate.NewGenerator(&expiredCfg).GenerateLeafCertificate(root, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		leaf    *x509.Certificate
+		root    *x509.Certificate
+		dnsName string
+		usages  []string
+		wantErr bool
+	}{
+		{"valid", leaf, root, "", nil, false},
+		{"valid for host", leaf, root, "verify.test.com", nil, false},
+		{"valid for usage", leaf, root, "verify.test.com", []string{"serverAuth"}, false},
+		{"wrong root", leaf, otherRoot, "", nil, true},
+		{"wrong host", leaf, root, "other.test.com", nil, true},
+		{"missing usage", leaf, root, "", []string{"serverAuth", "clientAuth"}, true},
+		{"unknown usage", leaf, root, "", []string{"bogus"}, true},
+		{"expired", expired, root, "", nil, true},
+		{"missing root", leaf, nil, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := certificate.VerifyLeaf(tt.leaf, tt.root, tt.dnsName, tt.usages)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyLeaf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package certificate_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/certificate"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func TestGenerator_GenerateLeafCertificateWithKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "external.test.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pub  crypto.PublicKey
+	}{
+		{"ECDSA", &ecKey.PublicKey},
+		{"Ed25519", edPub},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert, err := gen.GenerateLeafCertificateWithKey(rootCert, rootKey, tt.pub)
+			if err != nil {
+				t.Fatalf("GenerateLeafCertificateWithKey failed: %v", err)
+			}
+
+			pub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+			if !ok || !pub.Equal(tt.pub) {
+				t.Errorf("certificate public key does not match the supplied key")
+			}
+			if err := cert.CheckSignatureFrom(rootCert); err != nil {
+				t.Errorf("leaf not signed by root: %v", err)
+			}
+			if cert.Subject.CommonName != cfg.Domain {
+				t.Errorf("CommonName = %q, want %q", cert.Subject.CommonName, cfg.Domain)
+			}
+		})
+	}
+}
+
+func TestGenerator_GenerateLeafCertificateWithKey_Unsupported(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "external.test.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	if _, err := gen.GenerateLeafCertificateWithKey(rootCert, rootKey, "not a key"); err == nil {
+		t.Error("GenerateLeafCertificateWithKey should reject an unsupported key type")
+	}
+}
+
+func TestGenerator_GenerateLeafCertificateWithKey_WeakRSA(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "external.test.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	// A 512-bit modulus; Go refuses to generate one, but a key file can hold it
+	weak := &rsa.PublicKey{N: new(big.Int).SetBit(big.NewInt(1), 511, 1), E: 65537}
+	if _, err := gen.GenerateLeafCertificateWithKey(rootCert, rootKey, weak); err == nil {
+		t.Error("GenerateLeafCertificateWithKey should reject a 512-bit RSA key")
+	}
+}
+
+func TestGenerator_GenerateLeafCertificateForKey(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "rotate.test.com"
+	cfg.KeySize = 2048
+	gen := certificate.NewGenerator(cfg)
+	rootCert, rootKey, err := gen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("GenerateRootCA failed: %v", err)
+	}
+
+	original, leafKey, err := gen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificate failed: %v", err)
+	}
+
+	// Round-trip the key through PEM as --reuse-leaf-key does
+	keyPEM, err := encoding.EncodePrivateKeyToPEM(leafKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToPEM failed: %v", err)
+	}
+	loaded, err := encoding.DecodePEMPrivateKey(keyPEM)
+	if err != nil {
+		t.Fatalf("DecodePEMPrivateKey failed: %v", err)
+	}
+
+	rotated, err := gen.GenerateLeafCertificateForKey(rootCert, rootKey, loaded)
+	if err != nil {
+		t.Fatalf("GenerateLeafCertificateForKey failed: %v", err)
+	}
+
+	if !loaded.PublicKey.Equal(rotated.PublicKey) {
+		t.Error("rotated certificate public key does not match the loaded key")
+	}
+	if rotated.SerialNumber.Cmp(original.SerialNumber) == 0 {
+		t.Error("rotated certificate reuses the original serial number")
+	}
+	if err := rotated.CheckSignatureFrom(rootCert); err != nil {
+		t.Errorf("leaf not signed by root: %v", err)
+	}
+}
@@ -1,6 +1,12 @@
 package config_test
 
 import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +34,8 @@ func TestNewCertificateConfig(t *testing.T) {
 		{"ValidityDays", cfg.ValidityDays, 3650},
 		{"KeySize", cfg.KeySize, 4096},
 		{"PKCS12Password", cfg.PKCS12Password, "yourPKCS12Password"},
+		{"Profile", cfg.Profile, config.ProfileBoth},
+		{"SerialBits", cfg.SerialBits, 128},
 	}
 
 	for _, tt := range tests {
@@ -144,7 +152,7 @@ func TestCertificateConfig_GetLeafCertOptions(t *testing.T) {
 	}
 
 	// Test Key Usage
-	expectedKeyUsage := []string{"digitalSignature", "nonRepudiation", "keyEncipherment", "dataEncipherment"}
+	expectedKeyUsage := []string{"digitalSignature", "keyEncipherment"}
 	if len(opts.KeyUsage) != len(expectedKeyUsage) {
 		t.Errorf("KeyUsage length = %d, want %d", len(opts.KeyUsage), len(expectedKeyUsage))
 	}
@@ -169,3 +177,647 @@ func TestCertificateOptions_ValidFromTime(t *testing.T) {
 		t.Errorf("ValidFrom time is not within expected range")
 	}
 }
+
+func TestExtKeyUsageForProfile(t *testing.T) {
+	tests := []struct {
+		profile  string
+		expected []string
+		wantErr  bool
+	}{
+		{config.ProfileServer, []string{"serverAuth"}, false},
+		{config.ProfileClient, []string{"clientAuth"}, false},
+		{config.ProfileBoth, []string{"serverAuth", "clientAuth"}, false},
+		{"", []string{"serverAuth", "clientAuth"}, false},
+		{"bogus", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			got, err := config.ExtKeyUsageForProfile(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtKeyUsageForProfile(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("ExtKeyUsageForProfile(%q) = %v, want %v", tt.profile, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("ExtKeyUsageForProfile(%q)[%d] = %s, want %s", tt.profile, i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_RootSubjectOverrides(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "service.example.com"
+	cfg.Organization = "Service Org"
+
+	// Without overrides root and leaf share the subject
+	rootOpts := cfg.GetRootCAOptions()
+	if rootOpts.Subject.CommonName != cfg.Domain {
+		t.Errorf("Root CommonName = %s, want %s", rootOpts.Subject.CommonName, cfg.Domain)
+	}
+	if rootOpts.Subject.Organization != cfg.Organization {
+		t.Errorf("Root Organization = %s, want %s", rootOpts.Subject.Organization, cfg.Organization)
+	}
+
+	cfg.RootCommonName = "Example Root CA"
+	cfg.RootOrganization = "Example PKI"
+
+	rootOpts = cfg.GetRootCAOptions()
+	if rootOpts.Subject.CommonName != "Example Root CA" {
+		t.Errorf("Root CommonName = %s, want Example Root CA", rootOpts.Subject.CommonName)
+	}
+	if rootOpts.Subject.Organization != "Example PKI" {
+		t.Errorf("Root Organization = %s, want Example PKI", rootOpts.Subject.Organization)
+	}
+
+	// Leaf is unaffected by root overrides
+	leafOpts := cfg.GetLeafCertOptions()
+	if leafOpts.Subject.CommonName != cfg.Domain {
+		t.Errorf("Leaf CommonName = %s, want %s", leafOpts.Subject.CommonName, cfg.Domain)
+	}
+	if leafOpts.Subject.Organization != cfg.Organization {
+		t.Errorf("Leaf Organization = %s, want %s", leafOpts.Subject.Organization, cfg.Organization)
+	}
+}
+
+func TestCertificateConfig_SubjectOverridesPerDomain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orgs.json")
+	data := `{"A.tenant.test": {"organization": "Tenant A"}, "b.tenant.test": {"organization": "Tenant B", "organizational_unit": "Ops"}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write overrides file: %v", err)
+	}
+
+	overrides, err := config.LoadSubjectOverrides(path)
+	if err != nil {
+		t.Fatalf("LoadSubjectOverrides failed: %v", err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "a.tenant.test"
+	cfg.Organization = "Global Org"
+	cfg.OrganizationalUnit = "Global Unit"
+	cfg.SubjectOverrides = overrides
+	if err := cfg.Normalize(); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	tests := []struct {
+		domain string
+		org    string
+		unit   string
+	}{
+		{"a.tenant.test", "Tenant A", "Global Unit"},
+		{"b.tenant.test", "Tenant B", "Ops"},
+		{"c.tenant.test", "Global Org", "Global Unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			subject := cfg.ForDomain(tt.domain).GetLeafCertOptions().Subject
+			if subject.Organization != tt.org {
+				t.Errorf("Organization = %q, want %q", subject.Organization, tt.org)
+			}
+			if subject.OrganizationalUnit != tt.unit {
+				t.Errorf("OrganizationalUnit = %q, want %q", subject.OrganizationalUnit, tt.unit)
+			}
+		})
+	}
+
+	if got := cfg.GetRootCAOptions().Subject.Organization; got != "Global Org" {
+		t.Errorf("root Organization = %q, want %q", got, "Global Org")
+	}
+}
+
+func TestLoadSubjectOverrides_Invalid(t *testing.T) {
+	for _, data := range []string{`not json`, `{"": {"organization": "X"}}`} {
+		path := filepath.Join(t.TempDir(), "orgs.json")
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("Failed to write overrides file: %v", err)
+		}
+		if _, err := config.LoadSubjectOverrides(path); err == nil {
+			t.Errorf("LoadSubjectOverrides(%q) succeeded, want error", data)
+		}
+	}
+}
+
+func TestCertificateConfig_CommonName(t *testing.T) {
+	tests := []struct {
+		name       string
+		commonName string
+		rootCN     string
+		caSuffix   string
+		wantLeaf   string
+		wantRoot   string
+	}{
+		{"fallback to domain", "", "", "", "svc.example.com", "svc.example.com"},
+		{"explicit CN", "My Service", "", "", "My Service", "My Service"},
+		{"root override wins", "My Service", "Example Root CA", "", "My Service", "Example Root CA"},
+		{"CA suffix", "", "", "Root CA", "svc.example.com", "svc.example.com Root CA"},
+		{"CA suffix with explicit CN", "My Service", "", "Root CA", "My Service", "My Service Root CA"},
+		{"root override ignores suffix", "", "Example Root CA", "Root CA", "svc.example.com", "Example Root CA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "svc.example.com"
+			cfg.CommonName = tt.commonName
+			cfg.RootCommonName = tt.rootCN
+			cfg.CACommonNameSuffix = tt.caSuffix
+
+			leafOpts := cfg.GetLeafCertOptions()
+			if leafOpts.Subject.CommonName != tt.wantLeaf {
+				t.Errorf("Leaf CommonName = %s, want %s", leafOpts.Subject.CommonName, tt.wantLeaf)
+			}
+			rootOpts := cfg.GetRootCAOptions()
+			if rootOpts.Subject.CommonName != tt.wantRoot {
+				t.Errorf("Root CommonName = %s, want %s", rootOpts.Subject.CommonName, tt.wantRoot)
+			}
+
+			// The DNS SAN is always driven by the domain
+			if len(leafOpts.DNSNames) != 1 || leafOpts.DNSNames[0] != cfg.Domain {
+				t.Errorf("DNSNames = %v, want [%s]", leafOpts.DNSNames, cfg.Domain)
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_Identity(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		ips      []string
+		want     string
+		wantFile string
+	}{
+		{"domain", "svc.example.com", nil, "svc.example.com", "svc.example.com"},
+		{"domain with IP", "svc.example.com", []string{"10.0.0.5"}, "svc.example.com", "svc.example.com"},
+		{"IPv4 only", "", []string{"10.0.0.5"}, "10.0.0.5", "10.0.0.5"},
+		{"IPv6 only", "", []string{"fd00::5", "10.0.0.5"}, "fd00::5", "fd00--5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = tt.domain
+			for _, ip := range tt.ips {
+				cfg.IPAddresses = append(cfg.IPAddresses, net.ParseIP(ip))
+			}
+
+			if got := cfg.Identity(); got != tt.want {
+				t.Errorf("Identity() = %q, want %q", got, tt.want)
+			}
+			if got := cfg.FileIdentity(); got != tt.wantFile {
+				t.Errorf("FileIdentity() = %q, want %q", got, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_AdditionalDNSNames(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "example.com"
+	cfg.DNSNames = []string{"www.example.com", "example.com", "", "api.example.com", "www.example.com"}
+
+	expected := []string{"example.com", "www.example.com", "api.example.com"}
+	got := cfg.GetLeafCertOptions().DNSNames
+	if len(got) != len(expected) {
+		t.Fatalf("DNSNames = %v, want %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("DNSNames[%d] = %s, want %s", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestWWWName(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+		ok     bool
+	}{
+		{"example.com", "www.example.com", true},
+		{"Example.COM.", "www.example.com", true},
+		{"example.co.uk", "www.example.co.uk", true},
+		{"www.example.com", "", false},
+		{"*.example.com", "", false},
+		{"api.example.com", "", false},
+		{"co.uk", "", false},
+		{"localhost", "", false},
+		{"192.168.1.10", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := config.WWWName(tt.domain)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("WWWName(%q) = %q, %v, want %q, %v", tt.domain, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCertificateConfig_WithWWW(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		sans    []string
+		withWWW bool
+		want    []string
+	}{
+		{"apex", "example.com", []string{"api.example.com"}, true, []string{"example.com", "www.example.com", "api.example.com"}},
+		{"disabled", "example.com", []string{"api.example.com"}, false, []string{"example.com", "api.example.com"}},
+		{"www already a SAN", "example.com", []string{"api.example.com", "www.example.com"}, true, []string{"example.com", "www.example.com", "api.example.com"}},
+		{"already www", "www.example.com", nil, true, []string{"www.example.com"}},
+		{"wildcard", "*.example.com", nil, true, []string{"*.example.com"}},
+		{"subdomain", "app.example.com", nil, true, []string{"app.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = tt.domain
+			cfg.DNSNames = tt.sans
+			cfg.WithWWW = tt.withWWW
+
+			if got := cfg.GetLeafCertOptions().DNSNames; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DNSNames = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDNSNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"single", "example.com", []string{"example.com"}},
+		{"multiple", "example.com,www.example.com,api.example.com", []string{"example.com", "www.example.com", "api.example.com"}},
+		{"whitespace", " example.com , www.example.com ", []string{"example.com", "www.example.com"}},
+		{"empty entries", "example.com,,  ,www.example.com,", []string{"example.com", "www.example.com"}},
+		{"duplicates", "example.com,www.example.com,example.com", []string{"example.com", "www.example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := config.ParseDNSNames(tt.input)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("ParseDNSNames(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("ParseDNSNames(%q)[%d] = %s, want %s", tt.input, i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseDNSNames_MergeWithSAN(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "example.com"
+	cfg.DNSNames = []string{"www.example.com"}
+	cfg.DNSNames = append(cfg.DNSNames, config.ParseDNSNames("www.example.com, api.example.com, example.com")...)
+
+	expected := []string{"example.com", "www.example.com", "api.example.com"}
+	got := cfg.GetLeafCertOptions().DNSNames
+	if len(got) != len(expected) {
+		t.Fatalf("DNSNames = %v, want %v", got, expected)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("DNSNames[%d] = %s, want %s", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestCertificateConfig_NotBefore(t *testing.T) {
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "fixed.test.com"
+	cfg.ValidityDays = 30
+	cfg.NotBefore = &fixed
+
+	rootOpts := cfg.GetRootCAOptions()
+	if !rootOpts.ValidFrom.Equal(fixed) {
+		t.Errorf("Root ValidFrom = %v, want %v", rootOpts.ValidFrom, fixed)
+	}
+
+	leafOpts := cfg.GetLeafCertOptions()
+	if !leafOpts.ValidFrom.Equal(fixed) {
+		t.Errorf("Leaf ValidFrom = %v, want %v", leafOpts.ValidFrom, fixed)
+	}
+	if want := fixed.Add(30 * 24 * time.Hour); !leafOpts.ValidFrom.Add(leafOpts.ValidFor).Equal(want) {
+		t.Errorf("Leaf validity end = %v, want %v", leafOpts.ValidFrom.Add(leafOpts.ValidFor), want)
+	}
+}
+
+func TestCertificateConfig_SetClock(t *testing.T) {
+	frozen := time.Date(2030, 6, 15, 8, 30, 0, 0, time.UTC)
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "clock.test.com"
+	cfg.ValidityDays = 7
+	cfg.SetClock(func() time.Time { return frozen })
+
+	if got := cfg.GetRootCAOptions().ValidFrom; !got.Equal(frozen) {
+		t.Errorf("Root ValidFrom = %v, want %v", got, frozen)
+	}
+	leafOpts := cfg.GetLeafCertOptions()
+	if !leafOpts.ValidFrom.Equal(frozen) {
+		t.Errorf("Leaf ValidFrom = %v, want %v", leafOpts.ValidFrom, frozen)
+	}
+
+	// An explicit NotBefore still wins over the clock.
+	fixed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg.NotBefore = &fixed
+	if got := cfg.GetLeafCertOptions().ValidFrom; !got.Equal(fixed) {
+		t.Errorf("Leaf ValidFrom with NotBefore = %v, want %v", got, fixed)
+	}
+
+	cfg.NotBefore = nil
+	cfg.SetClock(nil)
+	if got := cfg.GetLeafCertOptions().ValidFrom; time.Since(got) > time.Minute {
+		t.Errorf("Leaf ValidFrom after SetClock(nil) = %v, want about now", got)
+	}
+}
+
+func TestIndexedDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		index  int
+		count  int
+		want   string
+	}{
+		{"client.example.com", 1, 100, "client-001.example.com"},
+		{"client.example.com", 42, 100, "client-042.example.com"},
+		{"client.example.com", 7, 1500, "client-0007.example.com"},
+		{"localhost", 2, 5, "localhost-002"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := config.IndexedDomain(tt.domain, tt.index, tt.count); got != tt.want {
+				t.Errorf("IndexedDomain(%q, %d, %d) = %q, want %q", tt.domain, tt.index, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_ProfileSelection(t *testing.T) {
+	tests := []struct {
+		profile     string
+		extKeyUsage []string
+		isCA        bool
+	}{
+		{config.ProfileWebServer, []string{"serverAuth"}, false},
+		{config.ProfileCodeSigning, []string{"codeSigning"}, false},
+		{config.ProfileCA, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "profile.test.com"
+			cfg.Profile = tt.profile
+
+			opts := cfg.GetLeafCertOptions()
+			if len(opts.ExtKeyUsage) != len(tt.extKeyUsage) {
+				t.Fatalf("ExtKeyUsage = %v, want %v", opts.ExtKeyUsage, tt.extKeyUsage)
+			}
+			for i := range opts.ExtKeyUsage {
+				if opts.ExtKeyUsage[i] != tt.extKeyUsage[i] {
+					t.Errorf("ExtKeyUsage[%d] = %s, want %s", i, opts.ExtKeyUsage[i], tt.extKeyUsage[i])
+				}
+			}
+			if opts.IsCA != tt.isCA {
+				t.Errorf("IsCA = %v, want %v", opts.IsCA, tt.isCA)
+			}
+		})
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	data := `{"mail": {"key_usage": ["digitalSignature"], "ext_key_usage": ["emailProtection"], "validity_days": 30}}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write profiles file: %v", err)
+	}
+	defer delete(config.Profiles, "mail")
+
+	if err := config.LoadProfiles(path); err != nil {
+		t.Fatalf("LoadProfiles failed: %v", err)
+	}
+
+	profile, err := config.LookupProfile("mail")
+	if err != nil {
+		t.Fatalf("LookupProfile failed: %v", err)
+	}
+	if profile.ValidityDays != 30 {
+		t.Errorf("ValidityDays = %d, want 30", profile.ValidityDays)
+	}
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "mail.test.com"
+	cfg.Profile = "mail"
+	opts := cfg.GetLeafCertOptions()
+	if len(opts.ExtKeyUsage) != 1 || opts.ExtKeyUsage[0] != "emailProtection" {
+		t.Errorf("ExtKeyUsage = %v, want [emailProtection]", opts.ExtKeyUsage)
+	}
+}
+
+func TestLoadProfiles_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"malformed JSON", `{"mail": `},
+		{"negative validity", `{"mail": {"validity_days": -1}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profiles.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("Failed to write profiles file: %v", err)
+			}
+			if err := config.LoadProfiles(path); err == nil {
+				delete(config.Profiles, "mail")
+				t.Error("LoadProfiles should fail")
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_Redacted(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "redact.test.com"
+	cfg.PKCS12Password = "s3cret-p12"
+
+	out, err := json.Marshal(cfg.Redacted())
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if strings.Contains(string(out), "s3cret-p12") {
+		t.Errorf("Redacted JSON leaks the PKCS#12 password: %s", out)
+	}
+	if !strings.Contains(string(out), config.RedactedPassword) {
+		t.Errorf("Redacted JSON does not contain the mask: %s", out)
+	}
+	if !strings.Contains(string(out), "redact.test.com") {
+		t.Errorf("Redacted JSON is missing the domain: %s", out)
+	}
+
+	if cfg.PKCS12Password != "s3cret-p12" {
+		t.Error("Redacted modified the original config")
+	}
+}
+
+func TestCertificateConfig_Normalize(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "München.Example.com"
+	cfg.DNSNames = []string{"WWW.Example.COM", "*.bücher.example.com", "_dmarc.example.com"}
+
+	if err := cfg.Normalize(); err != nil {
+		t.Fatalf("Normalize failed: %v", err)
+	}
+
+	want := []string{
+		"xn--mnchen-3ya.example.com",
+		"www.example.com",
+		"*.xn--bcher-kva.example.com",
+		"_dmarc.example.com",
+	}
+	got := cfg.GetLeafCertOptions().DNSNames
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DNSNames = %v, want %v", got, want)
+	}
+	if cn := cfg.GetLeafCertOptions().Subject.CommonName; cn != want[0] {
+		t.Errorf("CommonName = %q, want %q", cn, want[0])
+	}
+}
+
+func TestNormalizeDNSName_Invalid(t *testing.T) {
+	if _, err := config.NormalizeDNSName("bad..-example.com"); err == nil {
+		t.Error("NormalizeDNSName should reject an invalid name")
+	}
+}
+
+func TestParseExtension(t *testing.T) {
+	tests := []struct {
+		spec     string
+		oid      string
+		value    []byte
+		critical bool
+		wantErr  bool
+	}{
+		{spec: "1.3.6.1.4.1.99999.1:BAVoZWxsbw==", oid: "1.3.6.1.4.1.99999.1", value: []byte("\x04\x05hello")},
+		{spec: "2.5.29.99:BQA=:critical", oid: "2.5.29.99", value: []byte{0x05, 0x00}, critical: true},
+		{spec: "1.3.6.1.4.1.99999.1", wantErr: true},
+		{spec: "1:BQA=", wantErr: true},
+		{spec: "1.3.x.1:BQA=", wantErr: true},
+		{spec: "1.03.1:BQA=", wantErr: true},
+		{spec: "3.1.1:BQA=", wantErr: true},
+		{spec: "1.40.1:BQA=", wantErr: true},
+		{spec: "1.3.6.1:not base64!", wantErr: true},
+		{spec: "1.3.6.1:BQA=:noncritical", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			ext, err := config.ParseExtension(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseExtension(%q) should fail", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseExtension failed: %v", err)
+			}
+			if ext.OID.String() != tt.oid {
+				t.Errorf("OID = %s, want %s", ext.OID, tt.oid)
+			}
+			if !reflect.DeepEqual(ext.Value, tt.value) {
+				t.Errorf("Value = %x, want %x", ext.Value, tt.value)
+			}
+			if ext.Critical != tt.critical {
+				t.Errorf("Critical = %v, want %v", ext.Critical, tt.critical)
+			}
+		})
+	}
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		country string
+		want    string
+		wantErr bool
+	}{
+		{country: "SG", want: "SG"},
+		{country: "us", want: "US"},
+		{country: "Gb", want: "GB"},
+		{country: "", want: ""},
+		{country: "SGP", wantErr: true},
+		{country: "S", wantErr: true},
+		{country: "S1", wantErr: true},
+		{country: "Ü1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.country, func(t *testing.T) {
+			got, err := config.NormalizeCountry(tt.country)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NormalizeCountry(%q) = %q, want error", tt.country, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeCountry(%q) failed: %v", tt.country, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeCountry(%q) = %q, want %q", tt.country, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_ExceedsBrowserValidity(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  string
+		days     int
+		validity time.Duration
+		want     bool
+	}{
+		{"398 days", config.ProfileBoth, 398, 0, false},
+		{"399 days", config.ProfileBoth, 399, 0, true},
+		{"server profile 399 days", config.ProfileServer, 399, 0, true},
+		{"client profile exempt", config.ProfileClient, 3650, 0, false},
+		{"ca profile exempt", config.ProfileCA, 3650, 0, false},
+		{"validity duration over limit", config.ProfileBoth, 1, 399 * 24 * time.Hour, true},
+		{"validity duration at limit", config.ProfileBoth, 3650, 398 * 24 * time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "validity.example.com"
+			cfg.Profile = tt.profile
+			cfg.ValidityDays = tt.days
+			cfg.Validity = tt.validity
+
+			if got := cfg.ExceedsBrowserValidity(); got != tt.want {
+				t.Errorf("ExceedsBrowserValidity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestParseSubjectDN(t *testing.T) {
+	tests := []struct {
+		name    string
+		dn      string
+		want    config.Subject
+		wantErr bool
+	}{
+		{
+			name: "all attributes",
+			dn:   "CN=svc,OU=Platform,O=Acme,L=Singapore,ST=Central,C=SG",
+			want: config.Subject{CommonName: "svc", OrganizationalUnit: "Platform", Organization: "Acme", Locality: "Singapore", State: "Central", Country: "SG"},
+		},
+		{
+			name: "spaces and lowercase types",
+			dn:   "cn = svc , o = Acme Corp ; c=US",
+			want: config.Subject{CommonName: "svc", Organization: "Acme Corp", Country: "US"},
+		},
+		{
+			name: "escaped specials",
+			dn:   `CN=svc\+api,O=Acme\, Inc.,OU=\"Ops\" \<a\=b\>`,
+			want: config.Subject{CommonName: "svc+api", Organization: "Acme, Inc.", OrganizationalUnit: `"Ops" <a=b>`},
+		},
+		{
+			name: "escaped leading and trailing spaces",
+			dn:   `CN=\ svc\ `,
+			want: config.Subject{CommonName: " svc "},
+		},
+		{
+			name: "hex escapes",
+			dn:   `CN=M\C3\BCnchen,O=a\2Cb`,
+			want: config.Subject{CommonName: "München", Organization: "a,b"},
+		},
+		{
+			name: "multi-valued RDN",
+			dn:   "CN=svc+O=Acme,C=US",
+			want: config.Subject{CommonName: "svc", Organization: "Acme", Country: "US"},
+		},
+		{
+			name: "OID attribute types",
+			dn:   "2.5.4.3=svc,2.5.4.10=Acme",
+			want: config.Subject{CommonName: "svc", Organization: "Acme"},
+		},
+		{name: "empty", dn: " ", wantErr: true},
+		{name: "missing equals", dn: "CN=svc,Acme", wantErr: true},
+		{name: "unknown attribute", dn: "CN=svc,DC=example", wantErr: true},
+		{name: "duplicate attribute", dn: "O=Acme,O=Other", wantErr: true},
+		{name: "empty value", dn: "CN=,O=Acme", wantErr: true},
+		{name: "trailing backslash", dn: `CN=svc\`, wantErr: true},
+		{name: "invalid escape", dn: `CN=s\vc`, wantErr: true},
+		{name: "invalid UTF-8", dn: `CN=\FF`, wantErr: true},
+		{name: "BER value", dn: "CN=#0403737663", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.ParseSubjectDN(tt.dn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSubjectDN(%q) error = %v, wantErr %v", tt.dn, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseSubjectDN(%q) = %+v, want %+v", tt.dn, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSubjectDN_RoundTrip(t *testing.T) {
+	// pkix.Name.String renders attributes as CN,OU,O,L,ST,C, so these inputs
+	// use that order to compare verbatim.
+	tests := []string{
+		"CN=svc,O=Acme,C=US",
+		"CN=svc,OU=Platform,O=Acme,L=Singapore,ST=Central,C=SG",
+		`CN=svc\+api,O=Acme\, Inc.`,
+		`CN=\ padded\ ,O=\#hash`,
+		"O=No Common Name",
+	}
+
+	for _, dn := range tests {
+		t.Run(dn, func(t *testing.T) {
+			subject, err := config.ParseSubjectDN(dn)
+			if err != nil {
+				t.Fatalf("ParseSubjectDN failed: %v", err)
+			}
+			if got := subject.PKIXName().String(); got != dn {
+				t.Errorf("PKIXName().String() = %q, want %q", got, dn)
+			}
+		})
+	}
+}
+
+func TestCertificateConfig_ApplySubject(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "svc.example.com"
+	cfg.OrganizationalUnit = "From Flag"
+
+	subject, err := config.ParseSubjectDN("O=Acme,C=US")
+	if err != nil {
+		t.Fatalf("ParseSubjectDN failed: %v", err)
+	}
+	cfg.ApplySubject(subject)
+
+	got := cfg.GetLeafCertOptions().Subject
+	want := config.Subject{Organization: "Acme", Country: "US", CommonName: "svc.example.com"}
+	if got != want {
+		t.Errorf("leaf Subject = %+v, want %+v", got, want)
+	}
+}
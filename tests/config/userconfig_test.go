@@ -0,0 +1,133 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+// setHome points the home and XDG config directories at fresh temp dirs.
+func setHome(t *testing.T) (home, xdg string) {
+	t.Helper()
+	home, xdg = t.TempDir(), t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	return home, xdg
+}
+
+func TestLoadUserDefaults_Absent(t *testing.T) {
+	setHome(t)
+
+	cfg := config.NewCertificateConfig()
+	path, err := config.LoadUserDefaults(cfg)
+	if err != nil {
+		t.Fatalf("LoadUserDefaults() error = %v", err)
+	}
+	if path != "" {
+		t.Errorf("path = %q, want empty when no file exists", path)
+	}
+	if cfg.Organization != "Erfi Corp" {
+		t.Errorf("Organization = %q, want the built-in default", cfg.Organization)
+	}
+}
+
+func TestLoadUserDefaults_HomeRC(t *testing.T) {
+	home, _ := setHome(t)
+	rc := filepath.Join(home, config.UserConfigName)
+	if err := os.WriteFile(rc, []byte(`{"Organization": "Acme", "Country": "US"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	path, err := config.LoadUserDefaults(cfg)
+	if err != nil {
+		t.Fatalf("LoadUserDefaults() error = %v", err)
+	}
+	if path != rc {
+		t.Errorf("path = %q, want %q", path, rc)
+	}
+	if cfg.Organization != "Acme" || cfg.Country != "US" {
+		t.Errorf("subject = %q/%q, want Acme/US", cfg.Organization, cfg.Country)
+	}
+	if cfg.State != "Singapore" || cfg.KeySize != 4096 {
+		t.Errorf("fields missing from the file changed: State = %q, KeySize = %d", cfg.State, cfg.KeySize)
+	}
+}
+
+func TestLoadUserDefaults_XDGBeforeHome(t *testing.T) {
+	home, xdg := setHome(t)
+	if err := os.WriteFile(filepath.Join(home, config.UserConfigName), []byte(`{"Organization": "Home"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(xdg, "certgen")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(`{"Organization": "XDG"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	if _, err := config.LoadUserDefaults(cfg); err != nil {
+		t.Fatalf("LoadUserDefaults() error = %v", err)
+	}
+	if cfg.Organization != "XDG" {
+		t.Errorf("Organization = %q, want the XDG file to win", cfg.Organization)
+	}
+}
+
+func TestLoadUserDefaults_ExplicitValuesWin(t *testing.T) {
+	home, _ := setHome(t)
+	if err := os.WriteFile(filepath.Join(home, config.UserConfigName), []byte(`{"Organization": "Acme", "ValidityDays": 90}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Register flags bound to cfg, load the user defaults, then parse, in the
+	// order the CLI does
+	cfg := config.NewCertificateConfig()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.StringVar(&cfg.Organization, "organization", cfg.Organization, "Organization Name")
+	fs.IntVar(&cfg.ValidityDays, "days", cfg.ValidityDays, "Validity period")
+	if _, err := config.LoadUserDefaults(cfg); err != nil {
+		t.Fatalf("LoadUserDefaults() error = %v", err)
+	}
+	if err := fs.Parse([]string{"-organization", "From Flag"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if cfg.Organization != "From Flag" {
+		t.Errorf("Organization = %q, want the explicit value", cfg.Organization)
+	}
+	if cfg.ValidityDays != 90 {
+		t.Errorf("ValidityDays = %d, want 90 from the user config", cfg.ValidityDays)
+	}
+}
+
+func TestLoadUserDefaults_OperatorCapNotSettable(t *testing.T) {
+	home, _ := setHome(t)
+	if err := os.WriteFile(filepath.Join(home, config.UserConfigName), []byte(`{"MaxValidityDays": 99999}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.NewCertificateConfig()
+	if _, err := config.LoadUserDefaults(cfg); err == nil {
+		t.Error("expected an error for a field the user config may not set")
+	}
+	if cfg.MaxValidityDays != 0 {
+		t.Errorf("MaxValidityDays = %d, want 0", cfg.MaxValidityDays)
+	}
+}
+
+func TestLoadUserDefaults_Invalid(t *testing.T) {
+	home, _ := setHome(t)
+	if err := os.WriteFile(filepath.Join(home, config.UserConfigName), []byte(`{"Organisation": "typo"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := config.LoadUserDefaults(config.NewCertificateConfig()); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,211 @@
+package config_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/config"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*config.CertificateConfig)
+		wantErr []string
+	}{
+		{
+			name:   "valid",
+			modify: func(c *config.CertificateConfig) {},
+		},
+		{
+			name:    "empty domain",
+			modify:  func(c *config.CertificateConfig) { c.Domain = "" },
+			wantErr: []string{"domain or IP address is required"},
+		},
+		{
+			name:   "IP address without domain",
+			modify: func(c *config.CertificateConfig) { c.Domain, c.IPAddresses = "", []net.IP{net.ParseIP("10.0.0.5")} },
+		},
+		{
+			name:    "common name with no common name",
+			modify:  func(c *config.CertificateConfig) { c.CommonName, c.NoCommonName = "svc", true },
+			wantErr: []string{"common name cannot be set"},
+		},
+		{
+			name:   "no common name",
+			modify: func(c *config.CertificateConfig) { c.NoCommonName = true },
+		},
+		{
+			name:    "unknown signature algorithm",
+			modify:  func(c *config.CertificateConfig) { c.SignatureAlgorithm = "MD5-RSA" },
+			wantErr: []string{"unknown signature algorithm"},
+		},
+		{
+			name:   "known signature algorithm",
+			modify: func(c *config.CertificateConfig) { c.SignatureAlgorithm = "SHA384-RSA" },
+		},
+		{
+			name:    "negative days",
+			modify:  func(c *config.CertificateConfig) { c.ValidityDays = -1 },
+			wantErr: []string{"validity days"},
+		},
+		{
+			name:   "zero days uses the profile's validity",
+			modify: func(c *config.CertificateConfig) { c.ValidityDays = 0 },
+		},
+		{
+			name: "profile validity above the maximum",
+			modify: func(c *config.CertificateConfig) {
+				c.ValidityDays = 0
+				c.Profile = config.ProfileWebServer
+				c.MaxValidityDays = 90
+			},
+			wantErr: []string{"validity of 398 days exceeds the maximum of 90 days"},
+		},
+		{
+			name:    "too many days",
+			modify:  func(c *config.CertificateConfig) { c.ValidityDays = config.MaxValidityDays + 1 },
+			wantErr: []string{"validity days"},
+		},
+		{
+			name: "above the maximum validity",
+			modify: func(c *config.CertificateConfig) {
+				c.ValidityDays = 91
+				c.MaxValidityDays = 90
+			},
+			wantErr: []string{"exceeds the maximum of 90 days"},
+		},
+		{
+			name: "at the maximum validity",
+			modify: func(c *config.CertificateConfig) {
+				c.ValidityDays = 90
+				c.MaxValidityDays = 90
+			},
+		},
+		{
+			name:   "no maximum validity",
+			modify: func(c *config.CertificateConfig) { c.ValidityDays = 3650 },
+		},
+		{
+			name: "validity duration above the maximum",
+			modify: func(c *config.CertificateConfig) {
+				c.ValidityDays = 30
+				c.Validity = 91 * 24 * time.Hour
+				c.MaxValidityDays = 90
+			},
+			wantErr: []string{"exceeds the maximum of 90 days"},
+		},
+		{
+			name:    "tiny key size",
+			modify:  func(c *config.CertificateConfig) { c.KeySize = 512 },
+			wantErr: []string{"key size"},
+		},
+		{
+			name:    "tiny root key size",
+			modify:  func(c *config.CertificateConfig) { c.RootKeySize = 512 },
+			wantErr: []string{"root key size must be at least 1024 bits, got 512"},
+		},
+		{
+			name:    "tiny leaf key size",
+			modify:  func(c *config.CertificateConfig) { c.LeafKeySize = 512 },
+			wantErr: []string{"leaf key size must be at least 1024 bits, got 512"},
+		},
+		{
+			name:   "separate key sizes",
+			modify: func(c *config.CertificateConfig) { c.RootKeySize, c.LeafKeySize = 4096, 2048 },
+		},
+		{
+			name:    "bad country",
+			modify:  func(c *config.CertificateConfig) { c.Country = "Singapore" },
+			wantErr: []string{"invalid country"},
+		},
+		{
+			name: "bad country allowed",
+			modify: func(c *config.CertificateConfig) {
+				c.Country = "Singapore"
+				c.AllowAnyCountry = true
+			},
+		},
+		{
+			name:    "unknown profile",
+			modify:  func(c *config.CertificateConfig) { c.Profile = "nope" },
+			wantErr: []string{"unknown profile"},
+		},
+		{
+			name: "server-only without serverAuth",
+			modify: func(c *config.CertificateConfig) {
+				c.Profile = config.ProfileClient
+				c.ServerOnly = true
+			},
+			wantErr: []string{"server-only"},
+		},
+		{
+			name: "all problems reported together",
+			modify: func(c *config.CertificateConfig) {
+				c.Domain = ""
+				c.ValidityDays = -1
+				c.KeySize = 256
+				c.Country = "XYZ"
+			},
+			wantErr: []string{"domain or IP address is required", "validity days", "key size", "invalid country"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewCertificateConfig()
+			cfg.Domain = "valid.example.com"
+			tt.modify(cfg)
+
+			err := config.Validate(cfg)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate failed: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate returned nil, want an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate error = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_NilConfig(t *testing.T) {
+	if err := config.Validate(nil); err == nil {
+		t.Error("Validate(nil) should return an error")
+	}
+}
+
+func TestMaxValidityDaysFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"90", 90, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"90d", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(config.MaxValidityEnv, tt.value)
+		got, err := config.MaxValidityDaysFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("MaxValidityDaysFromEnv() with %q error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MaxValidityDaysFromEnv() with %q = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
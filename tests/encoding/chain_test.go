@@ -0,0 +1,142 @@
+package encoding_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+// issueTestCertificate creates a certificate for cn signed by parent, or a
+// self-signed one when parent is nil.
+func issueTestCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestValidateChainOrder(t *testing.T) {
+	root, rootKey := issueTestCertificate(t, "Root", true, nil, nil)
+	inter, interKey := issueTestCertificate(t, "Intermediate", true, root, rootKey)
+	leaf, _ := issueTestCertificate(t, "Leaf", false, inter, interKey)
+	other, _ := issueTestCertificate(t, "Other", true, nil, nil)
+
+	tests := []struct {
+		name    string
+		certs   []*x509.Certificate
+		wantErr string
+	}{
+		{"ordered", []*x509.Certificate{leaf, inter, root}, ""},
+		{"without root", []*x509.Certificate{leaf, inter}, ""},
+		{"single", []*x509.Certificate{leaf}, ""},
+		{"reversed", []*x509.Certificate{root, inter, leaf}, "reversed"},
+		{"unrelated", []*x509.Certificate{leaf, other}, "certificate 1 (CN=Leaf) is not issued by certificate 2 (CN=Other)"},
+		{"gap", []*x509.Certificate{leaf, root}, "not issued by"},
+		{"empty", nil, "empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := encoding.ValidateChainOrder(tt.certs)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateChainOrder failed: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateChainOrder error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodePEMCertificates(t *testing.T) {
+	root, rootKey := issueTestCertificate(t, "Root", true, nil, nil)
+	leaf, _ := issueTestCertificate(t, "Leaf", false, root, rootKey)
+
+	var buf bytes.Buffer
+	for _, cert := range []*x509.Certificate{leaf, root} {
+		pemData, err := encoding.EncodeCertificateToPEM(cert)
+		if err != nil {
+			t.Fatalf("EncodeCertificateToPEM failed: %v", err)
+		}
+		buf.Write(pemData)
+	}
+	keyPEM, err := encoding.EncodePrivateKeyToPEM(rootKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToPEM failed: %v", err)
+	}
+	buf.Write(keyPEM)
+
+	certs, err := encoding.DecodePEMCertificates(buf.Bytes())
+	if err != nil {
+		t.Fatalf("DecodePEMCertificates failed: %v", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(leaf) || !certs[1].Equal(root) {
+		t.Errorf("DecodePEMCertificates returned %d certificates, want leaf then root", len(certs))
+	}
+
+	if _, err := encoding.DecodePEMCertificates(keyPEM); err == nil {
+		t.Error("DecodePEMCertificates succeeded without certificates, want error")
+	}
+}
+
+func TestContainsCertificate(t *testing.T) {
+	root, _ := issueTestCertificate(t, "Root", true, nil, nil)
+	other, _ := issueTestCertificate(t, "Other", true, nil, nil)
+
+	rootPEM, err := encoding.EncodeCertificateToPEM(root)
+	if err != nil {
+		t.Fatalf("EncodeCertificateToPEM failed: %v", err)
+	}
+	otherPEM, err := encoding.EncodeCertificateToPEM(other)
+	if err != nil {
+		t.Fatalf("EncodeCertificateToPEM failed: %v", err)
+	}
+	// An unparseable block must not stop the search
+	junk := []byte("-----BEGIN CERTIFICATE-----\nanVuaw==\n-----END CERTIFICATE-----\n")
+	bundle := bytes.Join([][]byte{otherPEM, junk, rootPEM}, nil)
+
+	if !encoding.ContainsCertificate(bundle, root) {
+		t.Error("ContainsCertificate() = false for a bundle holding the certificate")
+	}
+	if encoding.ContainsCertificate(otherPEM, root) {
+		t.Error("ContainsCertificate() = true for a bundle without the certificate")
+	}
+	if encoding.ContainsCertificate(nil, root) {
+		t.Error("ContainsCertificate() = true for an empty bundle")
+	}
+}
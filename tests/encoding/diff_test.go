@@ -0,0 +1,105 @@
+package encoding_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func TestDiffCertificates(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// build self-signs a certificate with the shared key, so only the
+	// fields modify touches differ from the base.
+	build := func(modify func(*x509.Certificate)) *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "svc.example.com", Organization: []string{"Acme"}},
+			DNSNames:     []string{"svc.example.com"},
+			NotBefore:    start,
+			NotAfter:     start.Add(90 * 24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		modify(template)
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("Failed to create certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("Failed to parse certificate: %v", err)
+		}
+		return cert
+	}
+	base := build(func(*x509.Certificate) {})
+
+	tests := []struct {
+		name   string
+		modify func(*x509.Certificate)
+		opts   encoding.DiffOptions
+		want   []string
+	}{
+		{"identical", func(*x509.Certificate) {}, encoding.DiffOptions{}, nil},
+		{
+			name: "rotated",
+			modify: func(c *x509.Certificate) {
+				c.SerialNumber = big.NewInt(2)
+				c.NotBefore = start.Add(60 * 24 * time.Hour)
+				c.NotAfter = start.Add(150 * 24 * time.Hour)
+			},
+			want: nil,
+		},
+		{
+			name: "rotated with serial and validity",
+			modify: func(c *x509.Certificate) {
+				c.SerialNumber = big.NewInt(2)
+				c.NotAfter = start.Add(150 * 24 * time.Hour)
+			},
+			opts: encoding.DiffOptions{IncludeSerial: true, IncludeValidity: true},
+			want: []string{"serial: 1 -> 2", "not after: 2030-04-01T00:00:00Z -> 2030-05-31T00:00:00Z"},
+		},
+		{
+			name:   "SAN",
+			modify: func(c *x509.Certificate) { c.DNSNames = append(c.DNSNames, "www.example.com") },
+			want:   []string{"DNS names: [svc.example.com] -> [svc.example.com www.example.com]"},
+		},
+		{
+			name:   "subject",
+			modify: func(c *x509.Certificate) { c.Subject.Organization = []string{"Globex"} },
+			want: []string{
+				"subject: CN=svc.example.com,O=Acme -> CN=svc.example.com,O=Globex",
+				"issuer: CN=svc.example.com,O=Acme -> CN=svc.example.com,O=Globex",
+			},
+		},
+		{
+			name:   "key usage",
+			modify: func(c *x509.Certificate) { c.ExtKeyUsage = append(c.ExtKeyUsage, x509.ExtKeyUsageClientAuth) },
+			want:   []string{"extended key usage: [serverAuth] -> [serverAuth clientAuth]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encoding.DiffCertificatesWithOptions(base, build(tt.modify), tt.opts)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("DiffCertificatesWithOptions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := encoding.DiffCertificates(base, build(func(c *x509.Certificate) { c.SerialNumber = big.NewInt(3) })); len(got) != 0 {
+		t.Errorf("DiffCertificates() = %q, want no differences for a new serial", got)
+	}
+}
@@ -2,17 +2,25 @@ package encoding_test
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/pem"
 	"math/big"
+	"os/exec"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/erfianugrah/certgen/pkg/encoding"
+	"golang.org/x/crypto/ssh"
 )
 
 func generateTestCertificate(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
@@ -118,6 +126,42 @@ func TestEncodePrivateKeyToPEM(t *testing.T) {
 	}
 }
 
+func TestEncodeSignerToPEM(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.Signer
+	}{
+		{"ECDSA", ecKey},
+		{"Ed25519", edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pemData, err := encoding.EncodeSignerToPEM(tt.key)
+			if err != nil {
+				t.Fatalf("EncodeSignerToPEM failed: %v", err)
+			}
+			decoded, err := encoding.DecodePEMSigner(pemData)
+			if err != nil {
+				t.Fatalf("DecodePEMSigner failed: %v", err)
+			}
+			pub, ok := decoded.Public().(interface{ Equal(crypto.PublicKey) bool })
+			if !ok || !pub.Equal(tt.key.Public()) {
+				t.Error("decoded key does not match the original")
+			}
+		})
+	}
+}
+
 func TestConvertPEMToDER(t *testing.T) {
 	cert, _ := generateTestCertificate(t)
 
@@ -158,6 +202,92 @@ func TestEncodeDERToBase64(t *testing.T) {
 	}
 }
 
+func TestEncodeDERToBase64Writer(t *testing.T) {
+	// Lengths cover every base64 padding case.
+	for _, n := range []int{0, 1, 2, 3, 1000, 64 * 1024} {
+		data := make([]byte, n)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("Failed to generate data: %v", err)
+		}
+
+		var buf bytes.Buffer
+		if err := encoding.EncodeDERToBase64Writer(&buf, data); err != nil {
+			t.Fatalf("EncodeDERToBase64Writer failed: %v", err)
+		}
+		if want := encoding.EncodeDERToBase64(data); buf.String() != want {
+			t.Errorf("EncodeDERToBase64Writer(%d bytes) does not match EncodeDERToBase64", n)
+		}
+	}
+}
+
+func TestPEMToCRLF(t *testing.T) {
+	cert, _ := generateTestCertificate(t)
+	certPEM, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		t.Fatalf("EncodeCertificateToPEM failed: %v", err)
+	}
+
+	crlf := encoding.PEMToCRLF(certPEM)
+	if !bytes.Contains(crlf, []byte("\r\n")) {
+		t.Fatal("PEMToCRLF output has no CRLF line endings")
+	}
+	if bytes.Contains(bytes.ReplaceAll(crlf, []byte("\r\n"), nil), []byte("\n")) {
+		t.Error("PEMToCRLF output still has bare LF line endings")
+	}
+	if again := encoding.PEMToCRLF(crlf); !bytes.Equal(again, crlf) {
+		t.Error("PEMToCRLF doubled existing CRLF line endings")
+	}
+
+	block, rest := pem.Decode(crlf)
+	if block == nil {
+		t.Fatal("pem.Decode failed on CRLF output")
+	}
+	if len(bytes.TrimSpace(rest)) != 0 {
+		t.Errorf("pem.Decode left %q", rest)
+	}
+	if !bytes.Equal(block.Bytes, cert.Raw) {
+		t.Error("CRLF PEM does not decode to the original certificate")
+	}
+
+	if !encoding.IsPEM(crlf) || encoding.IsPEM([]byte("apiVersion: v1\n")) {
+		t.Error("IsPEM misclassified its input")
+	}
+}
+
+func TestWrapBase64(t *testing.T) {
+	// Lengths cover empty output, a partial line, exactly one line (48 bytes
+	// encode to 64 characters) and several lines.
+	for _, n := range []int{0, 1, 47, 48, 49, 1000} {
+		data := make([]byte, n)
+		if _, err := rand.Read(data); err != nil {
+			t.Fatalf("Failed to generate data: %v", err)
+		}
+
+		wrapped := encoding.WrapBase64(encoding.EncodeDERToBase64(data))
+		for i, line := range strings.Split(strings.TrimSuffix(wrapped, "\n"), "\n") {
+			if len(line) > encoding.Base64LineLength {
+				t.Errorf("WrapBase64(%d bytes) line %d has %d characters, want at most %d", n, i+1, len(line), encoding.Base64LineLength)
+			}
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(wrapped, "\n", ""))
+		if err != nil {
+			t.Fatalf("Failed to decode unwrapped base64: %v", err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("WrapBase64(%d bytes) does not unwrap to the original data", n)
+		}
+
+		var buf bytes.Buffer
+		if err := encoding.EncodeDERToBase64WrappedWriter(&buf, data); err != nil {
+			t.Fatalf("EncodeDERToBase64WrappedWriter failed: %v", err)
+		}
+		if buf.String() != wrapped {
+			t.Errorf("EncodeDERToBase64WrappedWriter(%d bytes) = %q, want %q", n, buf.String(), wrapped)
+		}
+	}
+}
+
 func TestConvertCertificateToBase64DER(t *testing.T) {
 	cert, _ := generateTestCertificate(t)
 
@@ -291,3 +421,503 @@ func TestRoundTripCertificate(t *testing.T) {
 		t.Error("Round trip certificate serial number doesn't match")
 	}
 }
+
+func TestZero(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	keyPEM, err := encoding.EncodePrivateKeyToPEM(key)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyToPEM failed: %v", err)
+	}
+
+	encoding.Zero(keyPEM)
+
+	for i, b := range keyPEM {
+		if b != 0 {
+			t.Fatalf("byte %d = %#x after Zero, want 0", i, b)
+		}
+	}
+
+	// Zeroing an empty or nil slice must not panic
+	encoding.Zero(nil)
+	encoding.Zero([]byte{})
+}
+
+func TestDecodePEMCertificateRequest(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "csr.example.com"},
+		DNSNames: []string{"csr.example.com"},
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	csr, err := encoding.DecodePEMCertificateRequest(csrPEM)
+	if err != nil {
+		t.Fatalf("DecodePEMCertificateRequest failed: %v", err)
+	}
+	if csr.Subject.CommonName != "csr.example.com" {
+		t.Errorf("CSR CN = %s, want csr.example.com", csr.Subject.CommonName)
+	}
+
+	if _, err := encoding.DecodePEMCertificateRequest([]byte("invalid")); err == nil {
+		t.Error("DecodePEMCertificateRequest should fail with invalid PEM")
+	}
+}
+
+func TestEncodeCSRToPEM_RoundTrip(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	template := &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "roundtrip.example.com"},
+		DNSNames: []string{"roundtrip.example.com"},
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %v", err)
+	}
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CSR: %v", err)
+	}
+
+	csrPEM, err := encoding.EncodeCSRToPEM(csr)
+	if err != nil {
+		t.Fatalf("EncodeCSRToPEM failed: %v", err)
+	}
+
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		t.Fatal("Failed to decode PEM block")
+	}
+	if block.Type != "CERTIFICATE REQUEST" {
+		t.Errorf("PEM block type = %s, want CERTIFICATE REQUEST", block.Type)
+	}
+
+	decoded, err := encoding.DecodePEMCertificateRequest(csrPEM)
+	if err != nil {
+		t.Fatalf("DecodePEMCertificateRequest failed: %v", err)
+	}
+	if !bytes.Equal(decoded.Raw, csr.Raw) {
+		t.Error("Decoded CSR does not match original")
+	}
+	if err := decoded.CheckSignature(); err != nil {
+		t.Errorf("Decoded CSR signature verification failed: %v", err)
+	}
+}
+
+func TestEncodeCSRToPEM_Nil(t *testing.T) {
+	if _, err := encoding.EncodeCSRToPEM(nil); err == nil {
+		t.Error("EncodeCSRToPEM should fail with nil CSR")
+	}
+}
+
+func TestPrivateKeyFormats_RoundTrip(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	tests := []struct {
+		format    string
+		blockType string
+	}{
+		{encoding.KeyFormatPKCS8, "PRIVATE KEY"},
+		{encoding.KeyFormatPKCS1, "RSA PRIVATE KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			pemData, err := encoding.EncodePrivateKey(key, tt.format)
+			if err != nil {
+				t.Fatalf("EncodePrivateKey failed: %v", err)
+			}
+
+			block, _ := pem.Decode(pemData)
+			if block == nil {
+				t.Fatal("Failed to decode PEM block")
+			}
+			if block.Type != tt.blockType {
+				t.Errorf("PEM block type = %s, want %s", block.Type, tt.blockType)
+			}
+
+			decodedKey, err := encoding.DecodePEMPrivateKey(pemData)
+			if err != nil {
+				t.Fatalf("DecodePEMPrivateKey failed: %v", err)
+			}
+			if !key.Equal(decodedKey) {
+				t.Error("Decoded key does not match original")
+			}
+		})
+	}
+}
+
+func TestEncodePrivateKeyToPEMPKCS1(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	pemData := encoding.EncodePrivateKeyToPEMPKCS1(key)
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		t.Fatal("Failed to decode PEM block")
+	}
+
+	parsedKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse PKCS#1 key: %v", err)
+	}
+	if !key.Equal(parsedKey) {
+		t.Error("Parsed key does not match original")
+	}
+
+	if encoding.EncodePrivateKeyToPEMPKCS1(nil) != nil {
+		t.Error("EncodePrivateKeyToPEMPKCS1(nil) should return nil")
+	}
+}
+
+func TestEncodePrivateKey_UnknownFormat(t *testing.T) {
+	_, key := generateTestCertificate(t)
+
+	if _, err := encoding.EncodePrivateKey(key, "pem"); err == nil {
+		t.Error("EncodePrivateKey should fail with unknown format")
+	}
+}
+
+func TestDecodePEMSigner_KeyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	pkcs8PEM := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Failed to marshal PKCS#8 key: %v", err)
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+	sec1DER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal EC key: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pemData  []byte
+		wantType string
+	}{
+		{"RSA PKCS#8", pkcs8PEM(rsaKey), "RSA 2048"},
+		{"RSA PKCS#1", encoding.EncodePrivateKeyToPEMPKCS1(rsaKey), "RSA 2048"},
+		{"ECDSA PKCS#8", pkcs8PEM(ecKey), "ECDSA P-256"},
+		{"ECDSA SEC 1", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1DER}), "ECDSA P-256"},
+		{"Ed25519 PKCS#8", pkcs8PEM(edKey), "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := encoding.DecodePEMSigner(tt.pemData)
+			if err != nil {
+				t.Fatalf("DecodePEMSigner failed: %v", err)
+			}
+			if got := encoding.KeyTypeName(signer); got != tt.wantType {
+				t.Errorf("KeyTypeName = %q, want %q", got, tt.wantType)
+			}
+
+			_, err = encoding.DecodePEMPrivateKey(tt.pemData)
+			isRSA := strings.HasPrefix(tt.wantType, "RSA")
+			if isRSA && err != nil {
+				t.Errorf("DecodePEMPrivateKey failed: %v", err)
+			}
+			if !isRSA {
+				if err == nil {
+					t.Fatal("DecodePEMPrivateKey should reject non-RSA keys")
+				}
+				if !strings.Contains(err.Error(), tt.wantType) {
+					t.Errorf("Error %q does not name key type %q", err, tt.wantType)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePEMSigner_DSA(t *testing.T) {
+	// A PKCS#8 structure carrying the DSA algorithm OID; the key material is
+	// irrelevant because the standard library refuses DSA before parsing it.
+	der, err := asn1.Marshal(struct {
+		Version    int
+		Algo       pkix.AlgorithmIdentifier
+		PrivateKey []byte
+	}{
+		Algo:       pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}},
+		PrivateKey: []byte{0x02, 0x01, 0x01},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS#8 structure: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pemData []byte
+	}{
+		{"PKCS#8", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})},
+		{"traditional", pem.EncodeToMemory(&pem.Block{Type: "DSA PRIVATE KEY", Bytes: []byte{0x30, 0x00}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encoding.DecodePEMSigner(tt.pemData)
+			if err == nil {
+				t.Fatal("DecodePEMSigner should reject DSA keys")
+			}
+			if !strings.Contains(err.Error(), "DSA") {
+				t.Errorf("Error %q does not mention DSA", err)
+			}
+		})
+	}
+}
+
+func TestEncodePrivateKey_BlockTypes(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		format   string
+		wantType string
+	}{
+		{encoding.KeyFormatPKCS8, encoding.PEMTypePrivateKey},
+		{encoding.KeyFormatPKCS1, encoding.PEMTypeRSAPrivateKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			pemData, err := encoding.EncodePrivateKey(key, tt.format)
+			if err != nil {
+				t.Fatalf("EncodePrivateKey failed: %v", err)
+			}
+			block, _ := pem.Decode(pemData)
+			if block == nil {
+				t.Fatal("Failed to decode PEM block")
+			}
+			if block.Type != tt.wantType {
+				t.Errorf("Block type = %q, want %q", block.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestDecodePEMSigner_BlockTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal PKCS#8 key: %v", err)
+	}
+	pkcs1DER := x509.MarshalPKCS1PrivateKey(rsaKey)
+
+	tests := []struct {
+		name    string
+		block   *pem.Block
+		wantErr string
+	}{
+		{"PKCS#8", &pem.Block{Type: encoding.PEMTypePrivateKey, Bytes: pkcs8DER}, ""},
+		{"PKCS#1", &pem.Block{Type: encoding.PEMTypeRSAPrivateKey, Bytes: pkcs1DER}, ""},
+		{"PKCS#1 labelled as PKCS#8", &pem.Block{Type: encoding.PEMTypePrivateKey, Bytes: pkcs1DER}, "failed to parse private key"},
+		{"PKCS#8 labelled as PKCS#1", &pem.Block{Type: encoding.PEMTypeRSAPrivateKey, Bytes: pkcs8DER}, "failed to parse private key"},
+		{"encrypted PKCS#8", &pem.Block{Type: encoding.PEMTypeEncryptedPrivateKey, Bytes: []byte{0x30, 0x00}}, "encrypted"},
+		{"legacy encrypted PEM", &pem.Block{Type: encoding.PEMTypeRSAPrivateKey, Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-128-CBC,00000000000000000000000000000000"}, Bytes: pkcs1DER}, "encrypted"},
+		{"certificate", &pem.Block{Type: encoding.PEMTypeCertificate, Bytes: pkcs8DER}, "unexpected PEM block type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encoding.DecodePEMSigner(pem.EncodeToMemory(tt.block))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("DecodePEMSigner failed: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("DecodePEMSigner should fail with %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error %q does not contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodePEMPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		block *pem.Block
+	}{
+		{"public key", &pem.Block{Type: encoding.PEMTypePublicKey, Bytes: pubDER}},
+		{"private key", &pem.Block{Type: encoding.PEMTypePrivateKey, Bytes: privDER}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := encoding.DecodePEMPublicKey(pem.EncodeToMemory(tt.block))
+			if err != nil {
+				t.Fatalf("DecodePEMPublicKey failed: %v", err)
+			}
+			if !key.PublicKey.Equal(pub) {
+				t.Error("decoded public key does not match")
+			}
+		})
+	}
+
+	if _, err := encoding.DecodePEMPublicKey([]byte("not pem")); err == nil {
+		t.Error("DecodePEMPublicKey should fail on invalid PEM")
+	}
+}
+
+func TestEncodePublicKeyToSSH(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pub     crypto.PublicKey
+		keyType string
+	}{
+		{"rsa", &rsaKey.PublicKey, ssh.KeyAlgoRSA},
+		{"ecdsa", &ecKey.PublicKey, ssh.KeyAlgoECDSA256},
+		{"ed25519", edPub, ssh.KeyAlgoED25519},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.EncodePublicKeyToSSH(tt.pub)
+			if err != nil {
+				t.Fatalf("EncodePublicKeyToSSH failed: %v", err)
+			}
+			parsed, _, _, _, err := ssh.ParseAuthorizedKey(data)
+			if err != nil {
+				t.Fatalf("ParseAuthorizedKey failed: %v", err)
+			}
+			if parsed.Type() != tt.keyType {
+				t.Errorf("key type = %s, want %s", parsed.Type(), tt.keyType)
+			}
+			want, err := ssh.NewPublicKey(tt.pub)
+			if err != nil {
+				t.Fatalf("NewPublicKey failed: %v", err)
+			}
+			if !bytes.Equal(parsed.Marshal(), want.Marshal()) {
+				t.Error("parsed SSH key does not match the original")
+			}
+		})
+	}
+
+	if _, err := encoding.EncodePublicKeyToSSH("not a key"); err == nil {
+		t.Error("EncodePublicKeyToSSH succeeded for an unsupported key, want error")
+	}
+}
+
+func TestEncodePublicKeyToPEM(t *testing.T) {
+	cert, _ := generateTestCertificate(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate ECDSA key: %v", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		pub  crypto.PublicKey
+	}{
+		{"rsa from certificate", cert.PublicKey},
+		{"ecdsa", &ecKey.PublicKey},
+		{"ed25519", edPub},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encoding.EncodePublicKeyToPEM(tt.pub)
+			if err != nil {
+				t.Fatalf("EncodePublicKeyToPEM failed: %v", err)
+			}
+			block, _ := pem.Decode(data)
+			if block == nil || block.Type != encoding.PEMTypePublicKey {
+				t.Fatalf("output is not a %s PEM block: %q", encoding.PEMTypePublicKey, data)
+			}
+			parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				t.Fatalf("ParsePKIXPublicKey failed: %v", err)
+			}
+			if !parsed.(interface{ Equal(crypto.PublicKey) bool }).Equal(tt.pub) {
+				t.Error("parsed public key does not match the original")
+			}
+		})
+	}
+
+	if _, err := encoding.EncodePublicKeyToPEM("not a key"); err == nil {
+		t.Error("EncodePublicKeyToPEM succeeded for an unsupported key, want error")
+	}
+}
+
+func TestFingerprintSHA256(t *testing.T) {
+	cert, _ := generateTestCertificate(t)
+
+	got := encoding.FingerprintSHA256(cert)
+	if len(got) != 32*3-1 {
+		t.Errorf("FingerprintSHA256 = %q, want 32 colon-separated bytes", got)
+	}
+
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("OpenSSL not found in PATH")
+	}
+	pemData, err := encoding.EncodeCertificateToPEM(cert)
+	if err != nil {
+		t.Fatalf("EncodeCertificateToPEM failed: %v", err)
+	}
+	cmd := exec.Command("openssl", "x509", "-noout", "-fingerprint", "-sha256")
+	cmd.Stdin = bytes.NewReader(pemData)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("openssl x509 -fingerprint failed: %v", err)
+	}
+	_, want, _ := strings.Cut(strings.TrimSpace(string(out)), "=")
+	if got != want {
+		t.Errorf("FingerprintSHA256 = %s, want %s", got, want)
+	}
+}
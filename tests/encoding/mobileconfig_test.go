@@ -0,0 +1,78 @@
+package encoding_test
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+)
+
+func TestEncodeMobileConfig(t *testing.T) {
+	root, _ := issueTestCertificate(t, "Acme & Sons <Root CA>", true, nil, nil)
+
+	out, err := encoding.EncodeMobileConfig(root, "acme_rootCA.crt")
+	if err != nil {
+		t.Fatalf("EncodeMobileConfig failed: %v", err)
+	}
+
+	// Walk the whole document so any malformed XML fails the test, keeping
+	// the <data> payload and every <string> value.
+	var data string
+	var stringValues []string
+	dec := xml.NewDecoder(bytes.NewReader(out))
+	var element string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("mobileconfig is not well-formed XML: %v\n%s", err, out)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			element = tok.Name.Local
+		case xml.EndElement:
+			element = ""
+		case xml.CharData:
+			switch element {
+			case "data":
+				data += string(tok)
+			case "string":
+				stringValues = append(stringValues, string(tok))
+			}
+		}
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+	if err != nil {
+		t.Fatalf("Failed to decode embedded certificate: %v", err)
+	}
+	embedded, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse embedded certificate: %v", err)
+	}
+	if !embedded.Equal(root) {
+		t.Error("Embedded certificate does not match the root")
+	}
+
+	for _, want := range []string{"com.apple.security.root", "Configuration", "Acme & Sons <Root CA>", "acme_rootCA.crt"} {
+		if !slices.Contains(stringValues, want) {
+			t.Errorf("mobileconfig has no <string>%s</string>", want)
+		}
+	}
+
+	again, err := encoding.EncodeMobileConfig(root, "acme_rootCA.crt")
+	if err != nil {
+		t.Fatalf("EncodeMobileConfig failed: %v", err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Error("EncodeMobileConfig output differs between runs for the same root")
+	}
+}
@@ -0,0 +1,91 @@
+package fileio_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/fileio"
+)
+
+func TestCreatedFiles_RemoveAll(t *testing.T) {
+	dir := t.TempDir()
+	names, err := fileio.ParseNameTemplate(filepath.Join(dir, fileio.DefaultNameTemplate))
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+
+	created := fileio.NewCreatedFiles()
+	fw := fileio.NewFileWriter("test.example.com")
+	fw.SetNameTemplate(names)
+	fw.SetChecksums(true)
+	fw.SetCreatedFiles(created)
+
+	// A file from an earlier run is overwritten but must survive cleanup
+	existing := fw.GetRootCertPath()
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write existing file: %v", err)
+	}
+
+	if err := fw.WriteFile(existing, []byte("new")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := fw.WriteFile(fw.GetLeafKeyPath(), []byte("key")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := fw.WriteBase64Stream(fw.GetLeafBase64Path(), []byte{1, 2, 3}); err != nil {
+		t.Fatalf("WriteBase64Stream failed: %v", err)
+	}
+	scratch := filepath.Join(dir, "scratch")
+	if err := os.Mkdir(scratch, 0700); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	created.Add(scratch)
+
+	// Simulate an interrupt: remove what the run created
+	removed, err := created.RemoveAll()
+	if err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+
+	// The overwritten file's checksum sidecar was new, so it goes too
+	wantRemoved := []string{
+		scratch,
+		fw.GetLeafBase64Path() + ".sha256",
+		fw.GetLeafBase64Path(),
+		fw.GetLeafKeyPath() + ".sha256",
+		fw.GetLeafKeyPath(),
+		existing + ".sha256",
+	}
+	if len(removed) != len(wantRemoved) {
+		t.Fatalf("removed = %v, want %v", removed, wantRemoved)
+	}
+	for i, path := range wantRemoved {
+		if removed[i] != path {
+			t.Errorf("removed[%d] = %s, want %s", i, removed[i], path)
+		}
+		if fw.FileExists(path) {
+			t.Errorf("%s still exists after RemoveAll", path)
+		}
+	}
+
+	if !fw.FileExists(existing) {
+		t.Errorf("pre-existing %s was removed", existing)
+	}
+	if len(created.Paths()) != 0 {
+		t.Errorf("Paths() after RemoveAll = %v, want none", created.Paths())
+	}
+}
+
+func TestCreatedFiles_RemoveAllSkipsMissing(t *testing.T) {
+	created := fileio.NewCreatedFiles()
+	created.Add(filepath.Join(t.TempDir(), "never-written"))
+
+	removed, err := created.RemoveAll()
+	if err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+	if len(removed) != 0 {
+		t.Errorf("removed = %v, want none", removed)
+	}
+}
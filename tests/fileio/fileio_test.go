@@ -1,10 +1,27 @@
 package fileio_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/erfianugrah/certgen/pkg/encoding"
 	"github.com/erfianugrah/certgen/pkg/fileio"
 )
 
@@ -43,8 +60,22 @@ func TestFileWriter_Paths(t *testing.T) {
 		{"GetRootCertPath", fw.GetRootCertPath, "test_rootCA.pem"},
 		{"GetLeafKeyPath", fw.GetLeafKeyPath, "test_leaf.key"},
 		{"GetLeafCertPath", fw.GetLeafCertPath, "test_leaf.pem"},
+		{"GetLeafECDSAKeyPath", fw.GetLeafECDSAKeyPath, "test_leaf_ecdsa.key"},
+		{"GetLeafECDSACertPath", fw.GetLeafECDSACertPath, "test_leaf_ecdsa.pem"},
+		{"GetRootDERPath", fw.GetRootDERPath, "test_rootCA.der"},
+		{"GetRootCRTPath", fw.GetRootCRTPath, "test_rootCA.crt"},
+		{"GetRootMobileConfigPath", fw.GetRootMobileConfigPath, "test_rootCA.mobileconfig"},
+		{"GetLeafDERPath", fw.GetLeafDERPath, "test_leaf.der"},
 		{"GetLeafCSRPath", fw.GetLeafCSRPath, "test_leaf.csr"},
+		{"GetLeafCSRDERPath", fw.GetLeafCSRDERPath, "test_leaf.csr.der"},
+		{"GetLeafOCSPPath", fw.GetLeafOCSPPath, "test_leaf.ocsp"},
+		{"GetLeafFingerprintPath", fw.GetLeafFingerprintPath, "test_leaf.sha256.txt"},
+		{"GetLeafSSHPublicKeyPath", fw.GetLeafSSHPublicKeyPath, "test_leaf.pub"},
+		{"GetLeafPublicKeyPEMPath", fw.GetLeafPublicKeyPEMPath, "test_leaf.pub.pem"},
+		{"GetHAProxyPEMPath", fw.GetHAProxyPEMPath, "test_haproxy.pem"},
 		{"GetPKCS12Path", fw.GetPKCS12Path, "test_certs.p12"},
+		{"GetTruststorePath", fw.GetTruststorePath, "test_truststore.p12"},
+		{"GetPKCS12TrustPath", fw.GetPKCS12TrustPath, "test_trust.p12"},
 		{"GetRootBase64Path", fw.GetRootBase64Path, "test_rootCA_base64.txt"},
 		{"GetLeafBase64Path", fw.GetLeafBase64Path, "test_leaf_base64.txt"},
 	}
@@ -117,6 +148,53 @@ func TestFileWriter_WriteFile_CreateDirectory(t *testing.T) {
 	}
 }
 
+func TestFileWriter_WriteFile_ConcurrentDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "batch", "nested", "out")
+	fw := fileio.NewFileWriter("test.com")
+
+	const writers = 64
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- fw.WriteFile(filepath.Join(dir, fmt.Sprintf("leaf-%03d.pem", i)), []byte("data"))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("WriteFile failed: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read directory: %v", err)
+	}
+	if len(entries) != writers {
+		t.Errorf("directory holds %d files, want %d", len(entries), writers)
+	}
+}
+
+func TestFileWriter_WriteFile_DirectoryError(t *testing.T) {
+	tmpDir := t.TempDir()
+	blocker := filepath.Join(tmpDir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	err := fileio.NewFileWriter("test.com").WriteFile(filepath.Join(blocker, "sub", "test.pem"), []byte("data"))
+	if err == nil {
+		t.Fatal("WriteFile succeeded under a regular file, want error")
+	}
+	if !strings.Contains(err.Error(), filepath.Join(blocker, "sub")) {
+		t.Errorf("WriteFile error = %v, want it to name the directory", err)
+	}
+}
+
 func TestFileWriter_WriteBase64File(t *testing.T) {
 	// Create temp directory for testing
 	tempDir, err := os.MkdirTemp("", "fileio_test")
@@ -129,7 +207,6 @@ func TestFileWriter_WriteBase64File(t *testing.T) {
 	base64Data := "VGVzdCBiYXNlNjQgZGF0YQ=="
 	testPath := filepath.Join(tempDir, "test_base64.txt")
 
-	// Capture stdout to verify print output
 	err = fw.WriteBase64File(testPath, base64Data)
 	if err != nil {
 		t.Fatalf("WriteBase64File failed: %v", err)
@@ -151,6 +228,110 @@ func TestFileWriter_WriteBase64File(t *testing.T) {
 	}
 }
 
+func TestFileWriter_WriteBase64Stream(t *testing.T) {
+	tmpDir := t.TempDir()
+	fw := fileio.NewFileWriter("test.com")
+	fw.SetChecksums(true)
+
+	der := []byte("test DER data of some length")
+	path := filepath.Join(tmpDir, "nested", "test_leaf_base64.txt")
+	if err := fw.WriteBase64Stream(path, der); err != nil {
+		t.Fatalf("WriteBase64Stream failed: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := encoding.EncodeDERToBase64(der)
+	if string(got) != want {
+		t.Errorf("WriteBase64Stream wrote %q, want %q", got, want)
+	}
+
+	sidecar, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		t.Fatalf("Failed to read checksum file: %v", err)
+	}
+	sum := sha256.Sum256([]byte(want))
+	if wantSum := hex.EncodeToString(sum[:]) + "  test_leaf_base64.txt\n"; string(sidecar) != wantSum {
+		t.Errorf("Checksum file = %q, want %q", sidecar, wantSum)
+	}
+}
+
+func TestFileWriter_Base64Wrap(t *testing.T) {
+	tmpDir := t.TempDir()
+	fw := fileio.NewFileWriter("test.com")
+	fw.SetBase64Wrap(true)
+
+	der := bytes.Repeat([]byte("certificate DER "), 20)
+	want := encoding.WrapBase64(encoding.EncodeDERToBase64(der))
+
+	streamPath := filepath.Join(tmpDir, "stream_base64.txt")
+	if err := fw.WriteBase64Stream(streamPath, der); err != nil {
+		t.Fatalf("WriteBase64Stream failed: %v", err)
+	}
+	filePath := filepath.Join(tmpDir, "file_base64.txt")
+	if err := fw.WriteBase64File(filePath, encoding.EncodeDERToBase64(der)); err != nil {
+		t.Fatalf("WriteBase64File failed: %v", err)
+	}
+
+	for _, path := range []string{streamPath, filePath} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestFileWriter_CRLF(t *testing.T) {
+	tmpDir := t.TempDir()
+	fw := fileio.NewFileWriter("test.com")
+	fw.SetCRLF(true)
+
+	block := []byte("-----BEGIN CERTIFICATE-----\nY2VydA==\n-----END CERTIFICATE-----\n")
+	crlfBlock := encoding.PEMToCRLF(block)
+	other := []byte("apiVersion: v1\nkind: Secret\n")
+
+	pemPath := filepath.Join(tmpDir, "leaf.pem")
+	otherPath := filepath.Join(tmpDir, "secret.yaml")
+	chainPath := filepath.Join(tmpDir, "chain.pem")
+	if err := fw.WriteFile(pemPath, block); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := fw.WriteFile(otherPath, other); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := fw.AppendFile(chainPath, block); err != nil {
+			t.Fatalf("AppendFile failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want []byte
+	}{
+		{pemPath, crlfBlock},
+		{otherPath, other},
+		{chainPath, append(append([]byte{}, crlfBlock...), crlfBlock...)},
+	}
+	for _, tt := range tests {
+		got, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("%s = %q, want %q", filepath.Base(tt.path), got, tt.want)
+		}
+	}
+	if string(block) != "-----BEGIN CERTIFICATE-----\nY2VydA==\n-----END CERTIFICATE-----\n" {
+		t.Error("WriteFile modified the caller's buffer")
+	}
+}
+
 func TestFileWriter_ReadFile(t *testing.T) {
 	// Create temp directory for testing
 	tempDir, err := os.MkdirTemp("", "fileio_test")
@@ -283,3 +464,358 @@ func TestFileWriter_WriteFile_Permissions(t *testing.T) {
 		t.Errorf("File permissions = %o, want %o", perm, 0644)
 	}
 }
+
+func TestFileWriter_WriteFile_HAProxyPEMPermissions(t *testing.T) {
+	names, err := fileio.ParseNameTemplate(filepath.Join(t.TempDir(), fileio.DefaultNameTemplate))
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+	fw := fileio.NewFileWriter("test.example.com")
+	fw.SetNameTemplate(names)
+
+	// The combined file holds the leaf key despite its .pem extension
+	if err := fw.WriteFile(fw.GetHAProxyPEMPath(), []byte("key and certs")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(fw.GetHAProxyPEMPath())
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("HAProxy PEM permissions = %o, want 600", perm)
+	}
+}
+
+func TestFileWriter_Checksums(t *testing.T) {
+	tmpDir := t.TempDir()
+	fw := fileio.NewFileWriter("test.example.com")
+	fw.SetChecksums(true)
+
+	path := filepath.Join(tmpDir, "test_leaf.pem")
+	data := []byte("test certificate data")
+	if err := fw.WriteFile(path, data); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	sidecar, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		t.Fatalf("Failed to read checksum file: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:]) + "  test_leaf.pem\n"
+	if string(sidecar) != want {
+		t.Errorf("Checksum file = %q, want %q", sidecar, want)
+	}
+
+	if _, err := exec.LookPath("sha256sum"); err != nil {
+		t.Skip("sha256sum not available")
+	}
+	cmd := exec.Command("sha256sum", "-c", "test_leaf.pem.sha256")
+	cmd.Dir = tmpDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("sha256sum -c failed: %v\n%s", err, out)
+	}
+}
+
+func TestFileWriter_ChecksumsDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	fw := fileio.NewFileWriter("test.example.com")
+
+	path := filepath.Join(tmpDir, "test_leaf.pem")
+	if err := fw.WriteFile(path, []byte("data")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if fw.FileExists(path + ".sha256") {
+		t.Error("Checksum file should not be written by default")
+	}
+}
+
+func testCertificatePEM(t *testing.T, cn string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestFileWriter_AppendFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []byte
+	}{
+		{"new file", nil},
+		{"missing trailing newline", []byte("# chain")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "chain.pem")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, tt.existing, 0644); err != nil {
+					t.Fatalf("Failed to write existing file: %v", err)
+				}
+			}
+
+			fw := fileio.NewFileWriter("test.example.com")
+			for _, cn := range []string{"first", "second"} {
+				if err := fw.AppendFile(path, testCertificatePEM(t, cn)); err != nil {
+					t.Fatalf("AppendFile failed: %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read chain: %v", err)
+			}
+			var names []string
+			for {
+				var block *pem.Block
+				block, data = pem.Decode(data)
+				if block == nil {
+					break
+				}
+				cert, err := x509.ParseCertificate(block.Bytes)
+				if err != nil {
+					t.Fatalf("Failed to parse appended certificate: %v", err)
+				}
+				names = append(names, cert.Subject.CommonName)
+			}
+			if len(names) != 2 || names[0] != "first" || names[1] != "second" {
+				t.Errorf("Chain certificates = %v, want [first second]", names)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Failed to stat chain: %v", err)
+			}
+			if info.Mode().Perm() != 0644 {
+				t.Errorf("Chain permissions = %v, want 0644", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestFileWriter_AppendCertificate(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca-certificates.crt")
+	system := testCertificatePEM(t, "system")
+	if err := os.WriteFile(bundle, system, 0644); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+	root, err := encoding.DecodePEMCertificate(testCertificatePEM(t, "root"))
+	if err != nil {
+		t.Fatalf("Failed to decode certificate: %v", err)
+	}
+
+	fw := fileio.NewFileWriter("test.example.com")
+	for i, want := range []bool{true, false} {
+		appended, err := fw.AppendCertificate(bundle, root)
+		if err != nil {
+			t.Fatalf("AppendCertificate #%d failed: %v", i+1, err)
+		}
+		if appended != want {
+			t.Errorf("AppendCertificate #%d appended = %v, want %v", i+1, appended, want)
+		}
+	}
+
+	data, err := os.ReadFile(bundle)
+	if err != nil {
+		t.Fatalf("Failed to read bundle: %v", err)
+	}
+	certs, err := encoding.DecodePEMCertificates(data)
+	if err != nil {
+		t.Fatalf("Failed to decode bundle: %v", err)
+	}
+	if len(certs) != 2 || certs[0].Subject.CommonName != "system" || certs[1].Subject.CommonName != "root" {
+		t.Errorf("bundle holds %d certificates, want the system one then the root once", len(certs))
+	}
+}
+
+func TestFileWriter_AppendCertificate_NewBundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "certs", "bundle.pem")
+	root, err := encoding.DecodePEMCertificate(testCertificatePEM(t, "root"))
+	if err != nil {
+		t.Fatalf("Failed to decode certificate: %v", err)
+	}
+
+	appended, err := fileio.NewFileWriter("test.example.com").AppendCertificate(bundle, root)
+	if err != nil {
+		t.Fatalf("AppendCertificate failed: %v", err)
+	}
+	if !appended {
+		t.Error("AppendCertificate did not append to a missing bundle")
+	}
+	data, err := os.ReadFile(bundle)
+	if err != nil {
+		t.Fatalf("Failed to read bundle: %v", err)
+	}
+	if !encoding.ContainsCertificate(data, root) {
+		t.Error("bundle does not contain the root certificate")
+	}
+}
+
+func TestFileWriter_PathOverrides(t *testing.T) {
+	tmpl, err := fileio.ParseNameTemplate("out/{{.Kind}}.{{.Ext}}")
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+	fw := fileio.NewFileWriter("example.com")
+	fw.SetNameTemplate(tmpl)
+	fw.SetLeafCertPath("tls.crt")
+	fw.SetLeafKeyPath("tls-key.pem")
+
+	if got := fw.GetLeafCertPath(); got != "tls.crt" {
+		t.Errorf("GetLeafCertPath() = %s, want tls.crt", got)
+	}
+	if got := fw.GetLeafKeyPath(); got != "tls-key.pem" {
+		t.Errorf("GetLeafKeyPath() = %s, want tls-key.pem", got)
+	}
+	if got := fw.GetRootCertPath(); got != "out/rootCA.pem" {
+		t.Errorf("GetRootCertPath() = %s, want out/rootCA.pem", got)
+	}
+	if got := fw.GetLeafDERPath(); got != "out/leaf.der" {
+		t.Errorf("GetLeafDERPath() = %s, want out/leaf.der", got)
+	}
+
+	// The key override keeps key permissions even without a .key extension.
+	keyPath := filepath.Join(t.TempDir(), "tls-key.pem")
+	fw.SetLeafKeyPath(keyPath)
+	if err := fw.WriteFile(fw.GetLeafKeyPath(), []byte("key")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("key override permissions = %o, want 600", perm)
+	}
+
+	fw.SetLeafCertPath("")
+	if got := fw.GetLeafCertPath(); got != "out/leaf.pem" {
+		t.Errorf("GetLeafCertPath() after reset = %s, want out/leaf.pem", got)
+	}
+}
+
+func TestParseNameTemplate_Custom(t *testing.T) {
+	tmpl, err := fileio.ParseNameTemplate("{{.Domain}}-{{.Kind}}-{{.Ext}}")
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+	fw := fileio.NewFileWriter("api.example.com")
+	fw.SetNameTemplate(tmpl)
+
+	if got := fw.GetLeafCertPath(); got != "api.example.com-leaf-pem" {
+		t.Errorf("GetLeafCertPath() = %s, want api.example.com-leaf-pem", got)
+	}
+	if got := fw.GetRootKeyPath(); got != "api.example.com-rootCA-key" {
+		t.Errorf("GetRootKeyPath() = %s, want api.example.com-rootCA-key", got)
+	}
+}
+
+func TestParseNameTemplate_UnknownField(t *testing.T) {
+	if _, err := fileio.ParseNameTemplate("{{.Host}}_{{.Kind}}.{{.Ext}}"); err == nil {
+		t.Error("ParseNameTemplate should reject an unknown field")
+	}
+}
+
+func TestParseNameTemplate_Collisions(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"out/{{.Kind}}", "{{.Ext}}"},
+		{"{{.Subdomain}}.{{.Ext}}", "{{.Kind}}"},
+		{"{{.Domain}}", "{{.Kind}}"},
+		{"{{slice .Kind 0 4}}.{{.Ext}}", "both be written to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := fileio.ParseNameTemplate(tt.text)
+			if err == nil {
+				t.Fatal("ParseNameTemplate should reject a template whose names collide")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileWriter_KeyPermissionsCustomTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := fileio.ParseNameTemplate(filepath.Join(dir, "{{.Domain}}-{{.Kind}}-{{.Ext}}"))
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+	fw := fileio.NewFileWriter("api.example.com")
+	fw.SetNameTemplate(tmpl)
+
+	// None of these names has a .key extension
+	tests := []struct {
+		path string
+		want os.FileMode
+	}{
+		{fw.GetRootKeyPath(), 0600},
+		{fw.GetLeafKeyPath(), 0600},
+		{fw.GetLeafECDSAKeyPath(), 0600},
+		{fw.GetK8sSecretPath(), 0600},
+		{fw.GetHAProxyPEMPath(), 0600},
+		{fw.GetRootCertPath(), 0644},
+		{fw.GetLeafCertPath(), 0644},
+	}
+	for _, tt := range tests {
+		if err := fw.WriteFile(tt.path, []byte("data")); err != nil {
+			t.Fatalf("WriteFile(%s) failed: %v", tt.path, err)
+		}
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", tt.path, err)
+		}
+		if perm := info.Mode().Perm(); perm != tt.want {
+			t.Errorf("%s permissions = %o, want %o", filepath.Base(tt.path), perm, tt.want)
+		}
+	}
+}
+
+func TestFileWriter_TightensExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions are not enforced on Windows")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to create existing file: %v", err)
+	}
+
+	fw := fileio.NewFileWriter("api.example.com")
+	fw.SetJSONBundlePath(path)
+	if got := fw.GetJSONBundlePath(); got != path {
+		t.Fatalf("GetJSONBundlePath() = %s, want %s", got, path)
+	}
+	if err := fw.WriteFile(fw.GetJSONBundlePath(), []byte("new")); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat %s: %v", path, err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("existing bundle permissions = %o, want 600", perm)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("file content = %q, want %q", data, "new")
+	}
+}
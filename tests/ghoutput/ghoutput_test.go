@@ -0,0 +1,71 @@
+package ghoutput_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/ghoutput"
+)
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+	t.Setenv(ghoutput.EnvVar, path)
+
+	// Earlier steps may already have written outputs
+	if err := os.WriteFile(path, []byte("previous=step\n"), 0644); err != nil {
+		t.Fatalf("Failed to seed output file: %v", err)
+	}
+
+	if got := ghoutput.Path(); got != path {
+		t.Fatalf("Path() = %q, want %q", got, path)
+	}
+	err := ghoutput.Append(ghoutput.Path(), []ghoutput.Output{
+		{Name: "leaf_cert", Value: "example_leaf.pem"},
+		{Name: "leaf_fingerprint", Value: "AB:CD"},
+	})
+	if err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	want := "previous=step\nleaf_cert=example_leaf.pem\nleaf_fingerprint=AB:CD\n"
+	if string(got) != want {
+		t.Errorf("output file = %q, want %q", got, want)
+	}
+}
+
+func TestAppend_Multiline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+
+	if err := ghoutput.Append(path, []ghoutput.Output{{Name: "pem", Value: "line one\nline two"}}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	re := regexp.MustCompile(`^pem<<(ghadelimiter_[0-9a-f]+)\nline one\nline two\n(ghadelimiter_[0-9a-f]+)\n$`)
+	m := re.FindStringSubmatch(string(got))
+	if m == nil || m[1] != m[2] {
+		t.Errorf("output file = %q, want a delimited multiline value", got)
+	}
+}
+
+func TestAppend_InvalidName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_output")
+
+	for _, name := range []string{"", "a=b", "a<<b", "a\nb"} {
+		if err := ghoutput.Append(path, []ghoutput.Output{{Name: name, Value: "x"}}); err == nil {
+			t.Errorf("Append accepted output name %q, want error", name)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Append with invalid names created %s", path)
+	}
+}
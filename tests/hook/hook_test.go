@@ -0,0 +1,96 @@
+package hook_test
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/hook"
+)
+
+func checkCommand(t *testing.T, name string) {
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not found in PATH, skipping test", name)
+	}
+}
+
+func TestHook_Run(t *testing.T) {
+	checkCommand(t, "echo")
+
+	data := hook.Data{
+		Domain:       "hook.test.com",
+		LeafCertPath: "out dir/leaf.pem",
+		LeafKeyPath:  "leaf.key; touch injected",
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		shell   bool
+		want    string
+	}{
+		{"paths as arguments", "echo {{.Domain}} {{.LeafCertPath}}", false, "hook.test.com out dir/leaf.pem\n"},
+		{"quoted words", `echo 'cert: {{ .LeafCertPath }}' "done"`, false, "cert: out dir/leaf.pem done\n"},
+		{"no shell injection", "echo {{.LeafKeyPath}}", false, "leaf.key; touch injected\n"},
+		{"shell pipeline", "echo {{.Domain}} | tr a-z A-Z", true, "HOOK.TEST.COM\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.shell {
+				checkCommand(t, "sh")
+			}
+			h, err := hook.Parse(tt.command, tt.shell)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			output, err := h.Run(context.Background(), data)
+			if err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if string(output) != tt.want {
+				t.Errorf("Run output = %q, want %q", output, tt.want)
+			}
+		})
+	}
+}
+
+func TestHook_RunErrors(t *testing.T) {
+	checkCommand(t, "sh")
+
+	failing, err := hook.Parse("echo oops; exit 3", true)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	output, err := failing.Run(context.Background(), hook.Data{})
+	if err == nil {
+		t.Error("Run succeeded for a failing command, want error")
+	}
+	if string(output) != "oops\n" {
+		t.Errorf("Run output = %q, want the failing command's output", output)
+	}
+
+	slow, err := hook.Parse("sleep 5", false)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	slow.SetTimeout(100 * time.Millisecond)
+	if _, err := slow.Run(context.Background(), hook.Data{}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Run error = %v, want a timeout", err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, command := range []string{
+		"",
+		"echo {{.Unknown}}",
+		"echo {{.Domain",
+		`echo "unterminated`,
+	} {
+		if _, err := hook.Parse(command, false); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", command)
+		}
+	}
+}
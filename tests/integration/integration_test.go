@@ -1,11 +1,15 @@
 package integration_test
 
 import (
+	"bytes"
+	"crypto/x509"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/erfianugrah/certgen/pkg/castore"
 	"github.com/erfianugrah/certgen/pkg/certificate"
 	"github.com/erfianugrah/certgen/pkg/config"
 	"github.com/erfianugrah/certgen/pkg/encoding"
@@ -316,3 +320,280 @@ func TestErrorHandling(t *testing.T) {
 		}
 	}
 }
+
+func TestDERFileOutput(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "der.test.local"
+	cfg.KeySize = 2048
+
+	certGen := certificate.NewGenerator(cfg)
+	fileWriter := fileio.NewFileWriter(cfg.Domain)
+
+	rootCert, rootKey, err := certGen.GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate root CA: %v", err)
+	}
+	leafCert, _, err := certGen.GenerateLeafCertificate(rootCert, rootKey)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf certificate: %v", err)
+	}
+
+	rootPath := filepath.Join(tempDir, fileWriter.GetRootDERPath())
+	leafPath := filepath.Join(tempDir, fileWriter.GetLeafDERPath())
+	if err := fileWriter.WriteFile(rootPath, rootCert.Raw); err != nil {
+		t.Fatalf("Failed to write root DER: %v", err)
+	}
+	if err := fileWriter.WriteFile(leafPath, leafCert.Raw); err != nil {
+		t.Fatalf("Failed to write leaf DER: %v", err)
+	}
+
+	rootDER, err := fileWriter.ReadFile(rootPath)
+	if err != nil {
+		t.Fatalf("Failed to read root DER: %v", err)
+	}
+	parsedRoot, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("Root DER file does not parse: %v", err)
+	}
+
+	leafDER, err := fileWriter.ReadFile(leafPath)
+	if err != nil {
+		t.Fatalf("Failed to read leaf DER: %v", err)
+	}
+	parsedLeaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatalf("Leaf DER file does not parse: %v", err)
+	}
+
+	if err := parsedLeaf.CheckSignatureFrom(parsedRoot); err != nil {
+		t.Errorf("Leaf certificate signature verification failed: %v", err)
+	}
+}
+
+func TestIndexedLeavesShareRoot(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "client.test.local"
+	cfg.KeySize = 2048
+
+	rootCert, rootKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate root CA: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+
+	const count = 3
+	seen := make(map[string]bool)
+	for i := 1; i <= count; i++ {
+		leafCfg := *cfg
+		leafCfg.Domain = config.IndexedDomain(cfg.Domain, i, count)
+
+		leafCert, _, err := certificate.NewGenerator(&leafCfg).GenerateLeafCertificate(rootCert, rootKey)
+		if err != nil {
+			t.Fatalf("Failed to generate leaf %d: %v", i, err)
+		}
+
+		if _, err := leafCert.Verify(x509.VerifyOptions{
+			DNSName:   leafCfg.Domain,
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			t.Errorf("Leaf %s does not chain to the shared root: %v", leafCfg.Domain, err)
+		}
+
+		path := fileio.NewFileWriter(leafCfg.Domain).GetLeafCertPath()
+		if seen[path] {
+			t.Errorf("Leaf %s reuses file name %s", leafCfg.Domain, path)
+		}
+		seen[path] = true
+	}
+}
+
+func TestExplicitLeafDomainsShareRoot(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "ca.test.local"
+	cfg.DNSNames = []string{"extra.test.local"}
+	cfg.KeySize = 2048
+
+	rootCert, rootKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate root CA: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+
+	names, err := fileio.ParseNameTemplate(fileio.FullNameTemplate)
+	if err != nil {
+		t.Fatalf("ParseNameTemplate failed: %v", err)
+	}
+
+	domains := []string{"api.test.local", "api.test.remote", "web.test.local"}
+	seen := make(map[string]bool)
+	for _, domain := range domains {
+		leafCfg := cfg.ForDomain(domain)
+
+		leafCert, _, err := certificate.NewGenerator(leafCfg).GenerateLeafCertificate(rootCert, rootKey)
+		if err != nil {
+			t.Fatalf("Failed to generate leaf %s: %v", domain, err)
+		}
+
+		if len(leafCert.DNSNames) != 1 || leafCert.DNSNames[0] != domain {
+			t.Errorf("Leaf %s DNSNames = %v, want [%s]", domain, leafCert.DNSNames, domain)
+		}
+		if _, err := leafCert.Verify(x509.VerifyOptions{
+			DNSName:   domain,
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			t.Errorf("Leaf %s does not chain to the shared root: %v", domain, err)
+		}
+
+		fw := fileio.NewFileWriter(domain)
+		fw.SetNameTemplate(names)
+		for _, path := range []string{fw.GetLeafCertPath(), fw.GetLeafKeyPath(), fw.GetPKCS12Path()} {
+			if seen[path] {
+				t.Errorf("Leaf %s reuses file name %s", domain, path)
+			}
+			seen[path] = true
+		}
+	}
+}
+
+func TestIndexedLeavesPerTenantOrganization(t *testing.T) {
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "tenant.test.local"
+	cfg.Organization = "Shared Org"
+	cfg.KeySize = 2048
+	cfg.SubjectOverrides = map[string]config.SubjectOverride{
+		"tenant-001.test.local": {Organization: "Tenant One"},
+		"tenant-002.test.local": {Organization: "Tenant Two"},
+	}
+
+	rootCert, rootKey, err := certificate.NewGenerator(cfg).GenerateRootCA()
+	if err != nil {
+		t.Fatalf("Failed to generate root CA: %v", err)
+	}
+
+	want := []string{"Tenant One", "Tenant Two", "Shared Org"}
+	for i, org := range want {
+		leafCfg := *cfg
+		leafCfg.Domain = config.IndexedDomain(cfg.Domain, i+1, len(want))
+
+		leafCert, _, err := certificate.NewGenerator(&leafCfg).GenerateLeafCertificate(rootCert, rootKey)
+		if err != nil {
+			t.Fatalf("Failed to generate leaf %s: %v", leafCfg.Domain, err)
+		}
+		if got := leafCert.Subject.Organization; len(got) != 1 || got[0] != org {
+			t.Errorf("Leaf %s Organization = %v, want [%s]", leafCfg.Domain, got, org)
+		}
+	}
+}
+
+// buildCertgen builds the certgen command into a temporary directory and
+// returns the binary's path, so tests can drive it as a user would.
+func buildCertgen(t *testing.T) string {
+	t.Helper()
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not in PATH")
+	}
+	bin := filepath.Join(t.TempDir(), "certgen")
+	out, err := exec.Command(goBin, "build", "-o", bin, "github.com/erfianugrah/certgen/cmd/certgen").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to build certgen: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// runCertgen runs bin with args in dir, isolated from the user's config file
+// and the caller's environment overrides, and returns its combined output.
+func runCertgen(t *testing.T, bin, dir string, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "HOME="+dir, "XDG_CONFIG_HOME="+dir, "GITHUB_OUTPUT=", config.MaxValidityEnv+"=")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestIssueCommand(t *testing.T) {
+	bin := buildCertgen(t)
+	dir := t.TempDir()
+
+	if out, err := runCertgen(t, bin, dir, "--domain", "example.com", "--ca-dir", "ca", "--root-only", "--root-key-size", "2048", "--quiet"); err != nil {
+		t.Fatalf("Failed to create stored CA: %v\n%s", err, out)
+	}
+	store := castore.NewStore(filepath.Join(dir, "ca"))
+	rootPEM, err := os.ReadFile(store.CertPath())
+	if err != nil {
+		t.Fatalf("Failed to read stored CA: %v", err)
+	}
+	rootCert, err := encoding.DecodePEMCertificate(rootPEM)
+	if err != nil {
+		t.Fatalf("Failed to decode stored CA: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(rootCert)
+
+	for _, domain := range []string{"svc.example.com", "web.example.com"} {
+		out, err := runCertgen(t, bin, dir, "issue", "--ca-dir", "ca", "--domain", domain, "--key-size", "2048", "--no-pkcs12", "--quiet")
+		if err != nil {
+			t.Fatalf("certgen issue --domain %s failed: %v\n%s", domain, err, out)
+		}
+
+		fw := fileio.NewFileWriter(domain)
+		leafPEM, err := os.ReadFile(filepath.Join(dir, fw.GetLeafCertPath()))
+		if err != nil {
+			t.Fatalf("Leaf certificate for %s not written: %v", domain, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, fw.GetLeafKeyPath())); err != nil {
+			t.Errorf("Leaf key for %s not written: %v", domain, err)
+		}
+		leafCert, err := encoding.DecodePEMCertificate(leafPEM)
+		if err != nil {
+			t.Fatalf("Failed to decode leaf %s: %v", domain, err)
+		}
+		if _, err := leafCert.Verify(x509.VerifyOptions{
+			DNSName:   domain,
+			Roots:     roots,
+			KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			t.Errorf("Leaf %s does not chain to the stored CA: %v", domain, err)
+		}
+	}
+
+	after, err := os.ReadFile(store.CertPath())
+	if err != nil {
+		t.Fatalf("Failed to read stored CA: %v", err)
+	}
+	if !bytes.Equal(after, rootPEM) {
+		t.Error("certgen issue rewrote the stored root CA")
+	}
+}
+
+func TestIssueCommandMissingKey(t *testing.T) {
+	bin := buildCertgen(t)
+	dir := t.TempDir()
+
+	if out, err := runCertgen(t, bin, dir, "--domain", "example.com", "--ca-dir", "ca", "--root-only", "--root-key-size", "2048", "--quiet"); err != nil {
+		t.Fatalf("Failed to create stored CA: %v\n%s", err, out)
+	}
+	if err := os.Remove(castore.NewStore(filepath.Join(dir, "ca")).KeyPath()); err != nil {
+		t.Fatalf("Failed to remove CA key: %v", err)
+	}
+
+	out, err := runCertgen(t, bin, dir, "issue", "--ca-dir", "ca", "--domain", "svc.example.com", "--no-pkcs12")
+	if err == nil {
+		t.Fatal("certgen issue succeeded without the CA key")
+	}
+	if !strings.Contains(out, "missing rootCA.key") {
+		t.Errorf("certgen issue output = %q, want it to name the missing rootCA.key", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fileio.NewFileWriter("svc.example.com").GetLeafCertPath())); err == nil {
+		t.Error("certgen issue wrote a leaf certificate without the CA key")
+	}
+}
@@ -0,0 +1,127 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/logging"
+)
+
+func logAll(l *logging.Logger) {
+	l.Infof("Generating certificates for domain: %s\n", "example.com")
+	l.Step("Generated Root CA certificate", "")
+	l.Step("Saved leaf certificate", "example_leaf.pem")
+	l.Debugf("Using %s\n", "OpenSSL 3.0.0")
+	l.Summaryf("Generated files:\n")
+}
+
+func TestLogger_Levels(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    logging.Level
+		contains []string
+		excludes []string
+	}{
+		{"silent", logging.LevelSilent, nil, []string{"Generating", "✓", "Using", "Generated files"}},
+		{"quiet", logging.LevelQuiet, []string{"Generated files"}, []string{"Generating", "✓", "Using"}},
+		{"normal", logging.LevelNormal, []string{"Generating", "✓ Generated Root CA certificate\n", "✓ Saved leaf certificate: example_leaf.pem", "Generated files"}, []string{"Using"}},
+		{"verbose", logging.LevelVerbose, []string{"Generating", "✓", "Using OpenSSL 3.0.0", "Generated files"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := logging.New(&buf, tt.level)
+			logAll(l)
+
+			out := buf.String()
+			if tt.level == logging.LevelSilent && out != "" {
+				t.Errorf("Silent logger wrote %q, want nothing", out)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(out, s) {
+					t.Errorf("Output %q does not contain %q", out, s)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(out, s) {
+					t.Errorf("Output %q should not contain %q", out, s)
+				}
+			}
+		})
+	}
+}
+
+func TestLogger_SilentStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	originalStdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = originalStdout }()
+
+	logAll(logging.New(os.Stdout, logging.LevelSilent))
+
+	w.Close()
+	captured, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Failed to read captured stdout: %v", err)
+	}
+	if len(captured) != 0 {
+		t.Errorf("Captured stdout = %q, want nothing", captured)
+	}
+}
+
+func TestLogger_JSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		level logging.Level
+		lines int
+	}{
+		{"silent", logging.LevelSilent, 0},
+		{"quiet", logging.LevelQuiet, 1},
+		{"normal", logging.LevelNormal, 4},
+		{"verbose", logging.LevelVerbose, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logAll(logging.NewJSON(&buf, tt.level))
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if buf.Len() == 0 {
+				lines = nil
+			}
+			if len(lines) != tt.lines {
+				t.Fatalf("JSON logger wrote %d lines, want %d:\n%s", len(lines), tt.lines, buf.String())
+			}
+
+			for _, line := range lines {
+				var record map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("line %q is not valid JSON: %v", line, err)
+				}
+				if record["level"] == nil || record["msg"] == nil {
+					t.Errorf("line %q lacks level or msg", line)
+				}
+				if record["msg"] == "Saved leaf certificate" {
+					if record["step"] != "Saved leaf certificate" || record["path"] != "example_leaf.pem" {
+						t.Errorf("step line %q lacks step or path", line)
+					}
+					if _, ok := record["duration_ms"].(float64); !ok {
+						t.Errorf("step line %q lacks duration_ms", line)
+					}
+				}
+				if strings.ContainsAny(record["msg"].(string), "✓\n") {
+					t.Errorf("line %q keeps text decorations", line)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package pkcs12_test
 
 import (
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -8,10 +9,15 @@ import (
 	"math/big"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/erfianugrah/certgen/pkg/pkcs12"
+	xpkcs12 "golang.org/x/crypto/pkcs12"
 )
 
 func generateTestCertificates(t *testing.T) (*x509.Certificate, *rsa.PrivateKey, *x509.Certificate, *rsa.PrivateKey) {
@@ -138,7 +144,38 @@ func TestGeneratePKCS12_EmptyPassword(t *testing.T) {
 	}
 
 	if len(pfxData) == 0 {
-		t.Error("GeneratePKCS12 returned empty data")
+		t.Fatal("GeneratePKCS12 returned empty data")
+	}
+
+	// The bundle must carry a MAC and import with the empty password.
+	blocks, err := xpkcs12.ToPEM(pfxData, "")
+	if err != nil {
+		t.Fatalf("Failed to import empty-password bundle: %v", err)
+	}
+	var certs, keys int
+	for _, block := range blocks {
+		switch block.Type {
+		case "CERTIFICATE":
+			certs++
+		case "PRIVATE KEY":
+			keys++
+		}
+	}
+	if certs != 2 || keys != 1 {
+		t.Errorf("imported %d certificates and %d keys, want 2 and 1", certs, keys)
+	}
+
+	p12Path := filepath.Join(t.TempDir(), "empty.p12")
+	if err := os.WriteFile(p12Path, pfxData, 0644); err != nil {
+		t.Fatalf("Failed to write PKCS#12 file: %v", err)
+	}
+	cmd := exec.Command("openssl", "pkcs12", "-info", "-noout", "-in", p12Path, "-passin", "pass:")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+	}
+	if !strings.Contains(string(output), "MAC:") || strings.Contains(string(output), "MAC is absent") {
+		t.Errorf("empty-password bundle has no MAC\nOutput: %s", output)
 	}
 }
 
@@ -263,3 +300,441 @@ func TestGeneratePKCS12_MultipleCertificates(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateTempDir_Permissions(t *testing.T) {
+	base := t.TempDir()
+
+	dir, err := pkcs12.CreateTempDir(base)
+	if err != nil {
+		t.Fatalf("CreateTempDir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if filepath.Dir(dir) != base {
+		t.Errorf("CreateTempDir created %s, want a directory under %s", dir, base)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Failed to stat temp dir: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("Temp dir permissions = %o, want 0700", perm)
+	}
+}
+
+func TestGeneratePKCS12_CustomTempDirCleanedUp(t *testing.T) {
+	checkOpenSSL(t)
+
+	base := t.TempDir()
+	gen := pkcs12.NewGenerator()
+	gen.SetTempDir(base)
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, "password"); err != nil {
+		t.Fatalf("GeneratePKCS12 failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatalf("Failed to read temp base dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Temp base dir has %d leftover entries, want 0", len(entries))
+	}
+}
+
+func TestGeneratePKCS12_IncludeCA(t *testing.T) {
+	checkOpenSSL(t)
+
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	password := "testpassword"
+
+	tests := []struct {
+		name      string
+		includeCA bool
+		wantCAs   int
+	}{
+		{"default includes CA", true, 1},
+		{"CA excluded", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := pkcs12.NewGenerator()
+			gen.SetIncludeCA(tt.includeCA)
+
+			pfxData, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, password)
+			if err != nil {
+				t.Fatalf("GeneratePKCS12 failed: %v", err)
+			}
+
+			blocks, err := xpkcs12.ToPEM(pfxData, password)
+			if err != nil {
+				t.Fatalf("ToPEM failed: %v", err)
+			}
+
+			var foundLeaf bool
+			var caCerts int
+			for _, block := range blocks {
+				if block.Type != "CERTIFICATE" {
+					continue
+				}
+				switch {
+				case bytes.Equal(block.Bytes, leafCert.Raw):
+					foundLeaf = true
+				case bytes.Equal(block.Bytes, caCert.Raw):
+					caCerts++
+				default:
+					t.Error("Bundle contains an unexpected certificate")
+				}
+			}
+			if !foundLeaf {
+				t.Error("Bundle does not contain the leaf certificate")
+			}
+			if caCerts != tt.wantCAs {
+				t.Errorf("Bundle has %d CA certificates, want %d", caCerts, tt.wantCAs)
+			}
+		})
+	}
+}
+
+func TestGeneratePKCS12_CAInOpenSSLInfo(t *testing.T) {
+	checkOpenSSL(t)
+
+	gen := pkcs12.NewGenerator()
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	password := "testpassword"
+
+	pfxData, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, password)
+	if err != nil {
+		t.Fatalf("GeneratePKCS12 failed: %v", err)
+	}
+
+	p12Path := filepath.Join(t.TempDir(), "bundle.p12")
+	if err := os.WriteFile(p12Path, pfxData, 0644); err != nil {
+		t.Fatalf("Failed to write PKCS#12 file: %v", err)
+	}
+
+	cmd := exec.Command("openssl", "pkcs12", "-info", "-nokeys", "-in", p12Path, "-passin", "pass:"+password)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+	}
+
+	if got := strings.Count(string(output), "-----BEGIN CERTIFICATE-----"); got != 2 {
+		t.Errorf("openssl pkcs12 -info shows %d certificates, want 2 (leaf and CA)\nOutput: %s", got, output)
+	}
+	if !strings.Contains(string(output), "Test CA") {
+		t.Errorf("openssl pkcs12 -info output does not contain the CA certificate\nOutput: %s", output)
+	}
+}
+
+func TestGeneratePKCS12_MACOptions(t *testing.T) {
+	checkOpenSSL(t)
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		iterations int
+		want       string
+	}{
+		{"legacy sha1", "sha1", 1024, "MAC: sha1, Iteration 1024"},
+		{"sha256", "sha256", 4096, "MAC: sha256, Iteration 4096"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := pkcs12.NewGenerator()
+			gen.SetMAC(tt.algorithm, tt.iterations)
+			leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+			password := "testpassword"
+
+			pfxData, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, password)
+			if err != nil {
+				t.Fatalf("GeneratePKCS12 failed: %v", err)
+			}
+
+			p12Path := filepath.Join(t.TempDir(), "bundle.p12")
+			if err := os.WriteFile(p12Path, pfxData, 0644); err != nil {
+				t.Fatalf("Failed to write PKCS#12 file: %v", err)
+			}
+
+			cmd := exec.Command("openssl", "pkcs12", "-info", "-noout", "-in", p12Path, "-passin", "pass:"+password)
+			output, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+			}
+			if !strings.Contains(string(output), tt.want) {
+				t.Errorf("openssl pkcs12 -info output does not contain %q\nOutput: %s", tt.want, output)
+			}
+		})
+	}
+}
+
+func TestGenerateTruststore(t *testing.T) {
+	checkOpenSSL(t)
+
+	gen := pkcs12.NewGenerator()
+	_, _, caCert, _ := generateTestCertificates(t)
+	password := "testpassword"
+
+	data, err := gen.GenerateTruststore(caCert, password)
+	if err != nil {
+		t.Fatalf("GenerateTruststore failed: %v", err)
+	}
+
+	storePath := filepath.Join(t.TempDir(), "truststore.p12")
+	if err := os.WriteFile(storePath, data, 0644); err != nil {
+		t.Fatalf("Failed to write truststore: %v", err)
+	}
+
+	cmd := exec.Command("openssl", "pkcs12", "-info", "-in", storePath, "-passin", "pass:"+password, "-nodes")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+	}
+	if got := strings.Count(string(output), "-----BEGIN CERTIFICATE-----"); got != 1 {
+		t.Errorf("truststore holds %d certificates, want 1\nOutput: %s", got, output)
+	}
+	if !strings.Contains(string(output), "Test CA") {
+		t.Errorf("truststore does not contain the CA certificate\nOutput: %s", output)
+	}
+	if strings.Contains(string(output), "PRIVATE KEY") {
+		t.Errorf("truststore contains a private key\nOutput: %s", output)
+	}
+
+	if _, err := exec.LookPath("keytool"); err == nil {
+		cmd := exec.Command("keytool", "-list", "-keystore", storePath, "-storetype", "PKCS12", "-storepass", password)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("keytool -list failed: %v\nOutput: %s", err, output)
+		}
+		if !strings.Contains(string(output), pkcs12.TruststoreAlias) || !strings.Contains(string(output), "trustedCertEntry") {
+			t.Errorf("keytool -list does not show %s as a trusted entry\nOutput: %s", pkcs12.TruststoreAlias, output)
+		}
+	}
+}
+
+func TestGeneratePKCS12TrustStore(t *testing.T) {
+	checkOpenSSL(t)
+
+	gen := pkcs12.NewGenerator()
+	leafCert, _, caCert, _ := generateTestCertificates(t)
+	password := "testpassword"
+
+	data, err := gen.GeneratePKCS12TrustStore([]*x509.Certificate{leafCert, caCert}, password)
+	if err != nil {
+		t.Fatalf("GeneratePKCS12TrustStore failed: %v", err)
+	}
+
+	storePath := filepath.Join(t.TempDir(), "trust.p12")
+	if err := os.WriteFile(storePath, data, 0644); err != nil {
+		t.Fatalf("Failed to write trust store: %v", err)
+	}
+
+	cmd := exec.Command("openssl", "pkcs12", "-info", "-nokeys", "-in", storePath, "-passin", "pass:"+password)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+	}
+	if got := strings.Count(string(output), "-----BEGIN CERTIFICATE-----"); got != 2 {
+		t.Errorf("trust store holds %d certificates, want 2\nOutput: %s", got, output)
+	}
+	if strings.Contains(string(output), "Shrouded Keybag") || strings.Contains(string(output), "PRIVATE KEY") {
+		t.Errorf("trust store contains a private key\nOutput: %s", output)
+	}
+
+	// Without -nokeys openssl would print any key it found
+	cmd = exec.Command("openssl", "pkcs12", "-info", "-in", storePath, "-passin", "pass:"+password, "-nodes")
+	output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl pkcs12 -info failed: %v\nOutput: %s", err, output)
+	}
+	if strings.Contains(string(output), "PRIVATE KEY") {
+		t.Errorf("trust store contains a private key\nOutput: %s", output)
+	}
+}
+
+func TestGeneratePKCS12TrustStore_NoCertificates(t *testing.T) {
+	if _, err := pkcs12.NewGenerator().GeneratePKCS12TrustStore(nil, "password"); err == nil {
+		t.Error("expected an error for an empty certificate list")
+	}
+}
+
+func TestGenerator_SetOpenSSLPath(t *testing.T) {
+	checkOpenSSL(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper script needs a Unix shell")
+	}
+	systemOpenSSL, _ := exec.LookPath("openssl")
+
+	// A wrapper that records each call shows the override is the binary run
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	wrapper := filepath.Join(dir, "openssl-wrapper")
+	script := "#!/bin/sh\necho \"$1\" >> " + calls + "\nexec " + systemOpenSSL + " \"$@\"\n"
+	if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write wrapper: %v", err)
+	}
+
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	gen := pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(wrapper)
+
+	// Concurrent lookups resolve once to the same path
+	var wg sync.WaitGroup
+	paths := make([]string, 8)
+	for i := range paths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], _ = gen.OpenSSLPath()
+		}(i)
+	}
+	wg.Wait()
+	for _, path := range paths {
+		if path != wrapper {
+			t.Fatalf("OpenSSLPath() = %q, want %q", path, wrapper)
+		}
+	}
+
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, "password"); err != nil {
+		t.Fatalf("GeneratePKCS12 failed: %v", err)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatalf("openssl wrapper was not run: %v", err)
+	}
+	if !strings.Contains(string(data), "pkcs12") {
+		t.Errorf("wrapper calls = %q, want a pkcs12 invocation", data)
+	}
+}
+
+func TestGenerator_SetOpenSSLPath_Missing(t *testing.T) {
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	gen := pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(filepath.Join(t.TempDir(), "no-such-openssl"))
+
+	if _, err := gen.OpenSSLPath(); err == nil {
+		t.Error("OpenSSLPath succeeded for a missing binary, want error")
+	}
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, "password"); err == nil {
+		t.Error("GeneratePKCS12 succeeded with a missing openssl binary, want error")
+	}
+}
+
+func TestGeneratePKCS12_MACAlgorithmLibreSSL(t *testing.T) {
+	checkOpenSSL(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper script needs a Unix shell")
+	}
+	systemOpenSSL, _ := exec.LookPath("openssl")
+
+	// A wrapper that reports itself as LibreSSL but otherwise runs openssl
+	dir := t.TempDir()
+	wrapper := filepath.Join(dir, "openssl-wrapper")
+	script := "#!/bin/sh\nif [ \"$1\" = version ]; then echo 'LibreSSL 3.3.6'; exit 0; fi\nexec " + systemOpenSSL + " \"$@\"\n"
+	if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write wrapper: %v", err)
+	}
+
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	gen := pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(wrapper)
+	gen.SetMAC("sha256", 0)
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, "password"); err == nil || !strings.Contains(err.Error(), "LibreSSL") {
+		t.Errorf("GeneratePKCS12 error = %v, want one naming LibreSSL", err)
+	}
+
+	// Without a MAC algorithm the same binary still works
+	gen = pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(wrapper)
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, "password"); err != nil {
+		t.Errorf("GeneratePKCS12 without a MAC algorithm failed: %v", err)
+	}
+}
+
+func TestGeneratePKCS12_PasswordNotInArgs(t *testing.T) {
+	checkOpenSSL(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper script needs a Unix shell")
+	}
+	systemOpenSSL, _ := exec.LookPath("openssl")
+
+	// A wrapper that records every argument list before running openssl
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args.log")
+	wrapper := filepath.Join(dir, "openssl-wrapper")
+	script := "#!/bin/sh\necho \"$@\" >> " + argsLog + "\nexec " + systemOpenSSL + " \"$@\"\n"
+	if err := os.WriteFile(wrapper, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write wrapper: %v", err)
+	}
+
+	leafCert, leafKey, caCert, _ := generateTestCertificates(t)
+	password := "argv-secret"
+	gen := pkcs12.NewGenerator()
+	gen.SetOpenSSLPath(wrapper)
+	if _, err := gen.GeneratePKCS12(leafCert, leafKey, caCert, password); err != nil {
+		t.Fatalf("GeneratePKCS12 failed: %v", err)
+	}
+	if _, err := gen.GeneratePKCS12TrustStore([]*x509.Certificate{leafCert, caCert}, password); err != nil {
+		t.Fatalf("GeneratePKCS12TrustStore failed: %v", err)
+	}
+
+	logged, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatalf("Failed to read argument log: %v", err)
+	}
+	if !strings.Contains(string(logged), "pkcs12") {
+		t.Fatalf("wrapper was not run for pkcs12\nArguments: %s", logged)
+	}
+	if strings.Contains(string(logged), password) {
+		t.Errorf("password was passed to openssl on the command line\nArguments: %s", logged)
+	}
+}
+
+func TestGenerateTruststore_SkipsKeytool(t *testing.T) {
+	checkOpenSSL(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("shell wrapper script needs a Unix shell")
+	}
+
+	// A keytool that always fails shows whether it was chosen
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "keytool"), []byte("#!/bin/sh\necho fake keytool >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake keytool: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	_, _, caCert, _ := generateTestCertificates(t)
+
+	tests := []struct {
+		name        string
+		password    string
+		macAlg      string
+		wantKeytool bool
+	}{
+		{"long password", "password", "", true},
+		{"empty password", "", "", false},
+		{"short password", "12345", "", false},
+		{"MAC algorithm", "password", "sha256", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := pkcs12.NewGenerator()
+			gen.SetMAC(tt.macAlg, 0)
+			_, err := gen.GenerateTruststore(caCert, tt.password)
+			if tt.wantKeytool {
+				if err == nil || !strings.Contains(err.Error(), "fake keytool") {
+					t.Errorf("GenerateTruststore error = %v, want the fake keytool's failure", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("GenerateTruststore failed: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package pkcs12_test
+
+import (
+	"testing"
+
+	"github.com/erfianugrah/certgen/pkg/pkcs12"
+)
+
+func TestParseOpenSSLVersion(t *testing.T) {
+	tests := []struct {
+		output   string
+		expected pkcs12.OpenSSLVersion
+		wantArgs bool
+		jdkTrust bool
+		macAlg   bool
+	}{
+		{"OpenSSL 3.0.13 30 Jan 2024 (Library: OpenSSL 3.0.13 30 Jan 2024)\n", pkcs12.OpenSSLVersion{Variant: pkcs12.VariantOpenSSL, Major: 3, Minor: 0, Patch: 13}, true, false, true},
+		{"OpenSSL 3.2.1 30 Jan 2024", pkcs12.OpenSSLVersion{Variant: pkcs12.VariantOpenSSL, Major: 3, Minor: 2, Patch: 1}, true, true, true},
+		{"OpenSSL 1.1.1w  11 Sep 2023", pkcs12.OpenSSLVersion{Variant: pkcs12.VariantOpenSSL, Major: 1, Minor: 1, Patch: 1}, false, false, true},
+		{"LibreSSL 3.3.6", pkcs12.OpenSSLVersion{Variant: pkcs12.VariantLibreSSL, Major: 3, Minor: 3, Patch: 6}, false, false, false},
+		{"LibreSSL 2.8", pkcs12.OpenSSLVersion{Variant: pkcs12.VariantLibreSSL, Major: 2, Minor: 8}, false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			v, err := pkcs12.ParseOpenSSLVersion(tt.output)
+			if err != nil {
+				t.Fatalf("ParseOpenSSLVersion failed: %v", err)
+			}
+			if *v != tt.expected {
+				t.Errorf("ParseOpenSSLVersion = %+v, want %+v", *v, tt.expected)
+			}
+
+			args := v.ExportArgs()
+			if tt.wantArgs && len(args) == 0 {
+				t.Errorf("ExportArgs for %s is empty, want compatibility flags", v)
+			}
+			if !tt.wantArgs && len(args) != 0 {
+				t.Errorf("ExportArgs for %s = %v, want none", v, args)
+			}
+			if got := v.SupportsJDKTrust(); got != tt.jdkTrust {
+				t.Errorf("SupportsJDKTrust for %s = %v, want %v", v, got, tt.jdkTrust)
+			}
+			if got := v.SupportsMACAlg(); got != tt.macAlg {
+				t.Errorf("SupportsMACAlg for %s = %v, want %v", v, got, tt.macAlg)
+			}
+		})
+	}
+}
+
+func TestParseOpenSSLVersion_Invalid(t *testing.T) {
+	for _, output := range []string{"", "BoringSSL", "openssl: command not found"} {
+		if _, err := pkcs12.ParseOpenSSLVersion(output); err == nil {
+			t.Errorf("ParseOpenSSLVersion(%q) should fail", output)
+		}
+	}
+}
+
+func TestGenerator_OpenSSLVersion(t *testing.T) {
+	checkOpenSSL(t)
+
+	v, err := pkcs12.NewGenerator().OpenSSLVersion()
+	if err != nil {
+		t.Fatalf("OpenSSLVersion failed: %v", err)
+	}
+	if v.Variant != pkcs12.VariantOpenSSL && v.Variant != pkcs12.VariantLibreSSL {
+		t.Errorf("Variant = %s, want OpenSSL or LibreSSL", v.Variant)
+	}
+}
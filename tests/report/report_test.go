@@ -0,0 +1,89 @@
+package report_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/certgen"
+	"github.com/erfianugrah/certgen/pkg/config"
+	"github.com/erfianugrah/certgen/pkg/report"
+)
+
+func TestScan(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := config.NewCertificateConfig()
+	cfg.Domain = "report.example.com"
+	cfg.KeySize = 2048
+	cfg.ValidityDays = 10
+	cfg.SetClock(func() time.Time { return start })
+
+	bundle, err := certgen.Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	_, rootKeyPEM, leafPEM, _, err := bundle.PEM()
+	if err != nil {
+		t.Fatalf("PEM failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"leaf.pem":        leafPEM,
+		"rootCA.key.pem":  rootKeyPEM,
+		"garbage.pem":     []byte("not pem"),
+		"nested/copy.pem": leafPEM,
+		"leaf.txt":        leafPEM,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		within time.Duration
+		days   int
+		status string
+	}{
+		{"ok", start, 24 * time.Hour, 10, report.StatusOK},
+		{"expiring", start.Add(5 * 24 * time.Hour), 7 * 24 * time.Hour, 5, report.StatusExpiring},
+		{"expired", start.Add(12 * 24 * time.Hour), 7 * 24 * time.Hour, -2, report.StatusExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := report.Scan(dir, tt.within, tt.now)
+			if err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			if len(entries) != 2 {
+				t.Fatalf("len(entries) = %d, want 2", len(entries))
+			}
+			for _, e := range entries {
+				if e.DaysRemaining != tt.days {
+					t.Errorf("%s: DaysRemaining = %d, want %d", e.Path, e.DaysRemaining, tt.days)
+				}
+				if e.Status != tt.status {
+					t.Errorf("%s: Status = %q, want %q", e.Path, e.Status, tt.status)
+				}
+				if e.Subject == "" {
+					t.Errorf("%s: empty Subject", e.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestScan_MissingDir(t *testing.T) {
+	if _, err := report.Scan(filepath.Join(t.TempDir(), "missing"), time.Hour, time.Now()); err == nil {
+		t.Error("Scan succeeded for a missing directory, want error")
+	}
+}
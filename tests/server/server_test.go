@@ -0,0 +1,157 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erfianugrah/certgen/pkg/encoding"
+	"github.com/erfianugrah/certgen/pkg/server"
+)
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open zip: %v", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", f.Name, err)
+		}
+		files[f.Name] = content
+	}
+	return files
+}
+
+func TestHandler_Generate(t *testing.T) {
+	body := `{"Domain": "svc.example.com", "KeySize": 2048, "ValidityDays": 30, "PKCS12Password": "secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	server.NewHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/zip" {
+		t.Errorf("Content-Type = %s, want application/zip", ct)
+	}
+
+	files := readZip(t, rec.Body.Bytes())
+	for _, name := range []string{"svc_rootCA.key", "svc_rootCA.pem", "svc_leaf.key", "svc_leaf.pem", "svc_rootCA_base64.txt", "svc_leaf_base64.txt"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Archive is missing %s", name)
+		}
+	}
+
+	rootCert, err := encoding.DecodePEMCertificate(files["svc_rootCA.pem"])
+	if err != nil {
+		t.Fatalf("Failed to decode root certificate: %v", err)
+	}
+	leafCert, err := encoding.DecodePEMCertificate(files["svc_leaf.pem"])
+	if err != nil {
+		t.Fatalf("Failed to decode leaf certificate: %v", err)
+	}
+	if err := leafCert.CheckSignatureFrom(rootCert); err != nil {
+		t.Errorf("Leaf certificate signature verification failed: %v", err)
+	}
+	if leafCert.NotAfter.Sub(leafCert.NotBefore) != 30*24*time.Hour {
+		t.Errorf("Leaf validity = %v, want 720h", leafCert.NotAfter.Sub(leafCert.NotBefore))
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"invalid JSON", http.MethodPost, "{", http.StatusBadRequest},
+		{"missing domain", http.MethodPost, `{"KeySize": 2048}`, http.StatusBadRequest},
+		{"key too large", http.MethodPost, `{"Domain": "a.example.com", "KeySize": 16384}`, http.StatusBadRequest},
+		{"bad days", http.MethodPost, `{"Domain": "a.example.com", "KeySize": 2048, "ValidityDays": -1}`, http.StatusBadRequest},
+		{"negative validity", http.MethodPost, `{"Domain": "a.example.com", "KeySize": 2048, "Validity": -3600000000000}`, http.StatusBadRequest},
+		{"server-only client profile", http.MethodPost, `{"Domain": "a.example.com", "KeySize": 2048, "Profile": "client", "ServerOnly": true}`, http.StatusBadRequest},
+		{"above max validity", http.MethodPost, `{"Domain": "a.example.com", "KeySize": 2048, "ValidityDays": 365, "MaxValidityDays": 0}`, http.StatusBadRequest},
+		{"body too large", http.MethodPost, `{"Domain": "` + strings.Repeat("a", 1024) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := server.NewHandler()
+			handler.SetMaxBodyBytes(512)
+			handler.SetMaxValidityDays(90)
+
+			req := httptest.NewRequest(tt.method, "/generate", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("Status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_Timeout(t *testing.T) {
+	handler := server.NewHandler()
+	handler.SetTimeout(time.Nanosecond)
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"Domain": "slow.example.com", "KeySize": 4096}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandler_MaxConcurrent(t *testing.T) {
+	handler := server.NewHandler()
+	handler.SetMaxConcurrent(1)
+
+	// With one slot, requests that arrive while another is generating its
+	// 4096-bit keys are turned away instead of queueing more work
+	const requests = 3
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"Domain": "busy.example.com", "KeySize": 4096}`))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Error("429 response has no Retry-After header")
+			}
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := make(map[int]int)
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusTooManyRequests] != requests-1 {
+		t.Errorf("Status counts = %v, want one 200 and %d 429", counts, requests-1)
+	}
+}